@@ -1,9 +1,28 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/zhuravlev-pe/course-watch/internal/apiserver"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "migrate":
+			err = apiserver.Migrate(os.Args[2:])
+		case "config":
+			err = apiserver.PrintConfig(os.Args[2:], os.Stdout)
+		default:
+			err = fmt.Errorf("unknown command %q, expected migrate or config", os.Args[1])
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	apiserver.Run()
 }
@@ -2,7 +2,14 @@ package apiserver
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
 	"github.com/zhuravlev-pe/course-watch/internal/config"
 	"github.com/zhuravlev-pe/course-watch/internal/delivery/http"
 	httpV1 "github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1"
@@ -11,11 +18,25 @@ import (
 	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
 	"github.com/zhuravlev-pe/course-watch/internal/server"
 	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/migrations"
+	"github.com/zhuravlev-pe/course-watch/pkg/background"
+	"github.com/zhuravlev-pe/course-watch/pkg/health"
 	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
 	"github.com/zhuravlev-pe/course-watch/pkg/keygen"
+	"github.com/zhuravlev-pe/course-watch/pkg/logger"
+	"github.com/zhuravlev-pe/course-watch/pkg/mailer"
+	"github.com/zhuravlev-pe/course-watch/pkg/password"
 	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
-	"log"
+	"github.com/zhuravlev-pe/course-watch/pkg/tracing"
+	stdlog "log"
+	stdhttp "net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // @title Course Watch API
@@ -34,66 +55,231 @@ import (
 // @tag.name Authentication
 // @tag.description Login, logout and other security related operations
 
+// @tag.name Admin
+// @tag.description Administration, requires the admin role
+
+// backgroundWorkers bounds the work running after the response, e.g. sending magic links
+const backgroundWorkers = 8
+
 // Run initializes whole application.
 func Run() {
+	// used until the configured logger is available
+	log := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	
 	cfg, err := config.GetConfig()
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal().Err(err).Msg("failed to load configuration")
+	}
+	
+	appLog, err := logger.New(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid LOG_LEVEL")
+	}
+	log = appLog
+	// the standard logger is still used by net/http and some libraries
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(log)
+	if log.GetLevel() > zerolog.DebugLevel {
+		gin.SetMode(gin.ReleaseMode)
 	}
 	
 	idGen, err := idgen.New(cfg.SnowflakeNode)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal().Err(err).Msg("failed to create the id generator")
 	}
 	
-	pgConfig := postgres.NewPgConfig(
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
-		cfg.Postgres.Database,
-	)
+	pgConfig := cfg.Postgres.PgConfig()
 	
-	ctx, cancel := context.WithCancel(context.Background())
+	// log.Fatal would skip the deferred cleanup, so a failure while serving sets the exit code instead, which is applied
+	// after the pool is closed and the traces are flushed
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+	
+	// the context is canceled on SIGTERM or SIGINT, which starts the graceful shutdown
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
 	defer cancel()
 	
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		ServiceName:  cfg.Tracing.ServiceName,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		File:         cfg.Tracing.File,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to set up tracing")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error().Err(err).Msg("failed to flush traces")
+		}
+	}()
+	
 	pgClient, err := postgres.NewClient(ctx, pgConfig)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal().Err(err).Msg("failed to connect to Postgres")
+	}
+	prometheus.MustRegister(postgres.NewPoolCollector(pgClient))
+	
+	if cfg.Postgres.AutoMigrate {
+		migrator, err := postgres.NewMigrator(pgClient, migrations.FS)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to read the embedded migrations")
+		}
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to migrate the database")
+		}
+		log.Info().Int("applied", applied).Msg("database migrated")
 	}
-	defer pgClient.Close()
+	
+	db := createCluster(ctx, cfg, pgClient, log)
+	defer db.Close()
+	go db.Run(ctx)
 	
 	repos := &repository.Repositories{
-		Courses: fake_repo.NewCourses(),
-		Users:   repository.NewUsersRepo(pgClient),
+		Courses:         fake_repo.NewCourses(),
+		Users:           repository.NewUsersRepo(db),
+		Sessions:        repository.NewSessionsRepo(db),
+		MagicLinks:      repository.NewMagicLinksRepo(db),
+		WebAuthn:        repository.NewWebAuthnRepo(db),
+		Identities:      repository.NewIdentitiesRepo(db),
+		OAuth:           repository.NewOAuthRepo(db),
+		IdempotencyKeys: repository.NewIdempotencyKeysRepo(db),
+	}
+	repos.Transactor = repository.NewTransactionsRepo(db, repos, cfg.Postgres.TxOptions())
+	
+	magicLinkKey, err := keygen.Generate(cfg.JWTAuthentication.SigningKey, "magic-link.key", 32)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to derive the magic link key")
+	}
+	
+	idempotencyKey, err := keygen.Generate(cfg.JWTAuthentication.SigningKey, "idempotency-fingerprint.key", 32)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to derive the idempotency fingerprint key")
+	}
+	
+	idTokenSigner, err := createIdTokenSigner(cfg, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create the ID token signer")
 	}
 	
-	bearerAuth, err := createAuthenticator(cfg)
+	relyingParty, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPOrigin:      cfg.WebAuthn.RPOrigin,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		Timeout:       int(cfg.WebAuthn.CeremonyTimeout.Milliseconds()),
+	})
 	if err != nil {
-		log.Fatal(err)
+		log.Fatal().Err(err).Msg("failed to configure WebAuthn")
 	}
 	
+	// the background tasks use the pool, so they are drained before it is closed
+	tasks := background.NewTasks(backgroundWorkers)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+		defer cancel()
+		if err := tasks.Wait(ctx); err != nil {
+			log.Error().Err(err).Msg("background tasks did not finish")
+		}
+	}()
+	
 	services := service.NewServices(service.Deps{
 		Repos: repos,
 		IdGen: idGen,
+		PasswordHasher: password.NewArgon2idHasher(password.Argon2idParams{
+			Memory:      cfg.PasswordHashing.MemoryKiB,
+			Iterations:  cfg.PasswordHashing.Iterations,
+			Parallelism: cfg.PasswordHashing.Parallelism,
+			SaltLength:  cfg.PasswordHashing.SaltLength,
+			KeyLength:   cfg.PasswordHashing.KeyLength,
+		}),
+		SessionTTL: cfg.JWTAuthentication.TokenTTL,
+		Mailer:     createMailer(cfg, log),
+		MagicLink: service.MagicLinkSettings{
+			URL:          cfg.MagicLink.URL,
+			TTL:          cfg.MagicLink.TTL,
+			RateLimit:    cfg.MagicLink.RateLimit,
+			RateInterval: cfg.MagicLink.RateInterval,
+			SigningKey:   magicLinkKey,
+		},
+		WebAuthn: service.WebAuthnSettings{
+			RelyingParty:    relyingParty,
+			CeremonyTimeout: cfg.WebAuthn.CeremonyTimeout,
+		},
+		OIDC: createOIDCSettings(cfg),
+		OAuth: service.OAuthSettings{
+			Issuer:        cfg.JWTAuthentication.Issuer,
+			LoginURL:      cfg.OAuth.LoginURL,
+			CodeTTL:       cfg.OAuth.CodeTTL,
+			IdTokenSigner: idTokenSigner,
+		},
+		SCIM: service.SCIMSettings{
+			Token:       cfg.SCIM.Token,
+			MaxPageSize: cfg.SCIM.MaxPageSize,
+		},
+		Idempotency: service.IdempotencySettings{
+			TTL:            cfg.Idempotency.KeyTTL,
+			LockTimeout:    cfg.Idempotency.LockTimeout,
+			FingerprintKey: idempotencyKey,
+		},
+		Background: tasks,
 	})
+	go purgeHourly(ctx, "expired idempotency keys", services.Idempotency.PurgeExpired, log)
+	go purgeHourly(ctx, "ended magic link rate limit windows", services.MagicLinks.PurgeAttempts, log)
+	
+	// JwtHandler uses HMAC-SHA256 for signing, block size for SHA256 is 64 bytes, so the key size is the same
+	bearerKey, err := keygen.Generate(cfg.JWTAuthentication.SigningKey, "bearer-auth.key", 64)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to derive the bearer token key")
+	}
+	bearerAuth, err := createAuthenticator(cfg, bearerKey, services.Sessions)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create the authenticator")
+	}
+	// the access tokens of OAuth clients are signed with their own key, so that first-party endpoints can never
+	// accept them
+	clientKey, err := keygen.Generate(cfg.JWTAuthentication.SigningKey, "oauth-access-token.key", 64)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to derive the OAuth access token key")
+	}
+	clientAuth := createClientAuthenticator(cfg, clientKey, services.Sessions)
 	
-	handler := http.NewHandler(services, bearerAuth)
+	expectedSchema, err := migrations.LatestVersion()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to read the embedded migrations")
+	}
+	checker := health.NewChecker(cfg.HTTP.HealthCheckTimeout)
+	checker.Register("postgres", postgres.PingCheck(pgClient))
+	checker.Register("migrations", postgres.MigrationCheck(pgClient, expectedSchema))
+	checker.Register("signing_keys", signingKeysCheck(bearerKey, clientKey, magicLinkKey, idTokenSigner))
+	
+	// without replicas every read goes to the primary, there is nothing to be sticky about
+	var stickiness time.Duration
+	if len(cfg.Postgres.Replicas) > 0 {
+		stickiness = cfg.Postgres.ReplicaStickiness
+	}
+	handler := http.NewHandler(services, bearerAuth, clientAuth, log, checker, stickiness)
 	
 	srv := server.NewServer(cfg, handler.Init())
+	// stop reporting ready as soon as the shutdown starts, while the server still accepts requests
+	srv.OnShutdown(checker.ShutDown)
 	
-	log.Print("Starting server")
-	if err = srv.Run(); err != nil {
-		log.Fatal(err)
+	log.Info().Str("addr", cfg.HTTP.Host+":"+cfg.HTTP.Port).Msg("starting server")
+	if err = srv.RunUntil(ctx, cfg.HTTP.ShutdownTimeout); err != nil {
+		log.Error().Err(err).Msg("server failed")
+		exitCode = 1
+		return
 	}
+	log.Info().Msg("server stopped, closing resources")
 }
 
-func createAuthenticator(cfg *config.Config) (httpV1.BearerAuthenticator, error) {
-	// JwtHandler uses HMAC-SHA256 for signing, block size for SHA256 is 64 bytes, so the key size is the same
-	key, err := keygen.Generate(cfg.JWTAuthentication.SigningKey, "bearer-auth.key", 64)
-	if err != nil {
-		return nil, err
-	}
+func createAuthenticator(cfg *config.Config, key []byte, sessions auth.SessionValidator) (httpV1.BearerAuthenticator, error) {
 	jwtHandler := security.NewJwtHandler(
 		cfg.JWTAuthentication.Issuer,
 		cfg.JWTAuthentication.ExpectedAudience,
@@ -101,6 +287,157 @@ func createAuthenticator(cfg *config.Config) (httpV1.BearerAuthenticator, error)
 		cfg.JWTAuthentication.TokenTTL,
 		key,
 	)
-	bearerAuth := auth.NewBearerAuthenticator(jwtHandler)
+	bearerAuth := auth.NewBearerAuthenticator(jwtHandler, sessions)
+	if cfg.AuthCookies.Enabled {
+		sameSite, err := parseSameSite(cfg.AuthCookies.SameSite)
+		if err != nil {
+			return nil, err
+		}
+		bearerAuth.EnableCookies(auth.CookieSettings{Domain: cfg.AuthCookies.Domain, SameSite: sameSite})
+	}
 	return bearerAuth, nil
 }
+
+// createClientAuthenticator creates the authenticator of the access tokens issued to OAuth clients. Their audience is
+// the userinfo endpoint, the only resource they are meant for
+func createClientAuthenticator(cfg *config.Config, key []byte, sessions auth.SessionValidator) httpV1.BearerAuthenticator {
+	audience := cfg.JWTAuthentication.Issuer + "/userinfo"
+	jwtHandler := security.NewJwtHandler(
+		cfg.JWTAuthentication.Issuer,
+		audience,
+		[]string{audience},
+		cfg.JWTAuthentication.TokenTTL,
+		key,
+	)
+	return auth.NewBearerAuthenticator(jwtHandler, sessions)
+}
+
+// createIdTokenSigner loads the configured ID token keys, or generates a random key when none is configured
+func createIdTokenSigner(cfg *config.Config, log zerolog.Logger) (*security.IdTokenSigner, error) {
+	if cfg.OAuth.IdTokenKey == "" {
+		log.Warn().Msg("OAUTH_ID_TOKEN_KEY is not set, ID tokens are signed with a random key which changes on restart")
+		key, err := security.GenerateIdTokenKey()
+		if err != nil {
+			return nil, err
+		}
+		return security.NewIdTokenSigner(cfg.JWTAuthentication.Issuer, cfg.OAuth.IdTokenTTL, key)
+	}
+	key, err := security.ParseIdTokenKey([]byte(cfg.OAuth.IdTokenKey), cfg.OAuth.IdTokenKeyId)
+	if err != nil {
+		return nil, fmt.Errorf("OAUTH_ID_TOKEN_KEY: %w", err)
+	}
+	var previous []*security.IdTokenKey
+	if cfg.OAuth.PreviousIdTokenKey != "" {
+		previousKey, err := security.ParseIdTokenKey([]byte(cfg.OAuth.PreviousIdTokenKey), cfg.OAuth.PreviousIdTokenKeyId)
+		if err != nil {
+			return nil, fmt.Errorf("OAUTH_PREVIOUS_ID_TOKEN_KEY: %w", err)
+		}
+		previous = append(previous, previousKey)
+	}
+	return security.NewIdTokenSigner(cfg.JWTAuthentication.Issuer, cfg.OAuth.IdTokenTTL, key, previous...)
+}
+
+// signingKeysCheck fails when any of the keys tokens are signed with is missing
+func signingKeysCheck(bearerKey, clientKey, magicLinkKey []byte, idTokenSigner *security.IdTokenSigner) health.Check {
+	return func(context.Context) error {
+		switch {
+		case len(bearerKey) == 0:
+			return errors.New("bearer token key is not loaded")
+		case len(clientKey) == 0:
+			return errors.New("OAuth access token key is not loaded")
+		case len(magicLinkKey) == 0:
+			return errors.New("magic link key is not loaded")
+		case idTokenSigner == nil || len(idTokenSigner.KeySet().Keys) == 0:
+			return errors.New("ID token key is not loaded")
+		}
+		return nil
+	}
+}
+
+func parseSameSite(value string) (stdhttp.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "strict":
+		return stdhttp.SameSiteStrictMode, nil
+	case "lax":
+		return stdhttp.SameSiteLaxMode, nil
+	case "none":
+		return stdhttp.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("invalid AUTH_COOKIE_SAMESITE %q, expected strict, lax or none", value)
+	}
+}
+
+func createMailer(cfg *config.Config, log zerolog.Logger) mailer.Sender {
+	if cfg.SMTP.Host == "" {
+		log.Warn().Msg("SMTP_HOST is not set, outgoing emails will be written to the log")
+		return mailer.LogSender{Logger: log}
+	}
+	return mailer.NewSMTPSender(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From)
+}
+
+// purgeHourly deletes the records purge finds outdated every hour, until ctx is done. what names them in the log
+func purgeHourly(ctx context.Context, what string, purge func(ctx context.Context) (int, error), log zerolog.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		purged, err := purge(ctx)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to purge the %s", what)
+			continue
+		}
+		log.Debug().Int("purged", purged).Msgf("purged the %s", what)
+	}
+}
+
+// createCluster routes the reads to the replicas, if there are any. The replicas are connected lazily, so that a
+// replica being down does not prevent the start
+func createCluster(ctx context.Context, cfg *config.Config, primary *pgxpool.Pool, log zerolog.Logger) *postgres.Cluster {
+	var replicas []*pgxpool.Pool
+	for i, replicaConfig := range cfg.Postgres.ReplicaPgConfigs() {
+		pool, err := postgres.NewLazyClient(ctx, replicaConfig)
+		if err != nil {
+			log.Fatal().Err(err).Int("replica", i).Msg("failed to configure the Postgres replica")
+		}
+		replicas = append(replicas, pool)
+	}
+	if len(replicas) > 0 {
+		log.Info().Int("replicas", len(replicas)).Msg("routing reads to the Postgres replicas")
+	}
+	return postgres.NewCluster(primary, replicas, postgres.ClusterOptions{
+		StickyKey:     stickyKey,
+		Stickiness:    cfg.Postgres.ReplicaStickiness,
+		CheckInterval: cfg.Postgres.ReplicaCheckInterval,
+	})
+}
+
+// stickyKey keeps the reads of a user on the primary after their writes. Anonymous requests, e.g. a signup, are
+// only sticky within themselves
+func stickyKey(ctx context.Context) string {
+	if up := security.PrincipalFromContext(ctx); up != nil {
+		return "user:" + up.UserId
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		return "request:" + id
+	}
+	return ""
+}
+
+func createOIDCSettings(cfg *config.Config) service.OIDCSettings {
+	settings := service.OIDCSettings{StateTTL: cfg.OIDC.StateTTL, PostLoginURL: cfg.OIDC.PostLoginURL}
+	for _, p := range cfg.OIDC.Providers {
+		settings.Providers = append(settings.Providers, service.OIDCProviderSettings{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		})
+	}
+	return settings
+}
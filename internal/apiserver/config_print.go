@@ -0,0 +1,43 @@
+package apiserver
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/zhuravlev-pe/course-watch/internal/config"
+)
+
+const configUsage = `usage: apiserver config print [-o table|json]
+
+Prints the effective configuration, the environment layered over CONFIG_FILE, with secrets redacted`
+
+// PrintConfig runs the config subcommand. The configuration is printed even if it is invalid, followed by the
+// validation errors
+func PrintConfig(args []string, out io.Writer) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	return printConfig(cfg, args, out)
+}
+
+func printConfig(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("o", "table", "output format: table or json")
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() > 0 || (*format != "table" && *format != "json") {
+		return errors.New(configUsage)
+	}
+	if err := config.WriteSettings(out, cfg, *format == "json"); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
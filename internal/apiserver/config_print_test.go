@@ -0,0 +1,70 @@
+package apiserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/config"
+)
+
+func TestPrintConfig(t *testing.T) {
+	cases := map[string]struct {
+		args    []string
+		modify  func(*config.Config)
+		// output holds patterns the output must match
+		output  []string
+		wantErr bool
+	}{
+		"table": {
+			args:   []string{"print"},
+			output: []string{`NAME +VALUE`, `SIGNING_KEY +REDACTED`, `TOKEN_TTL +1h0m0s`},
+		},
+		"json": {
+			args:   []string{"print", "-o", "json"},
+			output: []string{`"name": "POSTGRES_PASSWORD",\s+"value": "REDACTED"`},
+		},
+		"invalid_config_is_printed": {
+			args:    []string{"print"},
+			modify:  func(c *config.Config) { c.JWTAuthentication.TokenTTL = -1 },
+			output:  []string{`TOKEN_TTL +-1ns`},
+			wantErr: true,
+		},
+		"unknown_format": {
+			args:    []string{"print", "-o", "yaml"},
+			wantErr: true,
+		},
+		"no_command": {
+			args:    []string{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(config.FileEnv, "")
+			t.Setenv("SIGNING_KEY", "signing-secret")
+			t.Setenv("POSTGRES_PASSWORD", "pg-secret")
+			cfg, err := config.Load()
+			require.NoError(t, err)
+			if tc.modify != nil {
+				tc.modify(cfg)
+			}
+			var out bytes.Buffer
+
+			err = printConfig(cfg, tc.args, &out)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, expected := range tc.output {
+				assert.Regexp(t, expected, out.String())
+			}
+			assert.NotContains(t, out.String(), "signing-secret")
+			assert.NotContains(t, out.String(), "pg-secret")
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/zhuravlev-pe/course-watch/internal/config"
+	"github.com/zhuravlev-pe/course-watch/migrations"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+const migrateUsage = `usage: apiserver migrate <command>
+
+commands:
+  up           apply all pending migrations
+  down N       revert the last N migrations
+  status       print the schema version and the pending migrations
+  force V      set the schema version to V and clear the dirty flag, without running migrations (-1 for none)`
+
+var errMigrateUsage = errors.New(migrateUsage)
+
+type schemaMigrator interface {
+	Up(ctx context.Context) (int, error)
+	Down(ctx context.Context, n int) (int, error)
+	Status(ctx context.Context) (*postgres.MigrationStatus, error)
+	Force(ctx context.Context, version int) error
+}
+
+// Migrate runs the migrate subcommand with the migrations embedded in the binary. Only the Postgres settings of the
+// configuration are required
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	cfg, err := config.GetPostgresConfig()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer cancel()
+
+	pgClient, err := postgres.NewClient(ctx, cfg.PgConfig())
+	if err != nil {
+		return err
+	}
+	defer pgClient.Close()
+	migrator, err := postgres.NewMigrator(pgClient, migrations.FS)
+	if err != nil {
+		return err
+	}
+	return runMigrate(ctx, migrator, args, os.Stdout)
+}
+
+func runMigrate(ctx context.Context, migrator schemaMigrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "applied %d migration(s)\n", applied)
+		return err
+	case args[0] == "down" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("down expects a positive number of migrations, got %q", args[1])
+		}
+		reverted, err := migrator.Down(ctx, n)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "reverted %d migration(s)\n", reverted)
+		return err
+	case args[0] == "status" && len(args) == 1:
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(out, status)
+	case args[0] == "force" && len(args) == 2:
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < postgres.NilVersion {
+			return fmt.Errorf("force expects a migration version or -1, got %q", args[1])
+		}
+		if err = migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "schema version set to %d\n", version)
+		return err
+	default:
+		return errMigrateUsage
+	}
+}
+
+func printMigrationStatus(out io.Writer, status *postgres.MigrationStatus) error {
+	version := "none"
+	if status.Version != postgres.NilVersion {
+		version = strconv.Itoa(status.Version)
+	}
+	if status.Dirty {
+		version += " (dirty)"
+	}
+	if _, err := fmt.Fprintf(out, "version: %s\nlatest:  %d\npending: %d\n", version, status.Latest, len(status.Pending)); err != nil {
+		return err
+	}
+	for _, m := range status.Pending {
+		if _, err := fmt.Fprintf(out, "  %06d_%s\n", m.Version, m.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+type fakeMigrator struct {
+	calls  []string
+	status *postgres.MigrationStatus
+	err    error
+}
+
+func (f *fakeMigrator) Up(context.Context) (int, error) {
+	f.calls = append(f.calls, "up")
+	return 2, f.err
+}
+
+func (f *fakeMigrator) Down(_ context.Context, n int) (int, error) {
+	f.calls = append(f.calls, "down")
+	return n, f.err
+}
+
+func (f *fakeMigrator) Status(context.Context) (*postgres.MigrationStatus, error) {
+	f.calls = append(f.calls, "status")
+	return f.status, f.err
+}
+
+func (f *fakeMigrator) Force(context.Context, int) error {
+	f.calls = append(f.calls, "force")
+	return f.err
+}
+
+func TestRunMigrate(t *testing.T) {
+	failure := errors.New("connection refused")
+
+	cases := map[string]struct {
+		args      []string
+		status    *postgres.MigrationStatus
+		err       error
+		calls     []string
+		output    string
+		wantErr   bool
+		wantUsage bool
+	}{
+		"up": {
+			args:   []string{"up"},
+			calls:  []string{"up"},
+			output: "applied 2 migration(s)\n",
+		},
+		"up_failure": {
+			args:    []string{"up"},
+			err:     failure,
+			calls:   []string{"up"},
+			wantErr: true,
+		},
+		"down": {
+			args:   []string{"down", "3"},
+			calls:  []string{"down"},
+			output: "reverted 3 migration(s)\n",
+		},
+		"down_without_count": {
+			args:      []string{"down"},
+			wantErr:   true,
+			wantUsage: true,
+		},
+		"down_zero": {
+			args:    []string{"down", "0"},
+			wantErr: true,
+		},
+		"status": {
+			args: []string{"status"},
+			status: &postgres.MigrationStatus{Version: 6, Latest: 7, Pending: []postgres.Migration{
+				{Version: 7, Name: "add_users_provisioning_columns"},
+			}},
+			calls:  []string{"status"},
+			output: "version: 6\nlatest:  7\npending: 1\n  000007_add_users_provisioning_columns\n",
+		},
+		"status_dirty_empty_schema": {
+			args:   []string{"status"},
+			status: &postgres.MigrationStatus{Version: postgres.NilVersion, Dirty: true, Latest: 7},
+			calls:  []string{"status"},
+			output: "version: none (dirty)\nlatest:  7\npending: 0\n",
+		},
+		"force": {
+			args:   []string{"force", "-1"},
+			calls:  []string{"force"},
+			output: "schema version set to -1\n",
+		},
+		"force_invalid": {
+			args:    []string{"force", "latest"},
+			wantErr: true,
+		},
+		"unknown_command": {
+			args:      []string{"redo"},
+			wantErr:   true,
+			wantUsage: true,
+		},
+		"no_command": {
+			args:      []string{},
+			wantErr:   true,
+			wantUsage: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			migrator := &fakeMigrator{status: tc.status, err: tc.err}
+			var out bytes.Buffer
+
+			err := runMigrate(context.Background(), migrator, tc.args, &out)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.wantUsage {
+				assert.ErrorIs(t, err, errMigrateUsage)
+			}
+			assert.Equal(t, tc.calls, migrator.calls)
+			assert.Equal(t, tc.output, out.String())
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/caarlos0/env/v6"
-	"log"
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+	"net"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -11,37 +17,312 @@ type Config struct {
 	LogLevel      string `env:"LOG_LEVEL" envDefault:"info"`
 	
 	JWTAuthentication struct {
-		SigningKey       string        `env:"SIGNING_KEY,required"`
+		SigningKey       string        `env:"SIGNING_KEY,required" redact:"true"`
 		Issuer           string        `env:"ISSUER" envDefault:"https://localhost:8080/auth"`
 		ExpectedAudience string        `env:"EXPECTED_AUDIENCE" envDefault:"https://localhost:8080"`
 		TargetAudience   []string      `env:"TARGET_AUDIENCE" envDefault:"https://localhost:8080,https://cource-watch.com"`
 		TokenTTL         time.Duration `env:"TOKEN_TTL" envDefault:"1h"`
 	}
 	
+	// AuthCookies enables the cookie session mode for browser clients: login endpoints set the access token as an
+	// HttpOnly cookie, and state-changing requests authenticated by it need a double-submit CSRF token
+	AuthCookies struct {
+		Enabled bool   `env:"AUTH_COOKIES" envDefault:"false"`
+		Domain  string `env:"AUTH_COOKIE_DOMAIN"`
+		// SameSite is one of strict, lax or none
+		SameSite string `env:"AUTH_COOKIE_SAMESITE" envDefault:"strict"`
+	}
+	
 	HTTP struct {
 		Host               string        `env:"HOST" envDefault:"localhost"`
 		Port               string        `env:"PORT" envDefault:"8080"`
 		ReadTimeout        time.Duration `env:"READ_TIMEOUT" envDefault:"5s"`
 		WriteTimeout       time.Duration `env:"WRITE_TIMEOUT" envDefault:"5s"`
 		MaxHeaderMegabytes int           `env:"MAX_HEADER_MEGABYTES" envDefault:"1"`
+		// ShutdownTimeout bounds how long requests in flight may take to complete after SIGTERM or SIGINT, and then
+		// how long background work such as sending magic links may take
+		ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"15s"`
+		// ShutdownReadinessDelay is how long the server keeps accepting requests after SIGTERM or SIGINT while
+		// reporting not ready, so that load balancers stop routing to it before it stops listening
+		ShutdownReadinessDelay time.Duration `env:"SHUTDOWN_READINESS_DELAY" envDefault:"5s"`
+		// HealthCheckTimeout limits each dependency check of the readiness endpoint
+		HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	}
+	
+	// SMTP relay for outgoing mail. When Host is empty, emails are written to the log instead
+	SMTP struct {
+		Host     string `env:"SMTP_HOST"`
+		Port     string `env:"SMTP_PORT" envDefault:"587"`
+		Username string `env:"SMTP_USERNAME"`
+		Password string `env:"SMTP_PASSWORD" redact:"true"`
+		From     string `env:"SMTP_FROM" envDefault:"no-reply@course-watch.com"`
+	}
+	
+	MagicLink struct {
+		// URL of the frontend page which exchanges the link token. The token is appended as a query parameter
+		URL          string        `env:"MAGIC_LINK_URL" envDefault:"https://localhost:8080/login/magic"`
+		TTL          time.Duration `env:"MAGIC_LINK_TTL" envDefault:"15m"`
+		RateLimit    int           `env:"MAGIC_LINK_RATE_LIMIT" envDefault:"3"`
+		RateInterval time.Duration `env:"MAGIC_LINK_RATE_INTERVAL" envDefault:"1h"`
+	}
+	
+	WebAuthn struct {
+		RPID            string        `env:"WEBAUTHN_RP_ID" envDefault:"localhost"`
+		RPOrigin        string        `env:"WEBAUTHN_RP_ORIGIN" envDefault:"https://localhost:8080"`
+		RPDisplayName   string        `env:"WEBAUTHN_RP_NAME" envDefault:"Course Watch"`
+		CeremonyTimeout time.Duration `env:"WEBAUTHN_CEREMONY_TIMEOUT" envDefault:"5m"`
+	}
+	
+	OIDC struct {
+		// Providers is a JSON array of external OpenID Connect providers users can log in with, e.g.
+		// [{"name":"corp","issuer":"https://sso.example.com","client_id":"...","client_secret":"...",
+		//   "redirect_url":"https://localhost:8080/api/v1/auth/oidc/corp/callback"}]
+		Providers OIDCProviders `env:"OIDC_PROVIDERS"`
+		StateTTL  time.Duration `env:"OIDC_STATE_TTL" envDefault:"10m"`
+		// PostLoginURL of the frontend page the browser is redirected to after logging in with a provider. Without
+		// cookie authentication, the access token is passed to it in the URL fragment
+		PostLoginURL string `env:"OIDC_POST_LOGIN_URL" envDefault:"https://localhost:8080/login/oidc"`
+	}
+	
+	// OAuth configures Course Watch as an authorization server for other apps. The endpoints are derived from
+	// JWTAuthentication.Issuer, which must therefore be the public base URL followed by /auth
+	OAuth struct {
+		// LoginURL of the frontend page unauthenticated users are redirected to from the authorization endpoint.
+		// When empty, such requests fail with 401
+		LoginURL   string        `env:"OAUTH_LOGIN_URL"`
+		CodeTTL    time.Duration `env:"OAUTH_CODE_TTL" envDefault:"1m"`
+		IdTokenTTL time.Duration `env:"OAUTH_ID_TOKEN_TTL" envDefault:"1h"`
+		// IdTokenKey is the PEM encoded RSA or EC private key ID tokens are signed with, and IdTokenKeyId its kid,
+		// by default the JWK thumbprint. To rotate the key, move it to the previous key settings, which keep it
+		// published until the tokens it signed expire. Without a key, a random one is generated on start, which is
+		// neither shared between instances nor kept across restarts
+		IdTokenKey           string `env:"OAUTH_ID_TOKEN_KEY" redact:"true"`
+		IdTokenKeyId         string `env:"OAUTH_ID_TOKEN_KEY_ID"`
+		PreviousIdTokenKey   string `env:"OAUTH_PREVIOUS_ID_TOKEN_KEY" redact:"true"`
+		PreviousIdTokenKeyId string `env:"OAUTH_PREVIOUS_ID_TOKEN_KEY_ID"`
+	}
+	
+	// SCIM provisioning endpoints are only available when Token is set
+	SCIM struct {
+		Token       string `env:"SCIM_TOKEN" redact:"true"`
+		MaxPageSize int    `env:"SCIM_MAX_PAGE_SIZE" envDefault:"100"`
+	}
+	
+	// Idempotency configures the Idempotency-Key support of the POST endpoints which create resources
+	Idempotency struct {
+		// KeyTTL is how long the response to a request is replayed to its retries
+		KeyTTL time.Duration `env:"IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
+		// LockTimeout is how long retries wait for a request which neither completed nor failed, e.g. because the
+		// server crashed, before they are processed again
+		LockTimeout time.Duration `env:"IDEMPOTENCY_LOCK_TIMEOUT" envDefault:"1m"`
+	}
+	
+	// PasswordHashing sets the argon2id cost of new password hashes. Stored hashes with other parameters (or bcrypt
+	// hashes) are upgraded on the next successful login
+	PasswordHashing struct {
+		MemoryKiB   uint32 `env:"ARGON2_MEMORY_KIB" envDefault:"65536"`
+		Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"3"`
+		Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"2"`
+		SaltLength  uint32 `env:"ARGON2_SALT_LENGTH" envDefault:"16"`
+		KeyLength   uint32 `env:"ARGON2_KEY_LENGTH" envDefault:"32"`
 	}
 	
-	Postgres struct {
-		User     string `env:"POSTGRES_USER" envDefault:"postgres"`
-		Password string `env:"POSTGRES_PASSWORD,required"`
-		Host     string `env:"POSTGRES_HOST" envDefault:"localhost"`
-		Port     string `env:"POSTGRES_PORT" envDefault:"6543"`
-		Database string `env:"POSTGRES_DATABASE" envDefault:"postgres"`
+	// Tracing exports OpenTelemetry spans. Exporter is one of none, otlp or stdout. The otlp exporter also honors the
+	// standard OTEL_EXPORTER_OTLP_* variables; stdout writes to File when it is set
+	Tracing struct {
+		Exporter     string  `env:"TRACING_EXPORTER" envDefault:"none"`
+		ServiceName  string  `env:"TRACING_SERVICE_NAME" envDefault:"course-watch"`
+		OTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT"`
+		OTLPInsecure bool    `env:"TRACING_OTLP_INSECURE" envDefault:"false"`
+		File         string  `env:"TRACING_FILE"`
+		SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
 	}
+	
+	Postgres Postgres
+}
+
+// Postgres configures the database connection, either with POSTGRES_DSN or with the individual settings. The TLS,
+// session and pool settings apply to both
+type Postgres struct {
+	DSN      string `env:"POSTGRES_DSN" redact:"true"`
+	User     string `env:"POSTGRES_USER" envDefault:"postgres"`
+	Password string `env:"POSTGRES_PASSWORD" redact:"true"`
+	Host     string `env:"POSTGRES_HOST" envDefault:"localhost"`
+	Port     string `env:"POSTGRES_PORT" envDefault:"6543"`
+	Database string `env:"POSTGRES_DATABASE" envDefault:"postgres"`
+	
+	SSLMode     string `env:"POSTGRES_SSLMODE"`
+	SSLRootCert string `env:"POSTGRES_SSLROOTCERT"`
+	SSLCert     string `env:"POSTGRES_SSLCERT"`
+	SSLKey      string `env:"POSTGRES_SSLKEY"`
+	
+	ApplicationName  string        `env:"POSTGRES_APPLICATION_NAME" envDefault:"course-watch"`
+	StatementTimeout time.Duration `env:"POSTGRES_STATEMENT_TIMEOUT" envDefault:"0"`
+	
+	// zero pool settings keep the pgxpool defaults
+	MaxConns          int32         `env:"POSTGRES_MAX_CONNS" envDefault:"0"`
+	MinConns          int32         `env:"POSTGRES_MIN_CONNS" envDefault:"0"`
+	MaxConnLifetime   time.Duration `env:"POSTGRES_MAX_CONN_LIFETIME" envDefault:"1h"`
+	MaxConnIdleTime   time.Duration `env:"POSTGRES_MAX_CONN_IDLE_TIME" envDefault:"30m"`
+	HealthCheckPeriod time.Duration `env:"POSTGRES_HEALTH_CHECK_PERIOD" envDefault:"1m"`
+	// ConnectTimeout is how long the first connection is retried, e.g. while the database is still starting
+	ConnectTimeout time.Duration `env:"POSTGRES_CONNECT_TIMEOUT" envDefault:"30s"`
+	
+	// TxIsolation is the isolation level of the units of work spanning several repositories. Transactions aborted
+	// by conflicts with concurrent ones are retried up to TxMaxRetries times
+	TxIsolation  string `env:"POSTGRES_TX_ISOLATION" envDefault:"serializable"`
+	TxMaxRetries int    `env:"POSTGRES_TX_MAX_RETRIES" envDefault:"3"`
+	
+	// Replicas receive the read-only queries. Each is either a DSN or a host[:port], which is connected to with the
+	// primary's other settings. A user's reads stay on the primary for ReplicaStickiness after their own writes. The
+	// instance which served the write remembers it, other instances only know of it from the cookie returned to the
+	// client, so clients without cookies may read stale data from another instance
+	Replicas             []string      `env:"POSTGRES_REPLICAS" redact:"true"`
+	ReplicaStickiness    time.Duration `env:"POSTGRES_REPLICA_STICKINESS" envDefault:"5s"`
+	ReplicaCheckInterval time.Duration `env:"POSTGRES_REPLICA_CHECK_INTERVAL" envDefault:"5s"`
+	
+	// AutoMigrate applies pending migrations on start. Replicas starting at the same time wait for each other
+	AutoMigrate bool `env:"AUTO_MIGRATE" envDefault:"false"`
 }
 
+type OIDCProvider struct {
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret" redact:"true"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+}
+
+type OIDCProviders []OIDCProvider
+
+func (p *OIDCProviders) UnmarshalText(text []byte) error {
+	return json.Unmarshal(text, (*[]OIDCProvider)(p))
+}
+
+// FileEnv names the optional YAML or TOML configuration file. Environment variables take precedence over its settings
+const FileEnv = "CONFIG_FILE"
+
+// GetConfig loads and validates the configuration
 func GetConfig() (*Config, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	
+	return cfg, nil
+}
+
+// Load reads the configuration from the environment, layered over the configuration file, without validating it
+func Load() (*Config, error) {
+	environment, err := environment()
+	if err != nil {
+		return nil, err
+	}
 	cfg := &Config{}
 	
-	log.Println("Gathering config...")
-	if err := env.Parse(cfg); err != nil {
+	if err = env.Parse(cfg, env.Options{Environment: environment}); err != nil {
+		return nil, err
+	}
+	
+	return cfg, nil
+}
+
+// PgConfig converts the settings to the options of the connection pool
+func (p *Postgres) PgConfig() *postgres.PgConfig {
+	return &postgres.PgConfig{
+		DSN:               p.DSN,
+		Username:          p.User,
+		Password:          p.Password,
+		Host:              p.Host,
+		Port:              p.Port,
+		Database:          p.Database,
+		SSLMode:           p.SSLMode,
+		SSLRootCert:       p.SSLRootCert,
+		SSLCert:           p.SSLCert,
+		SSLKey:            p.SSLKey,
+		ApplicationName:   p.ApplicationName,
+		StatementTimeout:  p.StatementTimeout,
+		MaxConns:          p.MaxConns,
+		MinConns:          p.MinConns,
+		MaxConnLifetime:   p.MaxConnLifetime,
+		MaxConnIdleTime:   p.MaxConnIdleTime,
+		HealthCheckPeriod: p.HealthCheckPeriod,
+		ConnectTimeout:    p.ConnectTimeout,
+	}
+}
+
+// TxOptions converts the transaction settings, e.g. repeatable-read to pgx.RepeatableRead
+func (p *Postgres) TxOptions() postgres.TxOptions {
+	return postgres.TxOptions{
+		IsoLevel:   pgx.TxIsoLevel(strings.ReplaceAll(strings.ToLower(p.TxIsolation), "-", " ")),
+		MaxRetries: p.TxMaxRetries,
+	}
+}
+
+// ReplicaPgConfigs converts the replica settings to the options of their connection pools
+func (p *Postgres) ReplicaPgConfigs() []*postgres.PgConfig {
+	result := make([]*postgres.PgConfig, 0, len(p.Replicas))
+	for _, replica := range p.Replicas {
+		cfg := p.PgConfig()
+		cfg.ConnectTimeout = 0
+		if isDSN(replica) {
+			cfg.DSN = replica
+		} else {
+			cfg.Host, cfg.Port = splitHostPort(replica, p.Port)
+		}
+		result = append(result, cfg)
+	}
+	return result
+}
+
+func isDSN(s string) bool {
+	return strings.Contains(s, "://") || strings.Contains(s, "=")
+}
+
+func splitHostPort(address string, defaultPort string) (string, string) {
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		return host, port
+	}
+	return address, defaultPort
+}
+
+// GetPostgresConfig reads only the database settings, for commands which do not run the server
+func GetPostgresConfig() (*Postgres, error) {
+	environment, err := environment()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Postgres{}
+	
+	if err = env.Parse(cfg, env.Options{Environment: environment}); err != nil {
 		return nil, err
 	}
 	
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	
 	return cfg, nil
 }
+
+// environment merges the environment variables over the settings of the configuration file, if there is one
+func environment() (map[string]string, error) {
+	result := map[string]string{}
+	if path := os.Getenv(FileEnv); path != "" {
+		var err error
+		if result, err = readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads a YAML or TOML configuration file. Its keys are the environment variable names, case-insensitive,
+// e.g. TOKEN_TTL: 2h. Lists of values become comma separated values, and nested objects, such as the entries of
+// OIDC_PROVIDERS, become JSON
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	case ".toml":
+		err = toml.Unmarshal(content, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, expected .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	known := make(map[string]bool)
+	for _, s := range Settings(&Config{}) {
+		known[s.Name] = true
+	}
+	var unknown []string
+	result := make(map[string]string, len(raw))
+	for key, value := range raw {
+		name := strings.ToUpper(key)
+		if !known[name] {
+			unknown = append(unknown, key)
+			continue
+		}
+		if result[name], err = formatFileValue(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown settings in %s: %s", path, strings.Join(unknown, ", "))
+	}
+	return result, nil
+}
+
+func formatFileValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, isObject := item.(map[string]interface{}); isObject {
+				b, err := json.Marshal(v)
+				return string(b), err
+			}
+			s, err := formatFileValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
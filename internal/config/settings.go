@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Redacted replaces the values of fields tagged redact:"true", so that secrets never end up in command output or logs
+const Redacted = "REDACTED"
+
+// Setting is the effective value of a configuration variable
+type Setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Settings lists the configuration by environment variable, in declaration order. Secrets are redacted, and left
+// empty when not set, so that it is still visible whether they are configured
+func Settings(cfg *Config) []Setting {
+	var result []Setting
+	collectSettings(reflect.ValueOf(cfg).Elem(), &result)
+	return result
+}
+
+// WriteSettings prints the redacted settings as a table, or as JSON
+func WriteSettings(w io.Writer, cfg *Config, asJSON bool) error {
+	settings := Settings(cfg)
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(settings)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVALUE")
+	for _, s := range settings {
+		fmt.Fprintf(tw, "%s\t%s\n", s.Name, s.Value)
+	}
+	return tw.Flush()
+}
+
+func collectSettings(v reflect.Value, result *[]Setting) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+		if name == "" {
+			if value.Kind() == reflect.Struct {
+				collectSettings(value, result)
+			}
+			continue
+		}
+		*result = append(*result, Setting{Name: name, Value: formatSetting(value, field.Tag.Get("redact") == "true")})
+	}
+}
+
+func formatSetting(value reflect.Value, secret bool) string {
+	if secret {
+		if value.IsZero() {
+			return ""
+		}
+		return Redacted
+	}
+	switch {
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct:
+		// lists of structs, e.g. OIDC providers, are configured as JSON
+		items := make([]map[string]interface{}, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, redactedFields(value.Index(i)))
+		}
+		b, err := json.Marshal(items)
+		if err != nil {
+			return err.Error()
+		}
+		return string(b)
+	case value.Kind() == reflect.Slice:
+		items := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, fmt.Sprint(value.Index(i).Interface()))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// redactedFields maps the struct to its JSON field names with the secrets redacted
+func redactedFields(v reflect.Value) map[string]interface{} {
+	t := v.Type()
+	result := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		value := v.Field(i).Interface()
+		if field.Tag.Get("redact") == "true" && !v.Field(i).IsZero() {
+			value = Redacted
+		}
+		result[name] = value
+	}
+	return result
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings(t *testing.T) {
+	cfg := &Config{}
+	cfg.LogLevel = "debug"
+	cfg.JWTAuthentication.SigningKey = "very-secret-key"
+	cfg.JWTAuthentication.TargetAudience = []string{"https://a.example.com", "https://b.example.com"}
+	cfg.JWTAuthentication.TokenTTL = time.Hour
+	cfg.Postgres.Password = "pg-secret"
+	cfg.OIDC.Providers = OIDCProviders{{Name: "corp", ClientID: "cw", ClientSecret: "oidc-secret"}}
+
+	settings := Settings(cfg)
+
+	values := make(map[string]string, len(settings))
+	for _, s := range settings {
+		values[s.Name] = s.Value
+		for _, secret := range []string{"very-secret-key", "pg-secret", "oidc-secret"} {
+			assert.NotContains(t, s.Value, secret, s.Name)
+		}
+	}
+	assert.Equal(t, "SNOWFLAKE_NODE", settings[0].Name)
+	assert.Equal(t, "debug", values["LOG_LEVEL"])
+	assert.Equal(t, Redacted, values["SIGNING_KEY"])
+	assert.Equal(t, Redacted, values["POSTGRES_PASSWORD"])
+	assert.Equal(t, "", values["SMTP_PASSWORD"], "unset secrets stay empty")
+	assert.Equal(t, "https://a.example.com,https://b.example.com", values["TARGET_AUDIENCE"])
+	assert.Equal(t, "1h0m0s", values["TOKEN_TTL"])
+	assert.True(t, strings.Contains(values["OIDC_PROVIDERS"], `"client_id":"cw"`), values["OIDC_PROVIDERS"])
+	assert.True(t, strings.Contains(values["OIDC_PROVIDERS"], `"client_secret":"REDACTED"`), values["OIDC_PROVIDERS"])
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+// maxSnowflakeNode is the largest node number that fits the 10 bits snowflake ids reserve for it
+const maxSnowflakeNode = 1023
+
+var (
+	positive = validation.By(func(value interface{}) error {
+		if d, ok := value.(time.Duration); ok && d <= 0 {
+			return errors.New("must be positive")
+		}
+		if n, ok := value.(int); ok && n <= 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	})
+	// idTokenKey checks that a PEM key can sign ID tokens. The error does not quote the key
+	idTokenKey = validation.By(func(value interface{}) error {
+		s, _ := value.(string)
+		if s == "" {
+			return nil
+		}
+		_, err := security.ParseIdTokenKey([]byte(s), "")
+		return err
+	})
+	oneOf = func(values ...string) validation.Rule {
+		return validation.By(func(value interface{}) error {
+			s, _ := value.(string)
+			for _, v := range values {
+				if strings.EqualFold(s, v) {
+					return nil
+				}
+			}
+			return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+		})
+	}
+)
+
+// Validate checks the whole configuration and reports all problems at once, keyed by environment variable
+func (c *Config) Validate() error {
+	errs := validation.Errors{
+		"SNOWFLAKE_NODE": validation.Validate(c.SnowflakeNode, validation.Min(0), validation.Max(maxSnowflakeNode)),
+		"LOG_LEVEL":      validation.Validate(c.LogLevel, oneOf("trace", "debug", "info", "warn", "error", "fatal", "panic", "disabled")),
+
+		"SIGNING_KEY":       validation.Validate(c.JWTAuthentication.SigningKey, validation.Required),
+		"ISSUER":            validation.Validate(c.JWTAuthentication.Issuer, validation.Required, is.URL),
+		"EXPECTED_AUDIENCE": validation.Validate(c.JWTAuthentication.ExpectedAudience, validation.Required),
+		"TARGET_AUDIENCE":   validation.Validate(c.JWTAuthentication.TargetAudience, validation.Required),
+		"TOKEN_TTL":         validation.Validate(c.JWTAuthentication.TokenTTL, positive),
+
+		"AUTH_COOKIE_SAMESITE": validation.Validate(c.AuthCookies.SameSite, oneOf("strict", "lax", "none")),
+
+		"PORT":                     validation.Validate(c.HTTP.Port, validation.Required, is.Port),
+		"READ_TIMEOUT":             validation.Validate(c.HTTP.ReadTimeout, positive),
+		"WRITE_TIMEOUT":            validation.Validate(c.HTTP.WriteTimeout, positive),
+		"MAX_HEADER_MEGABYTES":     validation.Validate(c.HTTP.MaxHeaderMegabytes, positive),
+		"SHUTDOWN_TIMEOUT":         validation.Validate(c.HTTP.ShutdownTimeout, positive),
+		"SHUTDOWN_READINESS_DELAY": validation.Validate(c.HTTP.ShutdownReadinessDelay, validation.Min(time.Duration(0))),
+		"HEALTH_CHECK_TIMEOUT":     validation.Validate(c.HTTP.HealthCheckTimeout, positive),
+
+		"SMTP_PORT": validation.Validate(c.SMTP.Port, validation.When(c.SMTP.Host != "", validation.Required, is.Port)),
+		"SMTP_FROM": validation.Validate(c.SMTP.From, validation.When(c.SMTP.Host != "", validation.Required, is.EmailFormat)),
+
+		"MAGIC_LINK_URL":           validation.Validate(c.MagicLink.URL, validation.Required, is.URL),
+		"MAGIC_LINK_TTL":           validation.Validate(c.MagicLink.TTL, positive),
+		"MAGIC_LINK_RATE_LIMIT":    validation.Validate(c.MagicLink.RateLimit, positive),
+		"MAGIC_LINK_RATE_INTERVAL": validation.Validate(c.MagicLink.RateInterval, positive),
+
+		"WEBAUTHN_RP_ID":            validation.Validate(c.WebAuthn.RPID, validation.Required),
+		"WEBAUTHN_RP_ORIGIN":        validation.Validate(c.WebAuthn.RPOrigin, validation.Required, is.URL),
+		"WEBAUTHN_CEREMONY_TIMEOUT": validation.Validate(c.WebAuthn.CeremonyTimeout, positive),
+
+		"OIDC_PROVIDERS":      validation.Validate(c.OIDC.Providers),
+		"OIDC_STATE_TTL":      validation.Validate(c.OIDC.StateTTL, positive),
+		"OIDC_POST_LOGIN_URL": validation.Validate(c.OIDC.PostLoginURL, validation.Required, is.URL),
+
+		"OAUTH_LOGIN_URL":    validation.Validate(c.OAuth.LoginURL, is.URL),
+		"OAUTH_CODE_TTL":     validation.Validate(c.OAuth.CodeTTL, positive),
+		"OAUTH_ID_TOKEN_TTL": validation.Validate(c.OAuth.IdTokenTTL, positive),
+		"OAUTH_ID_TOKEN_KEY": validation.Validate(c.OAuth.IdTokenKey, idTokenKey,
+			validation.When(c.OAuth.PreviousIdTokenKey != "", validation.Required)),
+		"OAUTH_PREVIOUS_ID_TOKEN_KEY": validation.Validate(c.OAuth.PreviousIdTokenKey, idTokenKey),
+
+		"SCIM_MAX_PAGE_SIZE": validation.Validate(c.SCIM.MaxPageSize, positive),
+
+		"IDEMPOTENCY_KEY_TTL":      validation.Validate(c.Idempotency.KeyTTL, positive),
+		"IDEMPOTENCY_LOCK_TIMEOUT": validation.Validate(c.Idempotency.LockTimeout, positive),
+
+		// argon2 needs at least 8 KiB of memory per lane
+		"ARGON2_MEMORY_KIB":  validation.Validate(c.PasswordHashing.MemoryKiB, validation.Min(8*uint32(c.PasswordHashing.Parallelism))),
+		"ARGON2_ITERATIONS":  validation.Validate(c.PasswordHashing.Iterations, validation.Min(uint32(1))),
+		"ARGON2_PARALLELISM": validation.Validate(c.PasswordHashing.Parallelism, validation.Min(uint8(1))),
+		"ARGON2_SALT_LENGTH": validation.Validate(c.PasswordHashing.SaltLength, validation.Min(uint32(8))),
+		"ARGON2_KEY_LENGTH":  validation.Validate(c.PasswordHashing.KeyLength, validation.Min(uint32(16))),
+
+		"TRACING_EXPORTER":     validation.Validate(c.Tracing.Exporter, oneOf("none", "otlp", "stdout")),
+		"TRACING_SAMPLE_RATIO": validation.Validate(c.Tracing.SampleRatio, validation.Min(0.0), validation.Max(1.0)),
+	}
+	if err := c.Postgres.Validate(); err != nil {
+		var pgErrs validation.Errors
+		if !errors.As(err, &pgErrs) {
+			return err
+		}
+		for k, v := range pgErrs {
+			errs[k] = v
+		}
+	}
+	return errs.Filter()
+}
+
+func (p *Postgres) Validate() error {
+	return validation.Errors{
+		// the individual settings are only used without a DSN
+		"POSTGRES_USER":     validation.Validate(p.User, validation.When(p.DSN == "", validation.Required)),
+		"POSTGRES_PASSWORD": validation.Validate(p.Password, validation.When(p.DSN == "", validation.Required)),
+		"POSTGRES_HOST":     validation.Validate(p.Host, validation.When(p.DSN == "", validation.Required)),
+		"POSTGRES_PORT":     validation.Validate(p.Port, validation.When(p.DSN == "", validation.Required, is.Port)),
+		"POSTGRES_DATABASE": validation.Validate(p.Database, validation.When(p.DSN == "", validation.Required)),
+
+		"POSTGRES_SSLMODE": validation.Validate(p.SSLMode, validation.When(p.SSLMode != "",
+			oneOf("disable", "allow", "prefer", "require", "verify-ca", "verify-full"))),
+		"POSTGRES_SSLKEY": validation.Validate(p.SSLKey, validation.When(p.SSLCert != "", validation.Required)),
+
+		"POSTGRES_STATEMENT_TIMEOUT":   validation.Validate(p.StatementTimeout, validation.Min(time.Duration(0))),
+		"POSTGRES_MAX_CONNS":           validation.Validate(p.MaxConns, validation.Min(int32(0))),
+		"POSTGRES_MIN_CONNS":           validation.Validate(p.MinConns, validation.Min(int32(0)), validation.When(p.MaxConns > 0, validation.Max(p.MaxConns))),
+		"POSTGRES_MAX_CONN_LIFETIME":   validation.Validate(p.MaxConnLifetime, validation.Min(time.Duration(0))),
+		"POSTGRES_MAX_CONN_IDLE_TIME":  validation.Validate(p.MaxConnIdleTime, validation.Min(time.Duration(0))),
+		"POSTGRES_HEALTH_CHECK_PERIOD": validation.Validate(p.HealthCheckPeriod, validation.Min(time.Duration(0))),
+		"POSTGRES_CONNECT_TIMEOUT":     validation.Validate(p.ConnectTimeout, validation.Min(time.Duration(0))),
+
+		"POSTGRES_TX_ISOLATION":   validation.Validate(p.TxIsolation, oneOf("read-committed", "repeatable-read", "serializable")),
+		"POSTGRES_TX_MAX_RETRIES": validation.Validate(p.TxMaxRetries, validation.Min(0)),
+
+		"POSTGRES_REPLICAS":               validation.Validate(p.Replicas, validation.Each(validation.By(p.validateReplica))),
+		"POSTGRES_REPLICA_STICKINESS":     validation.Validate(p.ReplicaStickiness, validation.Min(time.Duration(0))),
+		"POSTGRES_REPLICA_CHECK_INTERVAL": validation.Validate(p.ReplicaCheckInterval, positive),
+	}.Filter()
+}
+
+// validateReplica checks a POSTGRES_REPLICAS entry. The errors do not quote it, since DSNs may contain passwords
+func (p *Postgres) validateReplica(value interface{}) error {
+	replica, _ := value.(string)
+	switch {
+	case replica == "":
+		return errors.New("must not be empty")
+	case isDSN(replica):
+		return nil
+	case p.DSN != "":
+		return errors.New("must be a DSN when POSTGRES_DSN is used")
+	}
+	host, port := splitHostPort(replica, p.Port)
+	if host == "" {
+		return errors.New("must be a DSN or host[:port]")
+	}
+	return validation.Validate(port, is.Port)
+}
+
+func (p OIDCProvider) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required),
+		validation.Field(&p.Issuer, validation.Required, is.URL),
+		validation.Field(&p.ClientID, validation.Required),
+		validation.Field(&p.RedirectURL, validation.Required, is.URL),
+	)
+}
@@ -4,4 +4,6 @@ type Course struct {
 	Id          string
 	Title       string
 	Description string
+	// Version is incremented on every change, for optimistic concurrency control
+	Version int64
 }
@@ -0,0 +1,25 @@
+package core
+
+import "time"
+
+// IdempotencyKey stores the response to the first request with an Idempotency-Key header, which is replayed to the
+// retries of that request. Keys are scoped to the user and the route
+type IdempotencyKey struct {
+	// UserId is empty for anonymous requests
+	UserId string
+	// Route is the method and the route pattern, e.g. "POST /api/v1/courses/"
+	Route string
+	Key   string
+	// Fingerprint is a hash of the request, which tells retries from different requests reusing the key
+	Fingerprint string
+	// Token identifies the reservation of the key by a request. Only its holder completes or releases the record, and
+	// an abandoned reservation is taken over by one retry only
+	Token string
+	// StatusCode is zero while the first request is being processed
+	StatusCode int
+	// Headers are the response headers worth replaying, e.g. Location
+	Headers   map[string]string
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
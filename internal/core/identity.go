@@ -0,0 +1,21 @@
+package core
+
+import "time"
+
+// UserIdentity links a user to an account at an external OpenID Connect provider
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserId    string
+	Email     string
+	CreatedAt time.Time
+}
+
+// OIDCLoginState is the server side state of an authorization code flow with an external provider
+type OIDCLoginState struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,13 @@
+package core
+
+import "time"
+
+// MagicLink is a single-use passwordless login link sent to the user's email address
+type MagicLink struct {
+	Id        string
+	UserId    string
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
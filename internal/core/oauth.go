@@ -0,0 +1,39 @@
+package core
+
+import "time"
+
+// OAuthClient is an application registered to log users in through Course Watch. Public clients (e.g. SPAs and
+// mobile apps) have no secret and rely on PKCE alone
+type OAuthClient struct {
+	Id           string
+	Name         string
+	HashedSecret []byte
+	RedirectURIs []string
+	CreatedAt    time.Time
+}
+
+func (c *OAuthClient) IsPublic() bool {
+	return len(c.HashedSecret) == 0
+}
+
+// AllowsRedirectURI reports whether uri exactly matches one of the registered redirect URIs
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorizationCode is an issued authorization code. Only the hash of the code is stored
+type OAuthAuthorizationCode struct {
+	CodeHash      string
+	ClientId      string
+	UserId        string
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
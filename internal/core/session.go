@@ -0,0 +1,22 @@
+package core
+
+import "time"
+
+// Session is a single login of a user on some device. Every token issued on login carries the session id, so
+// revoking the session invalidates these tokens
+type Session struct {
+	Id          string
+	UserId      string
+	DeviceLabel string
+	IP          string
+	UserAgent   string
+	CreatedAt   time.Time
+	LastSeenAt  time.Time
+	ExpiresAt   time.Time
+	RevokedAt   *time.Time
+}
+
+// IsActive reports whether the session is neither revoked nor expired at the given moment
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -14,4 +14,10 @@ type User struct {
 	RegistrationDate time.Time
 	HashedPassword   []byte
 	Roles            []security.Role
+	// Disabled users cannot log in. Set by deprovisioning
+	Disabled bool
+	// ExternalId is the id of the user in the provisioning system (e.g. HR), if the user was provisioned via SCIM
+	ExternalId string
+	// Version is incremented on every change, for optimistic concurrency control
+	Version int64
 }
@@ -0,0 +1,33 @@
+package core
+
+import "time"
+
+// WebAuthnCredential is a passkey or security key registered by a user. Id is the base64url encoded credential id
+type WebAuthnCredential struct {
+	Id              string
+	UserId          string
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      []string
+	CreatedAt       time.Time
+	LastUsedAt      *time.Time
+}
+
+type WebAuthnCeremonyKind string
+
+const (
+	WebAuthnRegistration WebAuthnCeremonyKind = "registration"
+	WebAuthnLogin        WebAuthnCeremonyKind = "login"
+)
+
+// WebAuthnCeremony keeps the server side state (challenge etc.) of a registration or login ceremony between its
+// begin and finish steps. Data is opaque to everything except the WebAuthn service
+type WebAuthnCeremony struct {
+	Id        string
+	UserId    string
+	Kind      WebAuthnCeremonyKind
+	Data      []byte
+	ExpiresAt time.Time
+}
@@ -0,0 +1,346 @@
+// Package cwadmin implements the admin command line tool, used to bootstrap and operate an installation without
+// going through the API
+package cwadmin
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/internal/config"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/password"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+const usage = `usage: cwadmin <command> [flags] [arguments]
+
+Users are referred to by id or email address. All commands accept -o table|json.
+
+commands:
+  user create -email E -first-name F -last-name L [-display-name D] -roles R[,R] [-password-stdin]
+  user grant <user> <role>
+  user revoke <user> <role>
+  user reset-password [-password-stdin] <user>
+  user disable <user>
+  user list [-offset N] [-limit N]
+  config print
+
+Without -password-stdin, a random password is generated and printed once. Roles: student, admin`
+
+var errUsage = errors.New(usage)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// Run executes the command line and returns the process exit code
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) < 2 {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	// validated before connecting, so that config print also works on an invalid configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, "failed to load configuration:", err)
+		return 1
+	}
+	a := &app{cfg: cfg, stdin: stdin, out: stdout, connect: connect}
+	if err = a.execute(ctx, args); err != nil {
+		fmt.Fprintln(stderr, err)
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// connect creates the admin service on top of Postgres. The returned function closes the connection pool
+func connect(ctx context.Context, cfg *config.Config) (service.Admin, func(), error) {
+	pgClient, err := postgres.NewClient(ctx, cfg.Postgres.PgConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	idGen, err := idgen.New(cfg.SnowflakeNode)
+	if err != nil {
+		pgClient.Close()
+		return nil, nil, err
+	}
+	// administration reads what it has just written, so the replicas are not used
+	db := postgres.NewCluster(pgClient, nil, postgres.ClusterOptions{})
+	repos := &repository.Repositories{
+		Users:    repository.NewUsersRepo(db),
+		Sessions: repository.NewSessionsRepo(db),
+	}
+	repos.Transactor = repository.NewTransactionsRepo(db, repos, cfg.Postgres.TxOptions())
+	services := service.NewServices(service.Deps{
+		Repos: repos,
+		IdGen: idGen,
+		PasswordHasher: password.NewArgon2idHasher(password.Argon2idParams{
+			Memory:      cfg.PasswordHashing.MemoryKiB,
+			Iterations:  cfg.PasswordHashing.Iterations,
+			Parallelism: cfg.PasswordHashing.Parallelism,
+			SaltLength:  cfg.PasswordHashing.SaltLength,
+			KeyLength:   cfg.PasswordHashing.KeyLength,
+		}),
+	})
+	return services.Admin, db.Close, nil
+}
+
+type app struct {
+	cfg     *config.Config
+	stdin   io.Reader
+	out     io.Writer
+	connect func(ctx context.Context, cfg *config.Config) (service.Admin, func(), error)
+
+	format string
+	admin  service.Admin
+}
+
+func (a *app) execute(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	command := args[0] + " " + args[1]
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&a.format, "o", formatTable, "output format: table or json")
+
+	switch command {
+	case "config print":
+		if err := a.parse(flags, args[2:], 0); err != nil {
+			return err
+		}
+		if err := config.WriteSettings(a.out, a.cfg, a.format == formatJSON); err != nil {
+			return err
+		}
+		if err := a.cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		return nil
+	case "user create":
+		return a.createUser(ctx, flags, args[2:])
+	case "user grant", "user revoke":
+		if err := a.parse(flags, args[2:], 2); err != nil {
+			return err
+		}
+		role, err := security.ParseRole(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		return a.withAdmin(ctx, func() error {
+			update := a.admin.GrantRole
+			if args[1] == "revoke" {
+				update = a.admin.RevokeRole
+			}
+			user, err := update(ctx, flags.Arg(0), role)
+			if err != nil {
+				return err
+			}
+			return a.printUsers(user)
+		})
+	case "user reset-password":
+		fromStdin := flags.Bool("password-stdin", false, "read the new password from the first line of stdin")
+		if err := a.parse(flags, args[2:], 1); err != nil {
+			return err
+		}
+		plain, generated, err := a.password(*fromStdin)
+		if err != nil {
+			return err
+		}
+		return a.withAdmin(ctx, func() error {
+			if err := a.admin.ResetPassword(ctx, flags.Arg(0), plain); err != nil {
+				return err
+			}
+			return a.printPasswordReset(generated)
+		})
+	case "user disable":
+		if err := a.parse(flags, args[2:], 1); err != nil {
+			return err
+		}
+		return a.withAdmin(ctx, func() error {
+			user, err := a.admin.DisableUser(ctx, flags.Arg(0))
+			if err != nil {
+				return err
+			}
+			return a.printUsers(user)
+		})
+	case "user list":
+		offset := flags.Int("offset", 0, "number of users to skip")
+		limit := flags.Int("limit", 50, "maximum number of users to list")
+		if err := a.parse(flags, args[2:], 0); err != nil {
+			return err
+		}
+		return a.withAdmin(ctx, func() error {
+			list, err := a.admin.ListUsers(ctx, *offset, *limit)
+			if err != nil {
+				return err
+			}
+			if a.format == formatJSON {
+				return a.printJSON(list)
+			}
+			if err = a.printUsers(list.Users...); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(a.out, "%d of %d user(s)\n", len(list.Users), list.Total)
+			return err
+		})
+	default:
+		return errUsage
+	}
+}
+
+func (a *app) createUser(ctx context.Context, flags *flag.FlagSet, args []string) error {
+	var input service.AdminCreateUserInput
+	var roles string
+	flags.StringVar(&input.Email, "email", "", "email address, used to log in")
+	flags.StringVar(&input.FirstName, "first-name", "", "first name")
+	flags.StringVar(&input.LastName, "last-name", "", "last name")
+	flags.StringVar(&input.DisplayName, "display-name", "", "display name")
+	flags.StringVar(&roles, "roles", "", "comma separated roles")
+	fromStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin")
+	if err := a.parse(flags, args, 0); err != nil {
+		return err
+	}
+	for _, name := range strings.Split(roles, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		role, err := security.ParseRole(name)
+		if err != nil {
+			return err
+		}
+		input.Roles = append(input.Roles, role)
+	}
+	var generated string
+	var err error
+	input.Password, generated, err = a.password(*fromStdin)
+	if err != nil {
+		return err
+	}
+	return a.withAdmin(ctx, func() error {
+		user, err := a.admin.CreateUser(ctx, &input)
+		if err != nil {
+			return err
+		}
+		if a.format == formatJSON {
+			return a.printJSON(struct {
+				*service.AdminUserOutput
+				Password string `json:"generated_password,omitempty"`
+			}{user, generated})
+		}
+		if err = a.printUsers(user); err != nil {
+			return err
+		}
+		if generated != "" {
+			_, err = fmt.Fprintln(a.out, "generated password:", generated)
+		}
+		return err
+	})
+}
+
+// parse parses the flags and checks the number of positional arguments
+func (a *app) parse(flags *flag.FlagSet, args []string, positional int) error {
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%s: %v: %w", flags.Name(), err, errUsage)
+	}
+	if flags.NArg() != positional {
+		return fmt.Errorf("%s: expected %d argument(s): %w", flags.Name(), positional, errUsage)
+	}
+	if a.format != formatTable && a.format != formatJSON {
+		return fmt.Errorf("unknown output format %q, expected table or json", a.format)
+	}
+	return nil
+}
+
+func (a *app) withAdmin(ctx context.Context, f func() error) error {
+	if a.admin == nil {
+		if err := a.cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		admin, closeFunc, err := a.connect(ctx, a.cfg)
+		if err != nil {
+			return err
+		}
+		defer closeFunc()
+		a.admin = admin
+	}
+	return f()
+}
+
+// password reads the password from stdin, or generates one. Passwords are never accepted as arguments, which would
+// leave them in the shell history and the process list
+func (a *app) password(fromStdin bool) (plain string, generated string, err error) {
+	if fromStdin {
+		line, err := bufio.NewReader(a.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return "", "", errors.New("no password on stdin")
+		}
+		return line, "", nil
+	}
+	b := make([]byte, 18)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	generated = base64.RawURLEncoding.EncodeToString(b)
+	return generated, generated, nil
+}
+
+func (a *app) printUsers(users ...*service.AdminUserOutput) error {
+	if a.format == formatJSON {
+		if len(users) == 1 {
+			return a.printJSON(users[0])
+		}
+		return a.printJSON(users)
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLES\tDISABLED\tREGISTERED")
+	for _, u := range users {
+		roles := make([]string, 0, len(u.Roles))
+		for i := range u.Roles {
+			roles = append(roles, u.Roles[i].String())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", u.Id, u.Email, strings.TrimSpace(u.FirstName+" "+u.LastName),
+			strings.Join(roles, ","), u.Disabled, u.RegistrationDate.UTC().Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func (a *app) printPasswordReset(generated string) error {
+	if a.format == formatJSON {
+		return a.printJSON(struct {
+			Password string `json:"generated_password,omitempty"`
+		}{generated})
+	}
+	if generated != "" {
+		_, err := fmt.Fprintln(a.out, "password reset, generated password:", generated)
+		return err
+	}
+	_, err := fmt.Fprintln(a.out, "password reset")
+	return err
+}
+
+func (a *app) printJSON(v interface{}) error {
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
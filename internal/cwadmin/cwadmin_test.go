@@ -0,0 +1,223 @@
+package cwadmin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/config"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	mock_service "github.com/zhuravlev-pe/course-watch/internal/service/mocks"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+var sampleAdmin = &service.AdminUserOutput{
+	Id:               "1582550893222432768",
+	Email:            "root@example.com",
+	FirstName:        "Ada",
+	LastName:         "Admin",
+	RegistrationDate: time.Date(2022, time.December, 1, 12, 0, 0, 0, time.UTC),
+	Roles:            []security.Role{security.Admin},
+}
+
+func TestApp_Execute(t *testing.T) {
+	cases := map[string]struct {
+		args       []string
+		stdin      string
+		setupMocks func(*mock_service.MockAdmin)
+		// checkOutput is called with the command output
+		checkOutput  func(*testing.T, string)
+		modifyConfig func(*config.Config)
+		wantErr      bool
+		connects     bool
+	}{
+		"create_with_generated_password": {
+			args: []string{"user", "create", "-email", "root@example.com", "-first-name", "Ada", "-last-name", "Admin",
+				"-roles", "admin, student"},
+			setupMocks: func(admin *mock_service.MockAdmin) {
+				admin.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, input *service.AdminCreateUserInput) (*service.AdminUserOutput, error) {
+						assert.Equal(t, []security.Role{security.Admin, security.Student}, input.Roles)
+						assert.Len(t, input.Password, 24)
+						return sampleAdmin, nil
+					})
+			},
+			checkOutput: func(t *testing.T, out string) {
+				assert.Contains(t, out, "ID                   EMAIL             NAME       ROLES  DISABLED  REGISTERED")
+				assert.Contains(t, out, "1582550893222432768  root@example.com  Ada Admin  admin  false     2022-12-01T12:00:00Z")
+				assert.Contains(t, out, "generated password: ")
+			},
+			connects: true,
+		},
+		"create_json_with_password_from_stdin": {
+			args: []string{"user", "create", "-o", "json", "-email", "root@example.com", "-first-name", "Ada",
+				"-last-name", "Admin", "-roles", "admin", "-password-stdin"},
+			stdin: "s3cret\n",
+			setupMocks: func(admin *mock_service.MockAdmin) {
+				admin.EXPECT().CreateUser(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, input *service.AdminCreateUserInput) (*service.AdminUserOutput, error) {
+						assert.Equal(t, "s3cret", input.Password)
+						return sampleAdmin, nil
+					})
+			},
+			checkOutput: func(t *testing.T, out string) {
+				var user map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(out), &user))
+				assert.Equal(t, "root@example.com", user["email"])
+				assert.Equal(t, []interface{}{"admin"}, user["roles"])
+				assert.NotContains(t, user, "generated_password")
+			},
+			connects: true,
+		},
+		"create_unknown_role": {
+			args:    []string{"user", "create", "-email", "root@example.com", "-roles", "owner"},
+			wantErr: true,
+		},
+		"grant": {
+			args: []string{"user", "grant", "root@example.com", "admin"},
+			setupMocks: func(admin *mock_service.MockAdmin) {
+				admin.EXPECT().GrantRole(gomock.Any(), "root@example.com", security.Admin).Return(sampleAdmin, nil)
+			},
+			checkOutput: func(t *testing.T, out string) {
+				assert.Contains(t, out, "root@example.com")
+			},
+			connects: true,
+		},
+		"revoke": {
+			args: []string{"user", "revoke", "1582550893222432768", "student"},
+			setupMocks: func(admin *mock_service.MockAdmin) {
+				admin.EXPECT().RevokeRole(gomock.Any(), "1582550893222432768", security.Student).Return(sampleAdmin, nil)
+			},
+			checkOutput: func(t *testing.T, out string) {
+				assert.Contains(t, out, "root@example.com")
+			},
+			connects: true,
+		},
+		"grant_missing_role": {
+			args:    []string{"user", "grant", "root@example.com"},
+			wantErr: true,
+		},
+		"reset_password_json": {
+			args: []string{"user", "reset-password", "-o", "json", "root@example.com"},
+			setupMocks: func(admin *mock_service.MockAdmin) {
+				admin.EXPECT().ResetPassword(gomock.Any(), "root@example.com", gomock.Any()).Return(nil)
+			},
+			checkOutput: func(t *testing.T, out string) {
+				var result map[string]string
+				require.NoError(t, json.Unmarshal([]byte(out), &result))
+				assert.Len(t, result["generated_password"], 24)
+			},
+			connects: true,
+		},
+		"reset_password_empty_stdin": {
+			args:    []string{"user", "reset-password", "-password-stdin", "root@example.com"},
+			stdin:   "",
+			wantErr: true,
+		},
+		"disable": {
+			args: []string{"user", "disable", "root@example.com"},
+			setupMocks: func(admin *mock_service.MockAdmin) {
+				disabled := *sampleAdmin
+				disabled.Disabled = true
+				admin.EXPECT().DisableUser(gomock.Any(), "root@example.com").Return(&disabled, nil)
+			},
+			checkOutput: func(t *testing.T, out string) {
+				assert.Contains(t, out, "true")
+			},
+			connects: true,
+		},
+		"list": {
+			args: []string{"user", "list", "-limit", "10"},
+			setupMocks: func(admin *mock_service.MockAdmin) {
+				admin.EXPECT().ListUsers(gomock.Any(), 0, 10).
+					Return(&service.AdminUserList{Total: 3, Users: []*service.AdminUserOutput{sampleAdmin}}, nil)
+			},
+			checkOutput: func(t *testing.T, out string) {
+				assert.Contains(t, out, "root@example.com")
+				assert.Contains(t, out, "1 of 3 user(s)")
+			},
+			connects: true,
+		},
+		"config_print": {
+			args: []string{"config", "print", "-o", "json"},
+			checkOutput: func(t *testing.T, out string) {
+				var settings []config.Setting
+				require.NoError(t, json.Unmarshal([]byte(out), &settings))
+				assert.Contains(t, settings, config.Setting{Name: "POSTGRES_PASSWORD", Value: config.Redacted})
+				assert.NotContains(t, out, "pg-secret")
+				assert.NotContains(t, out, "signing-secret")
+			},
+		},
+		"config_print_invalid": {
+			args:         []string{"config", "print"},
+			modifyConfig: func(c *config.Config) { c.SnowflakeNode = 4096 },
+			checkOutput: func(t *testing.T, out string) {
+				assert.Contains(t, out, "SNOWFLAKE_NODE")
+				assert.Contains(t, out, "4096")
+			},
+			wantErr: true,
+		},
+		"invalid_config_not_connected": {
+			args:         []string{"user", "disable", "root@example.com"},
+			modifyConfig: func(c *config.Config) { c.JWTAuthentication.TokenTTL = 0 },
+			wantErr:      true,
+		},
+		"unknown_format": {
+			args:    []string{"config", "print", "-o", "yaml"},
+			wantErr: true,
+		},
+		"unknown_command": {
+			args:    []string{"user", "delete", "root@example.com"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			admin := mock_service.NewMockAdmin(ctrl)
+			if tc.setupMocks != nil {
+				tc.setupMocks(admin)
+			}
+			t.Setenv(config.FileEnv, "")
+			t.Setenv("SIGNING_KEY", "signing-secret")
+			t.Setenv("POSTGRES_PASSWORD", "pg-secret")
+			cfg, err := config.Load()
+			require.NoError(t, err)
+			if tc.modifyConfig != nil {
+				tc.modifyConfig(cfg)
+			}
+			connected, closed := false, false
+			var out bytes.Buffer
+			a := &app{
+				cfg:   cfg,
+				stdin: strings.NewReader(tc.stdin),
+				out:   &out,
+				connect: func(context.Context, *config.Config) (service.Admin, func(), error) {
+					connected = true
+					return admin, func() { closed = true }, nil
+				},
+			}
+
+			err = a.execute(context.Background(), tc.args)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				if tc.checkOutput != nil {
+					tc.checkOutput(t, out.String())
+				}
+			} else {
+				require.NoError(t, err)
+				tc.checkOutput(t, out.String())
+			}
+			assert.Equal(t, tc.connects, connected)
+			assert.Equal(t, connected, closed)
+		})
+	}
+}
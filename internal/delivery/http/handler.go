@@ -2,23 +2,38 @@ package http
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/zhuravlev-pe/course-watch/api/swagger"
 	v1 "github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
 	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/health"
+	"go.opentelemetry.io/otel"
 	"net/http"
+	"time"
 )
 
 type Handler struct {
 	services *service.Services
 	bearer   v1.BearerAuthenticator
+	clients  v1.BearerAuthenticator
+	logger   zerolog.Logger
+	health   *health.Checker
+	// stickiness is how long clients read from the Postgres primary after their writes, zero without replicas
+	stickiness time.Duration
 }
 
-func NewHandler(services *service.Services, bearer v1.BearerAuthenticator) *Handler {
+func NewHandler(services *service.Services, bearer v1.BearerAuthenticator, clients v1.BearerAuthenticator, logger zerolog.Logger, checker *health.Checker, stickiness time.Duration) *Handler {
 	return &Handler{
-		services: services,
-		bearer:   bearer,
+		services:   services,
+		bearer:     bearer,
+		clients:    clients,
+		logger:     logger,
+		health:     checker,
+		stickiness: stickiness,
 	}
 }
 
@@ -26,17 +41,26 @@ func (h *Handler) Init() *gin.Engine {
 
 	router := gin.New()
 	router.Use(
-		gin.Recovery(),
-		gin.Logger(),
+		requestID(),
+		requestTracing(otel.Tracer(tracerName)),
+		requestLogger(h.logger),
+		requestMetrics(),
+		recoverer(),
+		readYourWrites(h.stickiness),
+		utils.Problems(),
 	)
 
 	swagger.SwaggerInfo.Host = "localhost:8080"
 	// http://localhost:8080/swagger/index.html
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	router.GET("/ping", func(c *gin.Context) {
 		c.String(http.StatusOK, "pong")
 	})
+	router.GET("/healthz", liveness)
+	router.GET("/readyz", readiness(h.health))
 
 	h.initAPI(router)
 
@@ -44,9 +68,11 @@ func (h *Handler) Init() *gin.Engine {
 }
 
 func (h *Handler) initAPI(router *gin.Engine) {
-	handlerV1 := v1.NewHandler(h.services, h.bearer)
+	handlerV1 := v1.NewHandler(h.services, h.bearer, h.clients)
 	api := router.Group("/api")
 	{
 		handlerV1.Init(api)
 	}
+	handlerV1.InitAuthorizationServer(router)
+	handlerV1.InitSCIM(router)
 }
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/pkg/health"
+	"github.com/zhuravlev-pe/course-watch/pkg/logger"
+)
+
+// liveness reports that the process is up and serving. It checks no dependencies, so that an unavailable database
+// gets the instance taken out of the load balancer by readiness rather than restarted
+func liveness(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// readiness runs the dependency checks and responds 503 unless all of them pass and no shutdown is in progress. The
+// response only tells which checks failed, the reasons are logged
+func readiness(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+		for name, result := range report.Checks {
+			if result.Status != health.StatusOK {
+				logger.FromContext(c.Request.Context()).Warn().Str("check", name).Str("error", result.Error).
+					Msg("readiness check failed")
+			}
+		}
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(status, report)
+	}
+}
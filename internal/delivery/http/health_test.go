@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/pkg/health"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	cases := map[string]struct {
+		path         string
+		dbErr        error
+		shuttingDown bool
+		status       int
+		report       health.Report
+	}{
+		"liveness_ignores_dependencies": {
+			path:   "/healthz",
+			dbErr:  errors.New("connection refused"),
+			status: http.StatusOK,
+			report: health.Report{Status: health.StatusOK},
+		},
+		"ready": {
+			path:   "/readyz",
+			status: http.StatusOK,
+			report: health.Report{Status: health.StatusOK, Checks: map[string]health.CheckResult{
+				"postgres": {Status: health.StatusOK},
+			}},
+		},
+		"dependency_down": {
+			path:   "/readyz",
+			dbErr:  errors.New("connection refused"),
+			status: http.StatusServiceUnavailable,
+			report: health.Report{Status: health.StatusUnavailable, Checks: map[string]health.CheckResult{
+				"postgres": {Status: health.StatusFailed},
+			}},
+		},
+		"shutting_down": {
+			path:         "/readyz",
+			shuttingDown: true,
+			status:       http.StatusServiceUnavailable,
+			report:       health.Report{Status: health.StatusUnavailable},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			checker := health.NewChecker(time.Second)
+			checker.Register("postgres", func(context.Context) error { return tc.dbErr })
+			if tc.shuttingDown {
+				checker.ShutDown()
+			}
+			router := gin.New()
+			router.GET("/healthz", liveness)
+			router.GET("/readyz", readiness(checker))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, tc.status, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			var report health.Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.NotContains(t, w.Body.String(), "connection refused")
+			// durations vary from run to run
+			for checkName, result := range report.Checks {
+				assert.NotEmpty(t, result.Duration)
+				result.Duration = ""
+				report.Checks[checkName] = result
+			}
+			assert.Equal(t, tc.report, report)
+		})
+	}
+}
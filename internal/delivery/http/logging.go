@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/logger"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestLogger puts a logger with the request fields into the request context, and logs every request when it is
+// complete. Must run after requestID and requestTracing. Query strings are left out, as they may carry secrets such as authorization codes
+func requestLogger(log zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		logCtx := log.With().
+			Str("request_id", requestid.FromContext(c.Request.Context())).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Str("client_ip", c.ClientIP())
+		if spanCtx := trace.SpanContextFromContext(c.Request.Context()); spanCtx.IsValid() {
+			logCtx = logCtx.Str("trace_id", spanCtx.TraceID().String())
+		}
+		reqLog := logCtx.Logger()
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), reqLog))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		event := reqLog.Info()
+		if status >= http.StatusInternalServerError {
+			event = reqLog.Error()
+		}
+		if up, err := auth.GetAuthenticatedUser(c); err == nil {
+			event = event.Str("user_id", up.UserId)
+		}
+		event.
+			Str("route", c.FullPath()).
+			Int("status", status).
+			Int("size", c.Writer.Size()).
+			Dur("latency", time.Since(start)).
+			Str("user_agent", c.Request.UserAgent()).
+			Msg("request")
+	}
+}
+
+// recoverer turns panics into 500 responses with a problem details body and logs them with the stack trace
+func recoverer() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered interface{}) {
+		logger.FromContext(c.Request.Context()).Error().
+			Interface("panic", recovered).
+			Bytes("stack", debug.Stack()).
+			Msg("recovered from panic")
+		utils.WriteProblem(c, service.ErrInternal)
+	})
+}
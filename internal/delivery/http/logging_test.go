@@ -0,0 +1,119 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/logger"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
+)
+
+func getLoggedRouter(t *testing.T, level string) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	log, err := logger.New(&buf, level)
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(requestID(), requestLogger(log), recoverer(), utils.Problems())
+	return router, &buf
+}
+
+func readEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRequestLogger(t *testing.T) {
+	router, buf := getLoggedRouter(t, "info")
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/42?code=secret", nil)
+	req.Header.Set(requestid.Header, "client-supplied-id")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := readEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "info", entries[0]["level"])
+	assert.Equal(t, "request", entries[0]["message"])
+	assert.Equal(t, "GET", entries[0]["method"])
+	assert.Equal(t, "/items/42", entries[0]["path"])
+	assert.Equal(t, "/items/:id", entries[0]["route"])
+	assert.Equal(t, "client-supplied-id", entries[0]["request_id"])
+	assert.Equal(t, float64(http.StatusOK), entries[0]["status"])
+	assert.NotContains(t, buf.String(), "secret")
+}
+
+func TestRequestLogger_InternalError(t *testing.T) {
+	router, buf := getLoggedRouter(t, "info")
+	router.GET("/fail", func(c *gin.Context) {
+		utils.Abort(c, errors.New("connection refused"))
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	id := rec.Header().Get(requestid.Header)
+	assert.Equal(t, `{"type":"https://course-watch.com/problems/internal_error","title":"internal server error",`+
+		`"status":500,"instance":"`+id+`","code":"internal_error"}`, rec.Body.String())
+	entries := readEntries(t, buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, id, entries[0]["request_id"])
+	assert.Equal(t, id, entries[1]["request_id"])
+	assert.Equal(t, "error", entries[0]["level"])
+	assert.Equal(t, "connection refused", entries[0]["error"])
+	assert.Equal(t, "/fail", entries[0]["path"])
+	assert.Equal(t, "error", entries[1]["level"])
+	assert.Equal(t, float64(http.StatusInternalServerError), entries[1]["status"])
+}
+
+func TestRequestLogger_ClientErrorsAtDebugLevel(t *testing.T) {
+	router, buf := getLoggedRouter(t, "info")
+	router.GET("/missing", func(c *gin.Context) {
+		utils.Abort(c, service.ErrNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	entries := readEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "request", entries[0]["message"])
+	assert.Equal(t, float64(http.StatusNotFound), entries[0]["status"])
+}
+
+func TestRecoverer(t *testing.T) {
+	router, buf := getLoggedRouter(t, "info")
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, utils.ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"instance":"`+rec.Header().Get(requestid.Header)+`"`)
+	entries := readEntries(t, buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "boom", entries[0]["panic"])
+	assert.NotEmpty(t, entries[0]["stack"])
+	assert.Equal(t, float64(http.StatusInternalServerError), entries[1]["status"])
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	router := gin.New()
+	router.Use(requestMetrics())
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	cases := map[string]struct {
+		path   string
+		route  string
+		status string
+	}{
+		"matched":   {path: "/items/42", route: "/items/:id", status: "204"},
+		"unmatched": {path: "/no/such/path", route: unmatchedRoute, status: "404"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			counter := httpRequests.WithLabelValues(tc.route, http.MethodGet, tc.status)
+			before := testutil.ToFloat64(counter)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+			assert.Greater(t, testutil.CollectAndCount(httpRequestDuration), 0)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
+)
+
+// requestID takes the request id from the X-Request-ID header, or generates one if it is missing or malformed,
+// stores it in the request context and returns it in the response
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.Generate()
+		}
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	cases := map[string]struct {
+		header    string
+		wantEqual bool
+	}{
+		"accepted":  {header: "5f0c7a3e-0b1e-4c8e-9a7a-2d6f3c1b9e44", wantEqual: true},
+		"missing":   {header: ""},
+		"malformed": {header: "bad id\r\nSet-Cookie: x"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(requestID())
+			var seen string
+			router.GET("/", func(c *gin.Context) {
+				seen = requestid.FromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(requestid.Header, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			returned := rec.Header().Get(requestid.Header)
+			assert.True(t, requestid.Valid(returned))
+			assert.Equal(t, returned, seen)
+			assert.Equal(t, tc.wantEqual, returned == tc.header)
+		})
+	}
+}
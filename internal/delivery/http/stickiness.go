@@ -0,0 +1,85 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+// lastWriteCookie holds the time of the client's last write, in Unix milliseconds
+const lastWriteCookie = "cw_last_write"
+
+// readYourWrites lets clients see their own writes when their requests are spread over several instances: the time of
+// the last write is returned in a cookie, and the reads of requests passing it back go to the Postgres primary for
+// the stickiness window, whichever instance made the write. Disabled when stickiness is not positive
+func readYourWrites(stickiness time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if stickiness <= 0 {
+			return
+		}
+		var last time.Time
+		if cookie, err := c.Cookie(lastWriteCookie); err == nil {
+			if millis, err := strconv.ParseInt(cookie, 10, 64); err == nil {
+				last = time.UnixMilli(millis)
+			}
+		}
+		c.Request = c.Request.WithContext(postgres.WithClientWrites(c.Request.Context(), last))
+		writer := &lastWriteWriter{ResponseWriter: c.Writer, c: c, reported: last, stickiness: stickiness}
+		c.Writer = writer
+
+		c.Next()
+
+		// responses without a body, e.g. 204 after an update, are written after the middleware returns
+		if !writer.Written() {
+			writer.setCookie()
+		}
+	}
+}
+
+// lastWriteWriter sets the cookie right before the headers are sent, once the handler has made its writes
+type lastWriteWriter struct {
+	gin.ResponseWriter
+	c          *gin.Context
+	reported   time.Time
+	stickiness time.Duration
+	done       bool
+}
+
+func (w *lastWriteWriter) setCookie() {
+	if w.done {
+		return
+	}
+	w.done = true
+	last, ok := postgres.LastClientWrite(w.c.Request.Context())
+	if !ok || !last.After(w.reported) {
+		return
+	}
+	cookie := &http.Cookie{
+		Name:     lastWriteCookie,
+		Value:    strconv.FormatInt(last.UnixMilli(), 10),
+		Path:     "/",
+		MaxAge:   int((w.stickiness + time.Second - 1) / time.Second),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	w.Header().Add("Set-Cookie", cookie.String())
+}
+
+func (w *lastWriteWriter) WriteHeaderNow() {
+	w.setCookie()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *lastWriteWriter) Write(data []byte) (int, error) {
+	w.setCookie()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *lastWriteWriter) WriteString(s string) (int, error) {
+	w.setCookie()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+func newLazyPool(t *testing.T) *pgxpool.Pool {
+	// the pool connects lazily, no statement runs
+	pool, err := pgxpool.New(context.Background(), "postgres://app:pw@127.0.0.1:1/cw")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestReadYourWrites(t *testing.T) {
+	cluster := postgres.NewCluster(newLazyPool(t), []*pgxpool.Pool{newLazyPool(t)}, postgres.ClusterOptions{
+		Stickiness: time.Minute,
+	})
+	router := gin.New()
+	router.Use(readYourWrites(time.Minute))
+	var reported time.Time
+	router.GET("/read", func(c *gin.Context) {
+		reported, _ = postgres.LastClientWrite(c.Request.Context())
+		c.String(http.StatusOK, "read")
+	})
+	router.PUT("/write", func(c *gin.Context) {
+		cluster.Writer(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+	router.POST("/write", func(c *gin.Context) {
+		cluster.Writer(c.Request.Context())
+		c.String(http.StatusCreated, "created")
+	})
+
+	for _, method := range []string{http.MethodPut, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			before := time.Now().Truncate(time.Millisecond)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(method, "/write", nil))
+
+			cookies := rec.Result().Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, lastWriteCookie, cookies[0].Name)
+			assert.Equal(t, 60, cookies[0].MaxAge)
+			assert.True(t, cookies[0].HttpOnly)
+			millis, err := strconv.ParseInt(cookies[0].Value, 10, 64)
+			require.NoError(t, err)
+			assert.False(t, time.UnixMilli(millis).Before(before))
+
+			request := httptest.NewRequest(http.MethodGet, "/read", nil)
+			request.AddCookie(cookies[0])
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, request)
+
+			assert.Equal(t, time.UnixMilli(millis), reported)
+			// reads do not renew the cookie
+			assert.Empty(t, rec.Result().Cookies())
+		})
+	}
+}
+
+func TestReadYourWrites_Disabled(t *testing.T) {
+	router := gin.New()
+	router.Use(readYourWrites(0))
+	var ok bool
+	router.GET("/read", func(c *gin.Context) {
+		_, ok = postgres.LastClientWrite(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+	request := httptest.NewRequest(http.MethodGet, "/read", nil)
+	request.AddCookie(&http.Cookie{Name: lastWriteCookie, Value: strconv.FormatInt(time.Now().UnixMilli(), 10)})
+
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	assert.False(t, ok)
+}
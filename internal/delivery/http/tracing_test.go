@@ -0,0 +1,96 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestTracing(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	router := gin.New()
+	router.Use(requestTracing(provider.Tracer(tracerName)))
+	var handlerSpan trace.SpanContext
+	router.GET("/items/:id", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/broken", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	cases := map[string]struct {
+		path        string
+		traceparent string
+		name        string
+		route       string
+		status      int
+		statusCode  codes.Code
+	}{
+		"continued_trace": {
+			path:        "/items/42?token=secret",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			name:        "GET /items/:id",
+			route:       "/items/:id",
+			status:      http.StatusNoContent,
+			statusCode:  codes.Unset,
+		},
+		"server_error": {
+			path:       "/broken",
+			name:       "GET /broken",
+			route:      "/broken",
+			status:     http.StatusInternalServerError,
+			statusCode: codes.Error,
+		},
+		"unmatched": {
+			path:       "/no/such/path",
+			name:       "GET " + unmatchedRoute,
+			route:      unmatchedRoute,
+			status:     http.StatusNotFound,
+			statusCode: codes.Unset,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			before := len(recorder.Ended())
+
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, before+1)
+			span := spans[before]
+			assert.Equal(t, tc.name, span.Name())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Equal(t, tc.statusCode, span.Status().Code)
+			assert.Contains(t, span.Attributes(), attribute.String("http.route", tc.route))
+			assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", tc.status))
+			for _, attr := range span.Attributes() {
+				assert.NotContains(t, attr.Value.Emit(), "secret")
+			}
+			if tc.traceparent != "" {
+				assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+				assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+				assert.Equal(t, span.SpanContext(), handlerSpan)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
 	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
 	"github.com/zhuravlev-pe/course-watch/internal/service"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
@@ -12,8 +13,14 @@ import (
 func (h *Handler) initAuthRoutes(api *gin.RouterGroup) {
 	courses := api.Group("/auth")
 	{
-		courses.POST("/signup", h.signupNewUser)
+		courses.POST("/signup", h.idempotent, h.signupNewUser)
 		courses.POST("/login", h.userLogin)
+		courses.POST("/logout", h.userLogout)
+		courses.POST("/magic-link", h.requestMagicLink)
+		courses.POST("/magic-link/exchange", h.exchangeMagicLink)
+		h.initWebAuthnRoutes(courses)
+		h.initOIDCRoutes(courses)
+		h.initIntrospectionRoutes(courses)
 	}
 }
 
@@ -24,23 +31,19 @@ func (h *Handler) initAuthRoutes(api *gin.RouterGroup) {
 // @Accept  json
 // @Produce  json
 // @Param input body service.SignupUserInput true "New user signup details"
+// @Param Idempotency-Key header string false "makes retries return the first response instead of failing"
 // @Success 200 {object} service.LoginInput
-// @Failure 400,500 {object} utils.Response
+// @Failure 400,409,422,500 {object} utils.Response
 // @Router /auth/signup [Post]
 func (h *Handler) signupNewUser(ctx *gin.Context) {
 	var input service.SignupUserInput
-	if err := ctx.BindJSON(&input); err != nil {
-		utils.ErrorResponseString(ctx, http.StatusBadRequest, "invalid input body")
+	if !h.parseRequestBody(ctx, &input) {
 		return
 	}
 	err := h.services.Users.Signup(ctx.Request.Context(), &input)
 
 	if err != nil {
-		if err == service.ErrUserAlreadyExist {
-			utils.ErrorResponse(ctx, http.StatusBadRequest, err)
-			return
-		}
-		utils.ErrorResponse(ctx, http.StatusInternalServerError, err)
+		utils.Abort(ctx, err)
 		return
 	}
 
@@ -54,37 +57,95 @@ func (h *Handler) signupNewUser(ctx *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param input body service.LoginInput true "Login user details"
-// @Success 200 {object} service.LoginInput
+// @Success 200 {object} service.PostUserLoginOutput
 // @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
 // @Router /auth/login [Post]
 func (h *Handler) userLogin(ctx *gin.Context) {
 	var input service.LoginInput
-	if err := ctx.BindJSON(&input); err != nil {
-		utils.ErrorResponseString(ctx, http.StatusBadRequest, "invalid input body")
+	if !h.parseRequestBody(ctx, &input) {
 		return
 	}
 	result, err := h.services.Users.Login(ctx.Request.Context(), &input)
 
 	if err != nil {
-		if err == service.ErrInvalidCredentials {
-			utils.ErrorResponse(ctx, http.StatusBadRequest, err)
-			return
-		}
+		utils.Abort(ctx, err)
+		return
+	}
 
-		utils.ErrorResponse(ctx, http.StatusInternalServerError, err)
+	output, err := h.startSession(ctx, result, input.DeviceLabel)
+	if err != nil {
+		utils.Abort(ctx, err)
 		return
 	}
+	h.sessionResponse(ctx, output)
+}
 
-	up := security.UserPrincipal{UserId: result.Id, Roles: result.Roles}
-	token, err := h.bearer.GenerateToken(&up)
+// @Summary Log out
+// @Tags Authentication
+// @Description revokes the session of the calling token and clears the session cookies
+// @ModuleID userLogout
+// @Accept  json
+// @Produce  json
+// @Success 204
+// @Failure 401,500 {object} utils.Response
+// @Router /auth/logout [Post]
+func (h *Handler) userLogout(ctx *gin.Context) {
+	// the cookies are kept on failure, or a cross-site request without the CSRF token could log the user out
+	up, err := h.bearer.Identify(ctx)
+	if err != nil {
+		utils.Abort(ctx, service.ErrUnauthorized.WithCause(err))
+		return
+	}
+
+	err = h.services.Sessions.Revoke(ctx.Request.Context(), up.UserId, up.SessionId)
 	if err != nil {
-		utils.ErrorResponse(ctx, http.StatusInternalServerError, err)
+		utils.Abort(ctx, err)
 		return
 	}
-	output := service.PostUserLoginOutput{
+	h.bearer.ClearTokenCookies(ctx)
+	ctx.Status(http.StatusNoContent)
+}
+
+// sessionResponse writes the response of a browser login. In the cookie session mode the access token is set as an
+// HttpOnly cookie instead of being returned in the body
+func (h *Handler) sessionResponse(ctx *gin.Context, output *service.PostUserLoginOutput) {
+	if h.bearer.CookieMode() {
+		csrfToken, err := h.bearer.SetTokenCookies(ctx, output.AccessToken)
+		if err != nil {
+			utils.Abort(ctx, err)
+			return
+		}
+		output.AccessToken = ""
+		output.CSRFToken = csrfToken
+	}
+	ctx.JSON(http.StatusOK, output)
+}
+
+// startSession records a new login session for the authenticated user and issues an access token bound to it. All
+// login methods must go through here so that the issued tokens can be revoked via session management
+func (h *Handler) startSession(ctx *gin.Context, user *core.User, deviceLabel string) (*service.PostUserLoginOutput, error) {
+	session, err := h.createSession(ctx, user.Id, deviceLabel)
+	if err != nil {
+		return nil, err
+	}
+	up := security.UserPrincipal{UserId: user.Id, Roles: user.Roles, SessionId: session.Id}
+	token, err := h.bearer.GenerateToken(&up)
+	if err != nil {
+		return nil, err
+	}
+	return &service.PostUserLoginOutput{
 		UserId:      up.UserId,
 		AccessToken: token,
 		ExpiresIn:   int(h.bearer.GetTokenTtl().Seconds()),
-	}
-	ctx.JSON(http.StatusOK, output)
+	}, nil
+}
+
+func (h *Handler) createSession(ctx *gin.Context, userId string, deviceLabel string) (*core.Session, error) {
+	return h.services.Sessions.Create(ctx.Request.Context(), &service.CreateSessionInput{
+		UserId:      userId,
+		DeviceLabel: deviceLabel,
+		IP:          ctx.ClientIP(),
+		UserAgent:   ctx.Request.UserAgent(),
+	})
 }
@@ -3,6 +3,8 @@ package auth
 //go:generate mockgen -source=$GOFILE -destination=mocks/mock_auth.go
 
 import (
+	"context"
+
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
 	"time"
 )
@@ -12,3 +14,8 @@ type BearerTokenHandler interface {
 	Parse(tokenString string) (*security.JwtPayload, error)
 	GetTokenTtl() time.Duration
 }
+
+// SessionValidator checks that the login session a token was issued for is still active
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, principal *security.UserPrincipal) error
+}
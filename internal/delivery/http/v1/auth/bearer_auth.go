@@ -1,12 +1,15 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	v1 "github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
-	"net/http"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 	"strings"
 	"time"
 )
@@ -15,10 +18,14 @@ const userKey = "user_principal"
 
 type BearerAuthenticator struct {
 	tokenHandler BearerTokenHandler
+	sessions     SessionValidator
+	cookies      *CookieSettings
 }
 
-func NewBearerAuthenticator(tokenHandler BearerTokenHandler) *BearerAuthenticator {
-	return &BearerAuthenticator{tokenHandler: tokenHandler}
+// NewBearerAuthenticator creates the authenticator. If sessions is not nil, every authenticated request is also
+// checked against the login session the token was issued for, so that revoked sessions are rejected immediately
+func NewBearerAuthenticator(tokenHandler BearerTokenHandler, sessions SessionValidator) *BearerAuthenticator {
+	return &BearerAuthenticator{tokenHandler: tokenHandler, sessions: sessions}
 }
 
 // Authenticate implements authentication middleware. When used on a group router, child endpoints will be called only
@@ -30,19 +37,61 @@ func NewBearerAuthenticator(tokenHandler BearerTokenHandler) *BearerAuthenticato
 // https://github.com/gin-gonic/gin/issues/2442
 // Check gin.Context.IsAborted() in the handler code to ensure that authentication has passed
 func (ba *BearerAuthenticator) Authenticate(ctx *gin.Context) {
-	payload, err := ba.parseAuthHeader(ctx)
+	up, err := ba.Identify(ctx)
 	if err != nil {
 		// We do not want to report the error details to the caller here in order to avoid revealing security related
-		// info. ErrorResponseWithMessage() should log the error
-		v1.ErrorResponseMessageOverride(ctx, http.StatusUnauthorized, err, "Unauthorized")
+		// info. The cause is only logged
+		v1.Abort(ctx, service.ErrUnauthorized.WithCause(err))
 		return
 	}
+	ctx.Set(userKey, up)
+}
+
+// Identify authenticates the request like Authenticate, but leaves the response alone. For endpoints which serve
+// both authenticated and anonymous callers
+func (ba *BearerAuthenticator) Identify(ctx *gin.Context) (*security.UserPrincipal, error) {
+	payload, err := ba.parseAuthHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
 	up := payload.UserPrincipal
-	ctx.Set(userKey, &up)
+	if ba.sessions != nil {
+		if err = ba.sessions.ValidateSession(ctx.Request.Context(), &up); err != nil {
+			return nil, err
+		}
+	}
+	// only the id identifies the user on traces, the token itself must never be recorded
+	trace.SpanFromContext(ctx.Request.Context()).SetAttributes(semconv.EnduserIDKey.String(up.UserId))
+	// the services only get the request context, e.g. the database routing keeps the user's reads on the primary
+	// after their own writes
+	ctx.Request = ctx.Request.WithContext(security.ContextWithPrincipal(ctx.Request.Context(), &up))
+	return &up, nil
+}
+
+// Inspect validates a token passed by other means than the request, e.g. in a token introspection request. Fails
+// unless the token is valid and its session is active
+func (ba *BearerAuthenticator) Inspect(ctx context.Context, token string) (*security.JwtPayload, error) {
+	payload, err := ba.tokenHandler.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if ba.sessions != nil {
+		if err = ba.sessions.ValidateSession(ctx, &payload.UserPrincipal); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
 }
 
 func (ba *BearerAuthenticator) parseAuthHeader(ctx *gin.Context) (*security.JwtPayload, error) {
 	header := ctx.GetHeader("Authorization")
+	if header == "" && ba.cookies != nil {
+		token, err := ba.readTokenCookie(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ba.tokenHandler.Parse(token)
+	}
 	if header == "" {
 		return nil, errors.New("empty or missing 'Authorization' header")
 	}
@@ -86,21 +135,19 @@ func EnsureAuthorizedUser(ctx *gin.Context, role security.Role) bool {
 	up, err := GetAuthenticatedUser(ctx)
 	if err != nil {
 		// No authentication middleware
-		msg := getUnauthorizedMessage(role)
-		v1.ErrorResponseMessageOverride(ctx, http.StatusForbidden, err, msg)
+		v1.Abort(ctx, service.ErrForbidden.WithDetail(getUnauthorizedMessage(role)).WithCause(err))
 		return false
 	}
 	if !up.HasRole(role) {
 		// User does not have the required role
-		msg := getUnauthorizedMessage(role)
-		v1.ErrorResponseString(ctx, http.StatusForbidden, msg)
+		v1.Abort(ctx, service.ErrForbidden.WithDetail(getUnauthorizedMessage(role)))
 		return false
 	}
 	return true
 }
 
 func getUnauthorizedMessage(role security.Role) string {
-	return fmt.Sprintf("Forbidden. Required user role: %s", role.String())
+	return fmt.Sprintf("required user role: %s", role.String())
 }
 
 // GenerateToken creates a signed token string to be passed to the frontend in a response
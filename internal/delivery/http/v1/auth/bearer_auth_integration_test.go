@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
 	"net/http"
 	"net/http/httptest"
@@ -30,11 +31,12 @@ func TestBearerAuthenticator_Integration_Authorize(t *testing.T) {
 	tokenHandler := getParametrizedTokenHandler(validKey)
 	fakeTokenHandler := getParametrizedTokenHandler(invalidKey)
 
-	ba := auth.NewBearerAuthenticator(tokenHandler)
+	ba := auth.NewBearerAuthenticator(tokenHandler, nil)
 
 	var endpointHit bool
 
 	router := gin.New()
+	router.Use(utils.Problems())
 	g := router.Group("/secure", ba.Authorize(security.Admin))
 	g.GET("/data", func(context *gin.Context) {
 		endpointHit = true
@@ -67,13 +69,13 @@ func TestBearerAuthenticator_Integration_Authorize(t *testing.T) {
 			},
 			tokenHandler:         fakeTokenHandler,
 			expectedStatusCode:   http.StatusUnauthorized,
-			expectedErrorMessage: `{"title":"Unauthorized","status":401}`,
+			expectedErrorMessage: `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
 		},
 		"no_authorization_header": {
 			loggedInUser:         nil,
 			tokenHandler:         nil,
 			expectedStatusCode:   http.StatusUnauthorized,
-			expectedErrorMessage: `{"title":"Unauthorized","status":401}`,
+			expectedErrorMessage: `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
 		},
 		"authorization_failure": {
 			loggedInUser: &security.UserPrincipal{
@@ -82,7 +84,7 @@ func TestBearerAuthenticator_Integration_Authorize(t *testing.T) {
 			},
 			tokenHandler:         tokenHandler,
 			expectedStatusCode:   http.StatusForbidden,
-			expectedErrorMessage: `{"title":"Forbidden. Required user role: admin","status":403}`,
+			expectedErrorMessage: `{"type":"https://course-watch.com/problems/forbidden","title":"Forbidden","status":403,"detail":"required user role: admin","code":"forbidden"}`,
 		},
 	}
 
@@ -120,11 +122,12 @@ func TestBearerAuthenticator_Integration_Authenticate(t *testing.T) {
 	tokenHandler := getParametrizedTokenHandler(validKey)
 	fakeTokenHandler := getParametrizedTokenHandler(invalidKey)
 
-	ba := auth.NewBearerAuthenticator(tokenHandler)
+	ba := auth.NewBearerAuthenticator(tokenHandler, nil)
 
 	var endpointHit bool
 
 	router := gin.New()
+	router.Use(utils.Problems())
 	g := router.Group("/secure", ba.Authenticate)
 	g.GET("/data", func(context *gin.Context) {
 		endpointHit = true
@@ -157,13 +160,13 @@ func TestBearerAuthenticator_Integration_Authenticate(t *testing.T) {
 			},
 			tokenHandler:         fakeTokenHandler,
 			expectedStatusCode:   http.StatusUnauthorized,
-			expectedErrorMessage: `{"title":"Unauthorized","status":401}`,
+			expectedErrorMessage: `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
 		},
 		"no_authorization_header": {
 			loggedInUser:         nil,
 			tokenHandler:         nil,
 			expectedStatusCode:   http.StatusUnauthorized,
-			expectedErrorMessage: `{"title":"Unauthorized","status":401}`,
+			expectedErrorMessage: `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
 		},
 		"success_single_role": {
 			loggedInUser: &security.UserPrincipal{
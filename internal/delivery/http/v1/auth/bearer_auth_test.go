@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	mockAuth "github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth/mocks"
+	v1 "github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
 	"net/http"
 	"net/http/httptest"
@@ -26,8 +27,9 @@ func getTestSetup(t *testing.T) *testSetup {
 	var ts testSetup
 
 	ts.bth = mockAuth.NewMockBearerTokenHandler(ctrl)
-	ts.ba = NewBearerAuthenticator(ts.bth)
+	ts.ba = NewBearerAuthenticator(ts.bth, nil)
 	ts.router = gin.New()
+	ts.router.Use(v1.Problems())
 
 	return &ts
 }
@@ -35,7 +37,7 @@ func getTestSetup(t *testing.T) *testSetup {
 const testData = "test data"
 const validToken = "valid.token.value"
 const invalidToken = "42"
-const unauthorizedMessageBody = `{"title":"Unauthorized","status":401}`
+const unauthorizedMessageBody = `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`
 
 var referencePayload = &security.JwtPayload{
 	UserPrincipal: security.UserPrincipal{
@@ -48,10 +50,12 @@ func TestBearerAuthenticator_Authenticate(t *testing.T) {
 	ts := getTestSetup(t)
 
 	var endpointHit bool
+	var requestPrincipal *security.UserPrincipal
 
 	g := ts.router.Group("/secure", ts.ba.Authenticate)
 	g.GET("/data", func(context *gin.Context) {
 		endpointHit = true
+		requestPrincipal = security.PrincipalFromContext(context.Request.Context())
 		context.String(http.StatusOK, testData)
 	})
 
@@ -141,6 +145,7 @@ func TestBearerAuthenticator_Authenticate(t *testing.T) {
 			require.Equal(t, c.expectedBody, w.Body.String())
 			if c.expectedStatusCode == http.StatusOK {
 				require.True(t, endpointHit)
+				require.Equal(t, &c.expectedParseOutput.UserPrincipal, requestPrincipal)
 			} else {
 				require.False(t, endpointHit)
 			}
@@ -152,7 +157,7 @@ func TestBearerAuthenticator_GenerateToken(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	bth := mockAuth.NewMockBearerTokenHandler(ctrl)
-	ba := NewBearerAuthenticator(bth)
+	ba := NewBearerAuthenticator(bth, nil)
 
 	bth.EXPECT().Generate(&referencePayload.UserPrincipal).Times(1).Return(validToken, nil)
 
@@ -250,7 +255,7 @@ func TestEnsureAuthorizedUser_NoMiddleware(t *testing.T) {
 	ts.router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusForbidden, w.Code)
-	assert.Equal(t, `{"title":"Forbidden. Required user role: admin","status":403}`, w.Body.String())
+	assert.Equal(t, `{"type":"https://course-watch.com/problems/forbidden","title":"Forbidden","status":403,"detail":"required user role: admin","code":"forbidden"}`, w.Body.String())
 	assert.False(t, runPastAuthorizeCheck)
 }
 
@@ -295,7 +300,7 @@ func TestBearerAuthenticator_Authorize(t *testing.T) {
 			userRoles:          []security.Role{security.Student},
 			expectedParseError: nil,
 			expectedStatusCode: http.StatusForbidden,
-			expectedBody:       `{"title":"Forbidden. Required user role: admin","status":403}`,
+			expectedBody:       `{"type":"https://course-watch.com/problems/forbidden","title":"Forbidden","status":403,"detail":"required user role: admin","code":"forbidden"}`,
 		},
 	}
 
@@ -331,3 +336,50 @@ func TestBearerAuthenticator_Authorize(t *testing.T) {
 		})
 	}
 }
+
+func TestBearerAuthenticator_Authenticate_SessionValidation(t *testing.T) {
+	cases := map[string]struct {
+		validationError    error
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"active_session": {
+			validationError:    nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       testData,
+		},
+		"revoked_session": {
+			validationError:    errors.New("session is revoked"),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       unauthorizedMessageBody,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bth := mockAuth.NewMockBearerTokenHandler(ctrl)
+			sv := mockAuth.NewMockSessionValidator(ctrl)
+			ba := NewBearerAuthenticator(bth, sv)
+
+			router := gin.New()
+			router.Use(v1.Problems())
+			g := router.Group("/secure", ba.Authenticate)
+			g.GET("/data", func(context *gin.Context) {
+				context.String(http.StatusOK, testData)
+			})
+
+			bth.EXPECT().Parse(validToken).Times(1).Return(referencePayload, nil)
+			sv.EXPECT().ValidateSession(gomock.Any(), &referencePayload.UserPrincipal).Times(1).Return(c.validationError)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/secure/data", nil)
+			req.Header.Add("Authorization", "Bearer "+validToken)
+
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, c.expectedStatusCode, w.Code)
+			require.Equal(t, c.expectedBody, w.Body.String())
+		})
+	}
+}
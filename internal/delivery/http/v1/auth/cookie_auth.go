@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// TokenCookie holds the access token in cookie mode. It is HttpOnly, so scripts cannot read it
+	TokenCookie = "cw_access_token"
+	// CSRFCookie holds the CSRF token in cookie mode. Scripts read it and echo it back in CSRFHeader
+	CSRFCookie = "cw_csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+	cookiePath = "/"
+)
+
+// CookieSettings configure the cookie session mode for browser clients
+type CookieSettings struct {
+	// Domain of the cookies. When empty, the cookies are only sent to the host which set them
+	Domain   string
+	SameSite http.SameSite
+}
+
+// EnableCookies switches on the cookie session mode. In this mode the access token can also be passed in the
+// TokenCookie instead of the Authorization header. Requests authenticated by the cookie which may change state (all
+// methods except GET, HEAD and OPTIONS) must pass the value of the CSRFCookie in the CSRFHeader (double-submit)
+func (ba *BearerAuthenticator) EnableCookies(settings CookieSettings) {
+	ba.cookies = &settings
+}
+
+// CookieMode reports whether login endpoints should set the session cookies instead of returning the access token
+func (ba *BearerAuthenticator) CookieMode() bool {
+	return ba.cookies != nil
+}
+
+// SetTokenCookies sets the access token cookie and a new CSRF token cookie, both expiring with the token. Returns the
+// CSRF token, so that it can also be passed to clients which cannot read the cookies of the API host
+func (ba *BearerAuthenticator) SetTokenCookies(ctx *gin.Context, token string) (string, error) {
+	if ba.cookies == nil {
+		return "", errors.New("cookie mode is disabled")
+	}
+	csrf := make([]byte, 32)
+	if _, err := rand.Read(csrf); err != nil {
+		return "", err
+	}
+	csrfToken := base64.RawURLEncoding.EncodeToString(csrf)
+	maxAge := int(ba.tokenHandler.GetTokenTtl().Seconds())
+	ctx.SetSameSite(ba.cookies.SameSite)
+	ctx.SetCookie(TokenCookie, token, maxAge, cookiePath, ba.cookies.Domain, true, true)
+	ctx.SetCookie(CSRFCookie, csrfToken, maxAge, cookiePath, ba.cookies.Domain, true, false)
+	return csrfToken, nil
+}
+
+// ClearTokenCookies removes the session cookies. Does nothing if the cookie mode is disabled
+func (ba *BearerAuthenticator) ClearTokenCookies(ctx *gin.Context) {
+	if ba.cookies == nil {
+		return
+	}
+	ctx.SetSameSite(ba.cookies.SameSite)
+	ctx.SetCookie(TokenCookie, "", -1, cookiePath, ba.cookies.Domain, true, true)
+	ctx.SetCookie(CSRFCookie, "", -1, cookiePath, ba.cookies.Domain, true, false)
+}
+
+func (ba *BearerAuthenticator) readTokenCookie(ctx *gin.Context) (string, error) {
+	token, err := ctx.Cookie(TokenCookie)
+	if err != nil || token == "" {
+		return "", errors.New("empty or missing 'Authorization' header and token cookie")
+	}
+	switch ctx.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return token, nil
+	}
+	cookie, err := ctx.Cookie(CSRFCookie)
+	if err != nil || cookie == "" {
+		return "", errors.New("missing CSRF cookie")
+	}
+	header := ctx.GetHeader(CSRFHeader)
+	if subtle.ConstantTimeCompare([]byte(header), []byte(cookie)) != 1 {
+		return "", errors.New("CSRF token mismatch")
+	}
+	return token, nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearerAuthenticator_Authenticate_Cookies(t *testing.T) {
+	cases := map[string]struct {
+		cookieMode         bool
+		method             string
+		header             string
+		cookies            map[string]string
+		csrfHeader         string
+		expectedParseInput string
+		expectedStatusCode int
+	}{
+		"cookie_get": {
+			cookieMode:         true,
+			method:             http.MethodGet,
+			cookies:            map[string]string{TokenCookie: validToken},
+			expectedParseInput: validToken,
+			expectedStatusCode: http.StatusOK,
+		},
+		"cookie_post_with_csrf": {
+			cookieMode:         true,
+			method:             http.MethodPost,
+			cookies:            map[string]string{TokenCookie: validToken, CSRFCookie: "csrf"},
+			csrfHeader:         "csrf",
+			expectedParseInput: validToken,
+			expectedStatusCode: http.StatusOK,
+		},
+		"cookie_post_without_csrf_header": {
+			cookieMode:         true,
+			method:             http.MethodPost,
+			cookies:            map[string]string{TokenCookie: validToken, CSRFCookie: "csrf"},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"cookie_post_without_csrf_cookie": {
+			cookieMode:         true,
+			method:             http.MethodPost,
+			cookies:            map[string]string{TokenCookie: validToken},
+			csrfHeader:         "csrf",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"header_takes_precedence": {
+			cookieMode:         true,
+			method:             http.MethodPost,
+			header:             "Bearer " + validToken,
+			cookies:            map[string]string{TokenCookie: invalidToken},
+			expectedParseInput: validToken,
+			expectedStatusCode: http.StatusOK,
+		},
+		"cookie_mode_disabled": {
+			cookieMode:         false,
+			method:             http.MethodGet,
+			cookies:            map[string]string{TokenCookie: validToken},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		"no_cookie": {
+			cookieMode:         true,
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ts := getTestSetup(t)
+			if c.cookieMode {
+				ts.ba.EnableCookies(CookieSettings{SameSite: http.SameSiteStrictMode})
+			}
+			g := ts.router.Group("/secure", ts.ba.Authenticate)
+			g.Handle(c.method, "/data", func(context *gin.Context) {
+				context.String(http.StatusOK, testData)
+			})
+			if c.expectedParseInput != "" {
+				ts.bth.EXPECT().Parse(c.expectedParseInput).Times(1).Return(referencePayload, nil)
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(c.method, "/secure/data", nil)
+			if c.header != "" {
+				req.Header.Add("Authorization", c.header)
+			}
+			for name, value := range c.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			if c.csrfHeader != "" {
+				req.Header.Add(CSRFHeader, c.csrfHeader)
+			}
+
+			ts.router.ServeHTTP(w, req)
+
+			assert.Equal(t, c.expectedStatusCode, w.Code)
+		})
+	}
+}
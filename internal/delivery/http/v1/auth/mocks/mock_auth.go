@@ -5,6 +5,7 @@
 package mock_auth
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
@@ -78,3 +79,40 @@ func (mr *MockBearerTokenHandlerMockRecorder) Parse(tokenString interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Parse", reflect.TypeOf((*MockBearerTokenHandler)(nil).Parse), tokenString)
 }
+
+// MockSessionValidator is a mock of SessionValidator interface.
+type MockSessionValidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionValidatorMockRecorder
+}
+
+// MockSessionValidatorMockRecorder is the mock recorder for MockSessionValidator.
+type MockSessionValidatorMockRecorder struct {
+	mock *MockSessionValidator
+}
+
+// NewMockSessionValidator creates a new mock instance.
+func NewMockSessionValidator(ctrl *gomock.Controller) *MockSessionValidator {
+	mock := &MockSessionValidator{ctrl: ctrl}
+	mock.recorder = &MockSessionValidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionValidator) EXPECT() *MockSessionValidatorMockRecorder {
+	return m.recorder
+}
+
+// ValidateSession mocks base method.
+func (m *MockSessionValidator) ValidateSession(ctx context.Context, principal *security.UserPrincipal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateSession", ctx, principal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateSession indicates an expected call of ValidateSession.
+func (mr *MockSessionValidatorMockRecorder) ValidateSession(ctx, principal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateSession", reflect.TypeOf((*MockSessionValidator)(nil).ValidateSession), ctx, principal)
+}
@@ -0,0 +1,200 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+// loginWithCookies performs a password login in the cookie session mode and returns the session cookies
+func loginWithCookies(t *testing.T, setup *testSetup) (*service.PostUserLoginOutput, []*http.Cookie) {
+	t.Helper()
+	ctx := context.Background()
+	user := &core.User{Id: sampleUserPrincipal.UserId, Roles: sampleUserPrincipal.Roles}
+	setup.users.EXPECT().Login(ctx, &service.LoginInput{Email: "doe.j@example.com", Password: "secret"}).Return(user, nil).Times(1)
+	setup.sessions.EXPECT().Create(ctx, gomock.Any()).Return(&core.Session{Id: sampleUserPrincipal.SessionId}, nil).Times(1)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"doe.j@example.com","password":"secret"}`))
+	rec := httptest.NewRecorder()
+	setup.router.ServeHTTP(rec, request)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var output service.PostUserLoginOutput
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &output))
+	return &output, rec.Result().Cookies()
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestUserLogin_CookieMode(t *testing.T) {
+	setup := getTestSetup(t)
+	setup.bearer.EnableCookies(auth.CookieSettings{SameSite: http.SameSiteStrictMode})
+
+	output, cookies := loginWithCookies(t, setup)
+
+	assert.Empty(t, output.AccessToken)
+	assert.NotEmpty(t, output.CSRFToken)
+	assert.Equal(t, int(tokenTtl.Seconds()), output.ExpiresIn)
+
+	token := findCookie(cookies, auth.TokenCookie)
+	require.NotNil(t, token)
+	assert.NotEmpty(t, token.Value)
+	assert.True(t, token.HttpOnly)
+	assert.True(t, token.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, token.SameSite)
+
+	csrf := findCookie(cookies, auth.CSRFCookie)
+	require.NotNil(t, csrf)
+	assert.Equal(t, output.CSRFToken, csrf.Value)
+	assert.False(t, csrf.HttpOnly)
+	assert.True(t, csrf.Secure)
+}
+
+func TestUserLogin_BearerMode(t *testing.T) {
+	setup := getTestSetup(t)
+	ctx := context.Background()
+	user := &core.User{Id: sampleUserPrincipal.UserId, Roles: sampleUserPrincipal.Roles}
+	setup.users.EXPECT().Login(ctx, gomock.Any()).Return(user, nil).Times(1)
+	setup.sessions.EXPECT().Create(ctx, gomock.Any()).Return(&core.Session{Id: sampleUserPrincipal.SessionId}, nil).Times(1)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"doe.j@example.com","password":"secret"}`))
+	rec := httptest.NewRecorder()
+	setup.router.ServeHTTP(rec, request)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var output service.PostUserLoginOutput
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &output))
+	assert.NotEmpty(t, output.AccessToken)
+	assert.Empty(t, output.CSRFToken)
+	assert.Empty(t, rec.Result().Cookies())
+}
+
+func TestCookieAuthentication(t *testing.T) {
+	cases := map[string]struct {
+		method       string
+		csrfHeader   func(csrfToken string) string
+		setupMocks   func(ctx context.Context, setup *testSetup)
+		responseCode int
+	}{
+		"read_without_csrf": {
+			method:     http.MethodGet,
+			csrfHeader: func(string) string { return "" },
+			setupMocks: func(ctx context.Context, setup *testSetup) {
+				setup.sessions.EXPECT().List(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(sampleSessions, nil).Times(1)
+			},
+			responseCode: http.StatusOK,
+		},
+		"write_with_csrf": {
+			method:     http.MethodDelete,
+			csrfHeader: func(csrfToken string) string { return csrfToken },
+			setupMocks: func(ctx context.Context, setup *testSetup) {
+				setup.sessions.EXPECT().RevokeOthers(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(nil).Times(1)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		"write_without_csrf": {
+			method:       http.MethodDelete,
+			csrfHeader:   func(string) string { return "" },
+			setupMocks:   func(ctx context.Context, setup *testSetup) {},
+			responseCode: http.StatusUnauthorized,
+		},
+		"write_with_wrong_csrf": {
+			method:       http.MethodDelete,
+			csrfHeader:   func(csrfToken string) string { return csrfToken + "x" },
+			setupMocks:   func(ctx context.Context, setup *testSetup) {},
+			responseCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			setup.bearer.EnableCookies(auth.CookieSettings{SameSite: http.SameSiteStrictMode})
+			output, cookies := loginWithCookies(t, setup)
+			ctx := authenticatedContext()
+			tc.setupMocks(ctx, setup)
+
+			request := httptest.NewRequest(tc.method, "/api/v1/user/sessions", nil)
+			for _, c := range cookies {
+				request.AddCookie(c)
+			}
+			if header := tc.csrfHeader(output.CSRFToken); header != "" {
+				request.Header.Set(auth.CSRFHeader, header)
+			}
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+		})
+	}
+}
+
+func TestUserLogout(t *testing.T) {
+	setup := getTestSetup(t)
+	setup.bearer.EnableCookies(auth.CookieSettings{SameSite: http.SameSiteStrictMode})
+	output, cookies := loginWithCookies(t, setup)
+	ctx := authenticatedContext()
+	setup.sessions.EXPECT().Revoke(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(nil).Times(1)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	for _, c := range cookies {
+		request.AddCookie(c)
+	}
+	request.Header.Set(auth.CSRFHeader, output.CSRFToken)
+	rec := httptest.NewRecorder()
+
+	setup.router.ServeHTTP(rec, request)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	token := findCookie(rec.Result().Cookies(), auth.TokenCookie)
+	require.NotNil(t, token)
+	assert.Empty(t, token.Value)
+	assert.Negative(t, token.MaxAge)
+}
+
+func TestUserLogout_WithoutCSRFToken(t *testing.T) {
+	setup := getTestSetup(t)
+	setup.bearer.EnableCookies(auth.CookieSettings{SameSite: http.SameSiteStrictMode})
+	_, cookies := loginWithCookies(t, setup)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	for _, c := range cookies {
+		request.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+
+	setup.router.ServeHTTP(rec, request)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+}
+
+func TestUserLogout_Unauthorized(t *testing.T) {
+	setup := getTestSetup(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	rec := httptest.NewRecorder()
+
+	setup.router.ServeHTTP(rec, request)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`, rec.Body.String())
+}
@@ -3,8 +3,8 @@ package v1
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
-	"github.com/zhuravlev-pe/course-watch/internal/repository"
 	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
 	"net/http"
 )
 
@@ -12,8 +12,10 @@ func (h *Handler) initCoursesRoutes(api *gin.RouterGroup) {
 	courses := api.Group("/courses")
 	{
 		//courses.GET("", h.getAllCourses)
-		courses.POST("/", h.create)
+		courses.POST("/", h.idempotent, h.create)
 		courses.GET("/:id", h.getCourseById)
+		courses.PUT("/:id", h.bearer.Authorize(security.Admin), h.updateCourse)
+		courses.PATCH("/:id", h.bearer.Authorize(security.Admin), h.patchCourse)
 	}
 }
 
@@ -24,7 +26,10 @@ func (h *Handler) initCoursesRoutes(api *gin.RouterGroup) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "course id"
+// @Param If-None-Match header string false "ETag of the cached version"
 // @Success 200 {object} core.Course
+// @Header 200 {string} ETag "version of the course"
+// @Success 304 "the cached version is current"
 // @Failure 400,404 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Failure default {object} utils.Response
@@ -32,23 +37,84 @@ func (h *Handler) initCoursesRoutes(api *gin.RouterGroup) {
 func (h *Handler) getCourseById(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		utils.ErrorResponseString(c, http.StatusBadRequest, "empty id param")
+		utils.Abort(c, service.ErrInvalidRequest.WithDetail("empty id param"))
 		return
 	}
 
 	course, err := h.services.Courses.GetById(c.Request.Context(), id)
 	if err != nil {
-		if err == repository.ErrNotFound {
-			utils.ErrorResponse(c, http.StatusNotFound, err)
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, err)
+		utils.Abort(c, err)
 		return
 	}
 
+	if notModified(c, course.Version) {
+		return
+	}
 	c.JSON(http.StatusOK, course)
 }
 
+// @Summary Modify a Course
+// @Tags courses
+// @Description replaces the title and description of the course. With If-Match, the update only succeeds if the
+// @Description course has not been modified since. Requires the admin role
+// @ModuleID updateCourse
+// @Accept  json
+// @Produce  json
+// @Param id path string true "course id"
+// @Param input body service.UpdateCourseInput true "course info"
+// @Param If-Match header string false "ETag of the version being modified"
+// @Success 204
+// @Failure 400 {object} utils.ValidationError
+// @Failure 401,403,404,412,500 {object} utils.Response
+// @Router /courses/{id} [put]
+func (h *Handler) updateCourse(c *gin.Context) {
+	var input service.UpdateCourseInput
+	if !h.parseRequestBody(c, &input) {
+		return
+	}
+	var ok bool
+	if input.Version, ok = ifMatchVersion(c); !ok {
+		return
+	}
+
+	if err := h.services.Courses.Update(c.Request.Context(), c.Param("id"), &input); err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Partially modify a Course
+// @Tags courses
+// @Description changes only the fields present in the JSON merge patch (RFC 7386). A null description clears it,
+// @Description the title cannot be removed. Requires the admin role
+// @ModuleID patchCourse
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param id path string true "course id"
+// @Param input body service.PatchCourseInput true "course fields to change"
+// @Param If-Match header string false "ETag of the version being modified"
+// @Success 204
+// @Failure 400 {object} utils.ValidationError
+// @Failure 401,403,404,412,415,500 {object} utils.Response
+// @Router /courses/{id} [patch]
+func (h *Handler) patchCourse(c *gin.Context) {
+	var input service.PatchCourseInput
+	if !h.parseMergePatch(c, &input) {
+		return
+	}
+	var ok bool
+	if input.Version, ok = ifMatchVersion(c); !ok {
+		return
+	}
+
+	if err := h.services.Courses.Patch(c.Request.Context(), c.Param("id"), &input); err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 // @Summary Creates a new Course entity
 // @Tags courses
 // @Description Creates a new Course entity
@@ -56,20 +122,19 @@ func (h *Handler) getCourseById(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param input body service.CreateCourseInput true "sign up info"
+// @Param Idempotency-Key header string false "makes retries return the first response instead of creating duplicates"
 // @Success 201 "The generated id is returned in Location header"
-// @Failure 400 {object} utils.Response
+// @Failure 400,409,422 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /courses/ [post]
 func (h *Handler) create(c *gin.Context) {
 	var input service.CreateCourseInput
-	if err := c.BindJSON(&input); err != nil {
-		utils.ErrorResponseString(c, http.StatusBadRequest, "invalid input body")
+	if !h.parseRequestBody(c, &input) {
 		return
 	}
 	course, err := h.services.Courses.Create(c.Request.Context(), input)
 	if err != nil {
-		//TODO: discriminate between validation errors, logic errors and internal server errors
-		utils.ErrorResponse(c, http.StatusInternalServerError, err)
+		utils.Abort(c, err)
 		return
 	}
 	c.Header("Location", "/"+course.Id)
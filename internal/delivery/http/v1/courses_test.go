@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModifyCourseRequiresAdmin(t *testing.T) {
+	requests := map[string]func() *http.Request{
+		"put": func() *http.Request {
+			return httptest.NewRequest(http.MethodPut, "/api/v1/courses/42", strings.NewReader(`{"title":"Go","description":"basics"}`))
+		},
+		"patch": func() *http.Request {
+			request := httptest.NewRequest(http.MethodPatch, "/api/v1/courses/42", strings.NewReader(`{"title":"Go"}`))
+			request.Header.Set("Content-Type", "application/merge-patch+json")
+			return request
+		},
+	}
+	cases := map[string]struct {
+		authenticated bool
+		responseCode  int
+	}{
+		"anonymous": {authenticated: false, responseCode: http.StatusUnauthorized},
+		"student":   {authenticated: true, responseCode: http.StatusForbidden},
+	}
+
+	for method, newRequest := range requests {
+		for name, tc := range cases {
+			t.Run(method+"_"+name, func(t *testing.T) {
+				setup := getTestSetup(t)
+				request := newRequest()
+				if tc.authenticated {
+					addAuthorizationHeader(request, setup)
+				}
+				rec := httptest.NewRecorder()
+
+				setup.router.ServeHTTP(rec, request)
+
+				assert.Equal(t, tc.responseCode, rec.Code)
+			})
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+// etag formats the version of an entity as a strong entity tag. Versions change with every update, so equal tags
+// mean byte-for-byte equal representations
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// notModified sets the ETag header and answers 304 Not Modified if the If-None-Match header matches it, i.e. the
+// client already has this version. The handler has to return if it does
+func notModified(ctx *gin.Context, version int64) bool {
+	tag := etag(version)
+	ctx.Header("ETag", tag)
+	header := ctx.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		// If-None-Match uses the weak comparison, which ignores the W/ prefix
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			ctx.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the version the If-Match header makes an update conditional on, and zero if the update is
+// unconditional. Only a single strong tag, or *, is supported. For any other header, which could never match, it
+// answers 412 Precondition Failed and returns false
+func ifMatchVersion(ctx *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if len(header) > 2 && strings.HasPrefix(header, `"`) && strings.HasSuffix(header, `"`) {
+		version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+		if err == nil && version > 0 {
+			return version, true
+		}
+	}
+	utils.Abort(ctx, service.ErrPreconditionFailed.WithDetail("If-Match does not match the current version"))
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	serviceMocks "github.com/zhuravlev-pe/course-watch/internal/service/mocks"
+)
+
+func TestGetUserInfoConditional(t *testing.T) {
+	cases := map[string]struct {
+		ifNoneMatch  string
+		responseCode int
+	}{
+		"no_header":      {ifNoneMatch: "", responseCode: http.StatusOK},
+		"current":        {ifNoneMatch: `"3"`, responseCode: http.StatusNotModified},
+		"weak_current":   {ifNoneMatch: `W/"3"`, responseCode: http.StatusNotModified},
+		"one_of_several": {ifNoneMatch: `"1", "3"`, responseCode: http.StatusNotModified},
+		"any":            {ifNoneMatch: "*", responseCode: http.StatusNotModified},
+		"outdated":       {ifNoneMatch: `"2"`, responseCode: http.StatusOK},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			setup.users.EXPECT().GetUserInfo(authenticatedContext(), sampleUserPrincipal.UserId).Return(sampleUserInfo, nil).Times(1)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+			addAuthorizationHeader(request, setup)
+			if tc.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+			assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+			if tc.responseCode == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			} else {
+				assert.NotEmpty(t, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateUserInfoConditional(t *testing.T) {
+	const body = `{"first_name":"UpdatedFirstName","last_name":"UpdatedLastName","display_name":"UpdatedDisplayName"}`
+	input := func(version int64) *service.UpdateUserInfoInput {
+		return &service.UpdateUserInfoInput{
+			FirstName:   "UpdatedFirstName",
+			LastName:    "UpdatedLastName",
+			DisplayName: "UpdatedDisplayName",
+			Version:     version,
+		}
+	}
+
+	cases := map[string]struct {
+		ifMatch      string
+		setupMocks   func(ctx context.Context, mockUsers *serviceMocks.MockUsers)
+		responseCode int
+		responseBody string
+	}{
+		"unconditional": {
+			ifMatch: "",
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				mockUsers.EXPECT().UpdateUserInfo(ctx, sampleUserPrincipal.UserId, input(0)).Return(nil).Times(1)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		"any": {
+			ifMatch: "*",
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				mockUsers.EXPECT().UpdateUserInfo(ctx, sampleUserPrincipal.UserId, input(0)).Return(nil).Times(1)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		"current": {
+			ifMatch: `"3"`,
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				mockUsers.EXPECT().UpdateUserInfo(ctx, sampleUserPrincipal.UserId, input(3)).Return(nil).Times(1)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		"outdated": {
+			ifMatch: `"2"`,
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				mockUsers.EXPECT().UpdateUserInfo(ctx, sampleUserPrincipal.UserId, input(2)).Return(repository.ErrVersionConflict).Times(1)
+			},
+			responseCode: http.StatusPreconditionFailed,
+			responseBody: `{"type":"https://course-watch.com/problems/version_conflict","title":"the resource has been modified","status":412,"code":"version_conflict"}`,
+		},
+		"weak": {
+			ifMatch:      `W/"3"`,
+			setupMocks:   func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
+			responseCode: http.StatusPreconditionFailed,
+			responseBody: `{"type":"https://course-watch.com/problems/precondition_failed","title":"precondition failed","status":412,"detail":"If-Match does not match the current version","code":"precondition_failed"}`,
+		},
+		"not_a_version": {
+			ifMatch:      `"abc"`,
+			setupMocks:   func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
+			responseCode: http.StatusPreconditionFailed,
+			responseBody: `{"type":"https://course-watch.com/problems/precondition_failed","title":"precondition failed","status":412,"detail":"If-Match does not match the current version","code":"precondition_failed"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			tc.setupMocks(authenticatedContext(), setup.users)
+
+			request := httptest.NewRequest(http.MethodPut, "/api/v1/user", strings.NewReader(body))
+			addAuthorizationHeader(request, setup)
+			if tc.ifMatch != "" {
+				request.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+			assert.Equal(t, tc.responseBody, rec.Body.String())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/zhuravlev-pe/course-watch/internal/service"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
@@ -10,20 +11,28 @@ import (
 type Handler struct {
 	services *service.Services
 	bearer   BearerAuthenticator
-	//TODO: logger
+	// clients authenticates the access tokens issued to OAuth clients. They are signed with a different key and
+	// audience than first-party tokens, so that clients can only call the endpoints meant for them
+	clients BearerAuthenticator
 }
 
 type BearerAuthenticator interface {
 	Authenticate(ctx *gin.Context)
+	Identify(ctx *gin.Context) (*security.UserPrincipal, error)
+	Inspect(ctx context.Context, token string) (*security.JwtPayload, error)
 	Authorize(role security.Role) func(ctx *gin.Context)
 	GenerateToken(principal *security.UserPrincipal) (string, error)
 	GetTokenTtl() time.Duration
+	CookieMode() bool
+	SetTokenCookies(ctx *gin.Context, token string) (string, error)
+	ClearTokenCookies(ctx *gin.Context)
 }
 
-func NewHandler(services *service.Services, bearer BearerAuthenticator) *Handler {
+func NewHandler(services *service.Services, bearer BearerAuthenticator, clients BearerAuthenticator) *Handler {
 	return &Handler{
 		services: services,
 		bearer:   bearer,
+		clients:  clients,
 	}
 }
 
@@ -33,5 +42,6 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 		h.initCoursesRoutes(v1)
 		h.initUserRoutes(v1)
 		h.initAuthRoutes(v1)
+		h.initOAuthClientRoutes(v1)
 	}
 }
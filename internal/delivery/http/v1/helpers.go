@@ -1,34 +1,45 @@
 package v1
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
-	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
-	"github.com/zhuravlev-pe/course-watch/internal/repository"
-	"net/http"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"io"
 )
 
+const mergePatchContentType = "application/merge-patch+json"
+
 func (h *Handler) parseRequestBody(ctx *gin.Context, input interface{}) bool {
-	if err := ctx.BindJSON(input); err != nil {
-		utils.ErrorResponseMessageOverride(ctx, http.StatusBadRequest, err, "body is missing or invalid")
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		utils.Abort(ctx, service.ErrInvalidBody.WithCause(err))
 		return false
 	}
 	return true
 }
 
-func (h *Handler) handleServiceError(ctx *gin.Context, err error) {
-	if err == repository.ErrNotFound {
-		utils.ErrorResponse(ctx, http.StatusNotFound, err)
-		return
+// parseMergePatch decodes a JSON merge patch (RFC 7386) into input, whose patch.Field members tell missing members
+// and nulls apart. The patch must be an object, and unknown members are rejected rather than silently ignored
+func (h *Handler) parseMergePatch(ctx *gin.Context, input interface{}) bool {
+	if ctx.ContentType() != mergePatchContentType {
+		ctx.Header("Accept-Patch", mergePatchContentType)
+		utils.Abort(ctx, service.ErrUnsupportedMediaType.WithDetail("content type must be "+mergePatchContentType))
+		return false
 	}
-
-	var validationErrors validation.Errors
-	if errors.As(err, &validationErrors) {
-		utils.ValidationErrorResponse(ctx, validationErrors)
-		return
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err == nil && !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		err = errors.New("merge patch is not an object")
 	}
-
-	utils.ErrorResponseMessageOverride(ctx, http.StatusInternalServerError, err, "internal server error")
-	return
+	if err == nil {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(input)
+	}
+	if err != nil {
+		utils.Abort(ctx, service.ErrInvalidBody.WithCause(err))
+		return false
+	}
+	return true
 }
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/logger"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored for replays, in addition to the status and the body
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// idempotent lets clients retry the request safely by passing the same Idempotency-Key header: the first response
+// for the key, user and route is stored and replayed to the retries. Anonymous callers are told apart by their
+// address. Server errors are not stored, a retry of such a request is processed again. Requests without the header
+// are passed through
+func (h *Handler) idempotent(ctx *gin.Context) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		utils.Abort(ctx, service.ErrInvalidRequest.WithDetail("Idempotency-Key must not exceed 255 characters"))
+		return
+	}
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		utils.Abort(ctx, service.ErrInvalidBody.WithCause(err))
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	request := &service.IdempotentRequest{
+		Caller: ctx.ClientIP(),
+		Route:  ctx.Request.Method + " " + ctx.FullPath(),
+		Key:    key,
+		Body:   body,
+	}
+	if up, err := h.bearer.Identify(ctx); err == nil {
+		request.UserId = up.UserId
+	}
+
+	response, err := h.services.Idempotency.Begin(ctx.Request.Context(), request)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	if response != nil {
+		replay(ctx, response)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+	ctx.Writer = recorder
+	stored := false
+	defer func() {
+		// runs on panics too, which are turned into server errors further up the chain
+		if !stored {
+			if err := h.services.Idempotency.Release(ctx.Request.Context(), request); err != nil {
+				logger.FromContext(ctx.Request.Context()).Error().Err(err).Msg("failed to release the idempotency key")
+			}
+		}
+	}()
+
+	ctx.Next()
+	// errors are rendered on the way out, they have to be recorded too
+	utils.Flush(ctx)
+
+	if recorder.Status() >= http.StatusInternalServerError {
+		return
+	}
+	response = &service.IdempotentResponse{
+		StatusCode: recorder.Status(),
+		Headers:    map[string]string{},
+		Body:       recorder.body.Bytes(),
+	}
+	for _, name := range replayedHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			response.Headers[name] = value
+		}
+	}
+	if err = h.services.Idempotency.Complete(ctx.Request.Context(), request, response); err != nil {
+		logger.FromContext(ctx.Request.Context()).Error().Err(err).Msg("failed to store the idempotent response")
+		return
+	}
+	stored = true
+}
+
+func replay(ctx *gin.Context, response *service.IdempotentResponse) {
+	for name, value := range response.Headers {
+		ctx.Header(name, value)
+	}
+	ctx.Header("Idempotent-Replayed", "true")
+	ctx.AbortWithStatus(response.StatusCode)
+	_, _ = ctx.Writer.Write(response.Body)
+}
+
+// responseRecorder keeps a copy of the response body
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+const sampleSignupBody = `{"email":"new@example.com","password":"secret","first_name":"Jane","last_name":"Doe"}`
+
+var sampleSignupInput = &service.SignupUserInput{
+	Email:     "new@example.com",
+	Password:  "secret",
+	FirstName: "Jane",
+	LastName:  "Doe",
+}
+
+func getIdempotencyTestSetup(t *testing.T) *testSetup {
+	t.Helper()
+	setup := getTestSetup(t)
+	setup.handler.services.Idempotency = service.NewServices(service.Deps{
+		Repos:       fake_repo.New(),
+		Idempotency: service.IdempotencySettings{TTL: time.Hour, LockTimeout: time.Minute},
+	}).Idempotency
+	return setup
+}
+
+func signup(setup *testSetup, key string, body string) *httptest.ResponseRecorder {
+	return signupFrom(setup, "192.0.2.1:41000", key, body)
+}
+
+func signupFrom(setup *testSetup, remoteAddr string, key string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", strings.NewReader(body))
+	request.RemoteAddr = remoteAddr
+	if key != "" {
+		request.Header.Set(idempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	setup.router.ServeHTTP(rec, request)
+	return rec
+}
+
+func TestIdempotent_Replay(t *testing.T) {
+	setup := getIdempotencyTestSetup(t)
+	setup.users.EXPECT().Signup(context.Background(), sampleSignupInput).Return(nil).Times(1)
+
+	first := signup(setup, "key-1", sampleSignupBody)
+	retry := signup(setup, "key-1", sampleSignupBody)
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotent_AnonymousCallersDoNotShareKeys(t *testing.T) {
+	setup := getIdempotencyTestSetup(t)
+	setup.users.EXPECT().Signup(context.Background(), sampleSignupInput).Return(nil).Times(2)
+
+	first := signupFrom(setup, "192.0.2.1:41000", "key-1", sampleSignupBody)
+	other := signupFrom(setup, "198.51.100.7:52000", "key-1", sampleSignupBody)
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, other.Code)
+	assert.Empty(t, other.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotent_ReplaysClientErrors(t *testing.T) {
+	setup := getIdempotencyTestSetup(t)
+	setup.users.EXPECT().Signup(context.Background(), sampleSignupInput).Return(service.ErrUserAlreadyExist).Times(1)
+
+	first := signup(setup, "key-1", sampleSignupBody)
+	retry := signup(setup, "key-1", sampleSignupBody)
+
+	assert.Equal(t, http.StatusConflict, first.Code)
+	assert.Contains(t, first.Body.String(), `"code":"user_already_exists"`)
+	assert.Equal(t, first.Code, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, utils.ProblemContentType, retry.Header().Get("Content-Type"))
+}
+
+func TestIdempotent_DifferentBody(t *testing.T) {
+	setup := getIdempotencyTestSetup(t)
+	setup.users.EXPECT().Signup(context.Background(), sampleSignupInput).Return(nil).Times(1)
+
+	signup(setup, "key-1", sampleSignupBody)
+	rec := signup(setup, "key-1", strings.Replace(sampleSignupBody, "Jane", "John", 1))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, `{"type":"https://course-watch.com/problems/idempotency_key_reused","title":"idempotency key has already been used for a different request","status":422,"code":"idempotency_key_reused"}`, rec.Body.String())
+}
+
+func TestIdempotent_ServerErrorIsNotStored(t *testing.T) {
+	setup := getIdempotencyTestSetup(t)
+	failure := setup.users.EXPECT().Signup(context.Background(), sampleSignupInput).Return(someDatabaseError).Times(1)
+	setup.users.EXPECT().Signup(context.Background(), sampleSignupInput).Return(nil).Times(1).After(failure)
+
+	first := signup(setup, "key-1", sampleSignupBody)
+	retry := signup(setup, "key-1", sampleSignupBody)
+
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotent_WithoutKey(t *testing.T) {
+	setup := getIdempotencyTestSetup(t)
+	setup.users.EXPECT().Signup(context.Background(), sampleSignupInput).Return(nil).Times(2)
+
+	assert.Equal(t, http.StatusOK, signup(setup, "", sampleSignupBody).Code)
+	assert.Equal(t, http.StatusOK, signup(setup, "", sampleSignupBody).Code)
+}
+
+func TestIdempotent_KeyTooLong(t *testing.T) {
+	setup := getIdempotencyTestSetup(t)
+
+	rec := signup(setup, strings.Repeat("k", maxIdempotencyKeyLength+1), sampleSignupBody)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
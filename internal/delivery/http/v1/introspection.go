@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+func (h *Handler) initIntrospectionRoutes(api *gin.RouterGroup) {
+	api.POST("/introspect", h.introspectToken)
+	api.POST("/revoke", h.revokeToken)
+}
+
+// @Summary Token introspection
+// @Tags Authentication
+// @Description tells resource servers whether an access token is active, see RFC 7662. Requires the credentials of a confidential OAuth client, preferably via HTTP Basic authentication
+// @ModuleID introspectToken
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param token formData string true "access token"
+// @Param token_type_hint formData string false "ignored, only access tokens are supported"
+// @Success 200 {object} security.IntrospectionResponse
+// @Failure 400,401 {object} service.OAuthError
+// @Failure 500 {object} utils.Response
+// @Router /auth/introspect [Post]
+func (h *Handler) introspectToken(ctx *gin.Context) {
+	input, ok := h.bindTokenRequest(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	payload, err := h.inspectToken(ctx.Request.Context(), input.Token)
+	if err != nil {
+		// the reason is not disclosed, see RFC 7662 section 2.2
+		ctx.JSON(http.StatusOK, &security.IntrospectionResponse{Active: false})
+		return
+	}
+	ctx.JSON(http.StatusOK, security.NewIntrospectionResponse(payload))
+}
+
+// @Summary Token revocation
+// @Tags Authentication
+// @Description ends the login session an access token was issued for, see RFC 7009. Invalid and inactive tokens are ignored. Requires the credentials of a confidential OAuth client, preferably via HTTP Basic authentication
+// @ModuleID revokeToken
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param token formData string true "access token"
+// @Param token_type_hint formData string false "ignored, only access tokens are supported"
+// @Success 200
+// @Failure 400,401 {object} service.OAuthError
+// @Failure 500 {object} utils.Response
+// @Router /auth/revoke [Post]
+func (h *Handler) revokeToken(ctx *gin.Context) {
+	input, ok := h.bindTokenRequest(ctx)
+	if !ok {
+		return
+	}
+
+	// first-party tokens are not bound to clients, so any registered client may revoke one it holds. Tokens issued to
+	// an OAuth client may only be revoked by that client, see RFC 7009 section 2.1
+	payload, err := h.inspectToken(ctx.Request.Context(), input.Token)
+	if err != nil {
+		ctx.Status(http.StatusOK)
+		return
+	}
+	if payload.ClientId != "" && payload.ClientId != input.ClientId {
+		oauthErrorResponse(ctx, http.StatusBadRequest, &service.OAuthError{Code: "unauthorized_client", Description: "the token was issued to another client"})
+		return
+	}
+	err = h.services.Sessions.Revoke(ctx.Request.Context(), payload.UserId, payload.SessionId)
+	if err != nil && err != repository.ErrNotFound {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// inspectToken validates first-party tokens as well as those issued to OAuth clients
+func (h *Handler) inspectToken(ctx context.Context, token string) (*security.JwtPayload, error) {
+	payload, err := h.bearer.Inspect(ctx, token)
+	if err != nil {
+		return h.clients.Inspect(ctx, token)
+	}
+	return payload, nil
+}
+
+// bindTokenRequest parses an introspection or revocation request and authenticates the client. Writes the error
+// response and returns false on failure
+func (h *Handler) bindTokenRequest(ctx *gin.Context) (*service.OAuthTokenRequestInput, bool) {
+	var input service.OAuthTokenRequestInput
+	if err := ctx.ShouldBindWith(&input, binding.Form); err != nil || !readClientCredentials(ctx, &input.ClientId, &input.ClientSecret) {
+		oauthErrorResponse(ctx, http.StatusBadRequest, &service.OAuthError{Code: "invalid_request"})
+		return nil, false
+	}
+
+	_, err := h.services.OAuth.AuthenticateConfidentialClient(ctx.Request.Context(), input.ClientId, input.ClientSecret)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			oauthClientErrorResponse(ctx, oauthErr)
+			return nil, false
+		}
+		utils.Abort(ctx, err)
+		return nil, false
+	}
+
+	if input.Token == "" {
+		oauthErrorResponse(ctx, http.StatusBadRequest, &service.OAuthError{Code: "invalid_request", Description: "token is required"})
+		return nil, false
+	}
+	return &input, true
+}
@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+const (
+	resourceServerId     = "1600000000000000001"
+	resourceServerSecret = "resource-server-secret"
+)
+
+func expectResourceServerAuthentication(ctx context.Context, setup *testSetup) {
+	setup.oauth.EXPECT().AuthenticateConfidentialClient(ctx, resourceServerId, resourceServerSecret).
+		Return(&service.OAuthClientOutput{Id: resourceServerId, Name: "reports"}, nil).Times(1)
+}
+
+func TestIntrospectToken(t *testing.T) {
+	cases := map[string]struct {
+		setupMocks     func(ctx context.Context, setup *testSetup)
+		prepareRequest func(request *http.Request, setup *testSetup)
+		token          func(setup *testSetup) string
+		// postCredentials passes the client credentials in the body instead of the Authorization header
+		postCredentials bool
+		responseCode    int
+		responseBody    string
+	}{
+		"active": {
+			setupMocks: expectResourceServerAuthentication,
+			prepareRequest: func(request *http.Request, setup *testSetup) {
+				request.SetBasicAuth(resourceServerId, resourceServerSecret)
+			},
+			token:        func(setup *testSetup) string { return setup.sampleUserToken },
+			responseCode: http.StatusOK,
+			responseBody: `"active":true,"token_type":"Bearer","sub":"1582550893222432768","roles":["student"],"iss":"course-watch","aud":["course-watch-api"]`,
+		},
+		"inactive": {
+			setupMocks: expectResourceServerAuthentication,
+			prepareRequest: func(request *http.Request, setup *testSetup) {
+				request.SetBasicAuth(resourceServerId, resourceServerSecret)
+			},
+			token:        func(setup *testSetup) string { return "not.a.token" },
+			responseCode: http.StatusOK,
+			responseBody: `{"active":false}`,
+		},
+		"client_secret_post": {
+			setupMocks:      expectResourceServerAuthentication,
+			prepareRequest:  func(request *http.Request, setup *testSetup) {},
+			token:           func(setup *testSetup) string { return "not.a.token" },
+			postCredentials: true,
+			responseCode:    http.StatusOK,
+			responseBody:    `{"active":false}`,
+		},
+		"invalid_client": {
+			setupMocks: func(ctx context.Context, setup *testSetup) {
+				setup.oauth.EXPECT().AuthenticateConfidentialClient(ctx, resourceServerId, "wrong").
+					Return(nil, &service.OAuthError{Code: "invalid_client", Description: "client authentication failed"}).Times(1)
+			},
+			prepareRequest: func(request *http.Request, setup *testSetup) {
+				request.SetBasicAuth(resourceServerId, "wrong")
+			},
+			token:        func(setup *testSetup) string { return setup.sampleUserToken },
+			responseCode: http.StatusUnauthorized,
+			responseBody: `{"error":"invalid_client","error_description":"client authentication failed"}`,
+		},
+		"missing_token": {
+			setupMocks: expectResourceServerAuthentication,
+			prepareRequest: func(request *http.Request, setup *testSetup) {
+				request.SetBasicAuth(resourceServerId, resourceServerSecret)
+			},
+			token:        func(setup *testSetup) string { return "" },
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"error":"invalid_request","error_description":"token is required"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			ctx := context.Background()
+			tc.setupMocks(ctx, setup)
+
+			form := url.Values{"token": {tc.token(setup)}}
+			if tc.postCredentials {
+				form.Set("client_id", resourceServerId)
+				form.Set("client_secret", resourceServerSecret)
+			}
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/introspect", strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			tc.prepareRequest(request, setup)
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tc.responseBody)
+			assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	cases := map[string]struct {
+		setupMocks   func(ctx context.Context, setup *testSetup)
+		token        func(setup *testSetup) string
+		responseCode int
+	}{
+		"success": {
+			setupMocks: func(ctx context.Context, setup *testSetup) {
+				expectResourceServerAuthentication(ctx, setup)
+				setup.sessions.EXPECT().Revoke(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(nil).Times(1)
+			},
+			token:        func(setup *testSetup) string { return setup.sampleUserToken },
+			responseCode: http.StatusOK,
+		},
+		"already_revoked": {
+			setupMocks: func(ctx context.Context, setup *testSetup) {
+				expectResourceServerAuthentication(ctx, setup)
+				setup.sessions.EXPECT().Revoke(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(repository.ErrNotFound).Times(1)
+			},
+			token:        func(setup *testSetup) string { return setup.sampleUserToken },
+			responseCode: http.StatusOK,
+		},
+		"invalid_token": {
+			setupMocks: func(ctx context.Context, setup *testSetup) {
+				expectResourceServerAuthentication(ctx, setup)
+				setup.sessions.EXPECT().Revoke(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			token:        func(setup *testSetup) string { return "not.a.token" },
+			responseCode: http.StatusOK,
+		},
+		"internal_server_err": {
+			setupMocks: func(ctx context.Context, setup *testSetup) {
+				expectResourceServerAuthentication(ctx, setup)
+				setup.sessions.EXPECT().Revoke(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(someDatabaseError).Times(1)
+			},
+			token:        func(setup *testSetup) string { return setup.sampleUserToken },
+			responseCode: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			ctx := context.Background()
+			tc.setupMocks(ctx, setup)
+
+			form := url.Values{"token": {tc.token(setup)}, "token_type_hint": {"access_token"}}
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/auth/revoke", strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.SetBasicAuth(resourceServerId, resourceServerSecret)
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+		})
+	}
+}
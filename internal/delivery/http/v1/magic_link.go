@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+const (
+	magicLinkNonceCookie = "magic_link_nonce"
+	magicLinkCookiePath  = "/api/v1/auth/magic-link"
+)
+
+// @Summary Request a passwordless login link
+// @Tags Authentication
+// @Description emails a single-use login link to the given address, if it belongs to a registered user. The link is bound to the calling browser via a cookie and must be exchanged from the same browser
+// @ModuleID requestMagicLink
+// @Accept  json
+// @Produce  json
+// @Param input body service.MagicLinkRequestInput true "email address"
+// @Success 202
+// @Failure 400             {object} utils.ValidationError
+// @Failure 429,500         {object} utils.Response
+// @Router /auth/magic-link [post]
+func (h *Handler) requestMagicLink(ctx *gin.Context) {
+	var input service.MagicLinkRequestInput
+	if !h.parseRequestBody(ctx, &input) {
+		return
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+
+	err = h.services.MagicLinks.Request(ctx.Request.Context(), &input, nonce)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteStrictMode)
+	ctx.SetCookie(magicLinkNonceCookie, nonce, 0, magicLinkCookiePath, "", true, true)
+	ctx.Status(http.StatusAccepted)
+}
+
+// @Summary Log in with a magic link
+// @Tags Authentication
+// @Description exchanges the token from a login link for an access token. Must be called from the browser which requested the link
+// @ModuleID exchangeMagicLink
+// @Accept  json
+// @Produce  json
+// @Param input body service.MagicLinkExchangeInput true "token from the login link"
+// @Success 200 {object} service.PostUserLoginOutput
+// @Failure 400,401,500 {object} utils.Response
+// @Router /auth/magic-link/exchange [post]
+func (h *Handler) exchangeMagicLink(ctx *gin.Context) {
+	var input service.MagicLinkExchangeInput
+	if !h.parseRequestBody(ctx, &input) {
+		return
+	}
+
+	nonce, err := ctx.Cookie(magicLinkNonceCookie)
+	if err != nil {
+		utils.Abort(ctx, service.ErrInvalidMagicLink)
+		return
+	}
+
+	user, err := h.services.MagicLinks.Exchange(ctx.Request.Context(), input.Token, nonce)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+
+	output, err := h.startSession(ctx, user, input.DeviceLabel)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.SetSameSite(http.SameSiteStrictMode)
+	ctx.SetCookie(magicLinkNonceCookie, "", -1, magicLinkCookiePath, "", true, true)
+	h.sessionResponse(ctx, output)
+}
+
+func generateNonce() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+// InitAuthorizationServer registers the OAuth 2.0 / OpenID Connect endpoints. They are standard protocol endpoints
+// outside the versioned API: their paths are derived from the issuer (https://<host>/auth), and errors are reported
+// in the OAuth format rather than as problem details
+func (h *Handler) InitAuthorizationServer(router gin.IRouter) {
+	router.GET("/.well-known/openid-configuration", h.openIdConfiguration)
+	oauth := router.Group("/auth")
+	{
+		// discovery document location as derived from the issuer by OpenID Connect Discovery
+		oauth.GET("/.well-known/openid-configuration", h.openIdConfiguration)
+		oauth.GET("/jwks", h.jsonWebKeySet)
+		oauth.GET("/authorize", h.oauthAuthorize)
+		oauth.POST("/authorize", h.oauthAuthorize)
+		oauth.POST("/token", h.oauthToken)
+	}
+	// only the tokens issued to OAuth clients are accepted here, see oauthToken
+	userInfo := router.Group("/auth/userinfo", h.clients.Authenticate)
+	{
+		userInfo.GET("", h.oauthUserInfo)
+		userInfo.POST("", h.oauthUserInfo)
+	}
+}
+
+func (h *Handler) openIdConfiguration(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.services.OAuth.Discovery())
+}
+
+func (h *Handler) jsonWebKeySet(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.services.OAuth.KeySet())
+}
+
+// oauthAuthorize handles authorization requests. Browsers navigating here (GET) are redirected back to the client,
+// or to the login page. Frontends which hold a bearer token may POST the same parameters instead and receive the
+// redirect URI in the response body
+func (h *Handler) oauthAuthorize(ctx *gin.Context) {
+	var input service.OAuthAuthorizeInput
+	if err := ctx.ShouldBindWith(&input, binding.Form); err != nil {
+		oauthErrorResponse(ctx, http.StatusBadRequest, &service.OAuthError{Code: "invalid_request"})
+		return
+	}
+
+	var userId string
+	if up, err := h.bearer.Identify(ctx); err == nil {
+		userId = up.UserId
+	}
+
+	output, err := h.services.OAuth.Authorize(ctx.Request.Context(), &input, userId)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		switch {
+		case errors.As(err, &oauthErr):
+			oauthErrorResponse(ctx, http.StatusBadRequest, oauthErr)
+		default:
+			utils.Abort(ctx, err)
+		}
+		return
+	}
+
+	if ctx.Request.Method == http.MethodPost {
+		ctx.JSON(http.StatusOK, output)
+		return
+	}
+	ctx.Redirect(http.StatusFound, output.RedirectURI)
+}
+
+func (h *Handler) oauthToken(ctx *gin.Context) {
+	var input service.OAuthTokenInput
+	if err := ctx.ShouldBindWith(&input, binding.Form); err != nil {
+		oauthErrorResponse(ctx, http.StatusBadRequest, &service.OAuthError{Code: "invalid_request"})
+		return
+	}
+	if !readClientCredentials(ctx, &input.ClientId, &input.ClientSecret) {
+		oauthErrorResponse(ctx, http.StatusBadRequest, &service.OAuthError{Code: "invalid_request"})
+		return
+	}
+
+	grant, err := h.services.OAuth.ExchangeCode(ctx.Request.Context(), &input)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			oauthClientErrorResponse(ctx, oauthErr)
+			return
+		}
+		utils.Abort(ctx, err)
+		return
+	}
+
+	// the client gets an access token bound to a new session, so that the user can see and revoke it like any other
+	// login. Unlike first-party tokens, it carries no roles, and the client and the granted scope are bound to it
+	session, err := h.createSession(ctx, grant.User.Id, grant.Client.Name)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	token, err := h.clients.GenerateToken(&security.UserPrincipal{
+		UserId:    grant.User.Id,
+		SessionId: session.Id,
+		ClientId:  grant.Client.Id,
+		Scope:     grant.Scope,
+	})
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+	ctx.JSON(http.StatusOK, &service.OAuthTokenOutput{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(h.clients.GetTokenTtl().Seconds()),
+		Scope:       grant.Scope,
+		IdToken:     grant.IdToken,
+	})
+}
+
+func (h *Handler) oauthUserInfo(ctx *gin.Context) {
+	up, err := auth.GetAuthenticatedUser(ctx)
+	if err != nil {
+		err = fmt.Errorf("authentication middleware failure: %w", err)
+		utils.Abort(ctx, err)
+		return
+	}
+
+	result, err := h.services.OAuth.UserInfo(ctx.Request.Context(), up.UserId, up.Scope)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// readClientCredentials overrides the client credentials from the form (client_secret_post) with those of the
+// Authorization header (client_secret_basic), if any. Returns false if the header is malformed
+func readClientCredentials(ctx *gin.Context, clientId *string, clientSecret *string) bool {
+	id, secret, ok := ctx.Request.BasicAuth()
+	if !ok {
+		return true
+	}
+	// credentials are form-encoded before being put into the header, see RFC 6749 section 2.3.1
+	var err1, err2 error
+	*clientId, err1 = url.QueryUnescape(id)
+	*clientSecret, err2 = url.QueryUnescape(secret)
+	return err1 == nil && err2 == nil
+}
+
+// oauthClientErrorResponse reports errors of requests made by clients, invalid_client requires 401, see RFC 6749
+// section 5.2
+func oauthClientErrorResponse(ctx *gin.Context, err *service.OAuthError) {
+	status := http.StatusBadRequest
+	if err.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		ctx.Header("WWW-Authenticate", `Basic realm="course-watch"`)
+	}
+	oauthErrorResponse(ctx, status, err)
+}
+
+func oauthErrorResponse(ctx *gin.Context, status int, err *service.OAuthError) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.AbortWithStatusJSON(status, err)
+}
@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+func (h *Handler) initOAuthClientRoutes(api *gin.RouterGroup) {
+	clients := api.Group("/admin/oauth-clients", h.bearer.Authorize(security.Admin))
+	{
+		clients.GET("", h.listOAuthClients)
+		clients.POST("", h.registerOAuthClient)
+		clients.DELETE("/:id", h.deleteOAuthClient)
+	}
+}
+
+// @Summary Register an OAuth client
+// @Tags Admin
+// @Description registers an application which logs users in through Course Watch. The client secret is only returned in this response
+// @ModuleID registerOAuthClient
+// @Accept  json
+// @Produce  json
+// @Param input body service.RegisterOAuthClientInput true "client details"
+// @Success 201 {object} service.RegisterOAuthClientOutput
+// @Failure 400 {object} utils.ValidationError
+// @Failure 401,403,500 {object} utils.Response
+// @Router /admin/oauth-clients [post]
+func (h *Handler) registerOAuthClient(ctx *gin.Context) {
+	var input service.RegisterOAuthClientInput
+	if !h.parseRequestBody(ctx, &input) {
+		return
+	}
+
+	result, err := h.services.OAuth.RegisterClient(ctx.Request.Context(), &input)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// @Summary List OAuth clients
+// @Tags Admin
+// @Description returns all registered OAuth clients
+// @ModuleID listOAuthClients
+// @Produce  json
+// @Success 200 {array} service.OAuthClientOutput
+// @Failure 401,403,500 {object} utils.Response
+// @Router /admin/oauth-clients [get]
+func (h *Handler) listOAuthClients(ctx *gin.Context) {
+	result, err := h.services.OAuth.ListClients(ctx.Request.Context())
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary Delete an OAuth client
+// @Tags Admin
+// @Description deletes the client. Outstanding authorization codes of the client become invalid
+// @ModuleID deleteOAuthClient
+// @Param id path string true "client id"
+// @Success 204
+// @Failure 401,403,404,500 {object} utils.Response
+// @Router /admin/oauth-clients/{id} [delete]
+func (h *Handler) deleteOAuthClient(ctx *gin.Context) {
+	err := h.services.OAuth.DeleteClient(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,256 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	serviceMocks "github.com/zhuravlev-pe/course-watch/internal/service/mocks"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+const authorizeQuery = "response_type=code&client_id=client-1&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcb" +
+	"&scope=openid&state=xyz&code_challenge=challenge&code_challenge_method=S256"
+
+var sampleAuthorizeInput = &service.OAuthAuthorizeInput{
+	ResponseType:        "code",
+	ClientId:            "client-1",
+	RedirectURI:         "https://app.example.com/cb",
+	Scope:               "openid",
+	State:               "xyz",
+	CodeChallenge:       "challenge",
+	CodeChallengeMethod: "S256",
+}
+
+func TestOAuthAuthorize(t *testing.T) {
+	redirect := &service.OAuthAuthorizeOutput{RedirectURI: "https://app.example.com/cb?code=abc&state=xyz"}
+	cases := map[string]struct {
+		method        string
+		authenticated bool
+		setupMocks    func(ctx context.Context, mockOAuth *serviceMocks.MockOAuth)
+		responseCode  int
+		location      string
+		responseBody  string
+	}{
+		"browser redirect": {
+			method:        http.MethodGet,
+			authenticated: true,
+			setupMocks: func(ctx context.Context, mockOAuth *serviceMocks.MockOAuth) {
+				mockOAuth.EXPECT().Authorize(ctx, sampleAuthorizeInput, sampleUserPrincipal.UserId).Return(redirect, nil)
+			},
+			responseCode: http.StatusFound,
+			location:     redirect.RedirectURI,
+		},
+		"frontend post": {
+			method:        http.MethodPost,
+			authenticated: true,
+			setupMocks: func(ctx context.Context, mockOAuth *serviceMocks.MockOAuth) {
+				mockOAuth.EXPECT().Authorize(ctx, sampleAuthorizeInput, sampleUserPrincipal.UserId).Return(redirect, nil)
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"redirect_uri":"https://app.example.com/cb?code=abc\u0026state=xyz"}`,
+		},
+		"anonymous": {
+			method: http.MethodGet,
+			setupMocks: func(ctx context.Context, mockOAuth *serviceMocks.MockOAuth) {
+				mockOAuth.EXPECT().Authorize(ctx, sampleAuthorizeInput, "").Return(nil, service.ErrLoginRequired)
+			},
+			responseCode: http.StatusUnauthorized,
+			responseBody: `{"type":"https://course-watch.com/problems/login_required","title":"login required","status":401,"code":"login_required"}`,
+		},
+		"invalid client": {
+			method:        http.MethodGet,
+			authenticated: true,
+			setupMocks: func(ctx context.Context, mockOAuth *serviceMocks.MockOAuth) {
+				mockOAuth.EXPECT().Authorize(ctx, sampleAuthorizeInput, sampleUserPrincipal.UserId).
+					Return(nil, &service.OAuthError{Code: "invalid_request", Description: "unknown client_id"})
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"error":"invalid_request","error_description":"unknown client_id"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			ctx := context.Background()
+			if tc.authenticated {
+				ctx = authenticatedContext()
+			}
+			tc.setupMocks(ctx, setup.oauth)
+
+			var request *http.Request
+			if tc.method == http.MethodGet {
+				request = httptest.NewRequest(http.MethodGet, "/auth/authorize?"+authorizeQuery, nil)
+			} else {
+				request = httptest.NewRequest(http.MethodPost, "/auth/authorize", strings.NewReader(authorizeQuery))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tc.authenticated {
+				addAuthorizationHeader(request, setup)
+			}
+			recorder := httptest.NewRecorder()
+			setup.router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.responseCode, recorder.Code)
+			if tc.location != "" {
+				assert.Equal(t, tc.location, recorder.Header().Get("Location"))
+			}
+			if tc.responseBody != "" {
+				assert.Equal(t, tc.responseBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestOAuthToken(t *testing.T) {
+	setup := getTestSetup(t)
+	ctx := context.Background()
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"abc"},
+		"redirect_uri":  {"https://app.example.com/cb"},
+		"code_verifier": {"verifier"},
+	}
+	expectedInput := &service.OAuthTokenInput{
+		GrantType:    "authorization_code",
+		Code:         "abc",
+		RedirectURI:  "https://app.example.com/cb",
+		ClientId:     "client-1",
+		ClientSecret: "s3cret/+",
+		CodeVerifier: "verifier",
+	}
+	grant := &service.OAuthGrant{
+		User:    &core.User{Id: sampleUserPrincipal.UserId, Roles: sampleUserPrincipal.Roles},
+		Client:  &core.OAuthClient{Id: "client-1", Name: "Reports"},
+		Scope:   "openid",
+		IdToken: "id-token",
+	}
+	setup.oauth.EXPECT().ExchangeCode(ctx, expectedInput).Return(grant, nil).Times(1)
+	setup.sessions.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *service.CreateSessionInput) (*core.Session, error) {
+			assert.Equal(t, "Reports", input.DeviceLabel)
+			return &core.Session{Id: "1590000000000000001", UserId: input.UserId}, nil
+		}).Times(1)
+
+	request := httptest.NewRequest(http.MethodPost, "/auth/token", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth("client-1", url.QueryEscape("s3cret/+"))
+	recorder := httptest.NewRecorder()
+	setup.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	var output service.OAuthTokenOutput
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &output))
+	assert.NotEmpty(t, output.AccessToken)
+	assert.Equal(t, "Bearer", output.TokenType)
+	assert.Equal(t, int(tokenTtl.Seconds()), output.ExpiresIn)
+	assert.Equal(t, "id-token", output.IdToken)
+
+	payload, err := setup.clients.Inspect(ctx, output.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, sampleUserPrincipal.UserId, payload.UserId)
+	assert.Equal(t, "1590000000000000001", payload.SessionId)
+	assert.Equal(t, "client-1", payload.ClientId)
+	assert.Equal(t, "openid", payload.Scope)
+	assert.Empty(t, payload.Roles)
+	assert.Equal(t, []string{clientAud}, payload.Audience)
+}
+
+func TestOAuthToken_RejectedByFirstPartyEndpoints(t *testing.T) {
+	setup := getTestSetup(t)
+	token, err := setup.clients.GenerateToken(&security.UserPrincipal{
+		UserId:    sampleUserPrincipal.UserId,
+		SessionId: sampleUserPrincipal.SessionId,
+		ClientId:  "client-1",
+		Scope:     "openid profile email",
+	})
+	require.NoError(t, err)
+
+	_, err = setup.bearer.Inspect(context.Background(), token)
+	assert.Error(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	setup.router.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestOAuthToken_InvalidClient(t *testing.T) {
+	setup := getTestSetup(t)
+	ctx := context.Background()
+	setup.oauth.EXPECT().ExchangeCode(ctx, gomock.Any()).
+		Return(nil, &service.OAuthError{Code: "invalid_client", Description: "client authentication failed"}).Times(1)
+
+	request := httptest.NewRequest(http.MethodPost, "/auth/token", strings.NewReader("grant_type=authorization_code"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	setup.router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, `{"error":"invalid_client","error_description":"client authentication failed"}`, recorder.Body.String())
+}
+
+func TestOAuthUserInfo(t *testing.T) {
+	setup := getTestSetup(t)
+	up := &security.UserPrincipal{
+		UserId:    sampleUserPrincipal.UserId,
+		SessionId: sampleUserPrincipal.SessionId,
+		ClientId:  "client-1",
+		Scope:     "openid email",
+	}
+	clientToken, err := setup.clients.GenerateToken(up)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		token        string
+		setupMocks   func(mockOAuth *serviceMocks.MockOAuth)
+		responseCode int
+		responseBody string
+	}{
+		"client_token": {
+			token: clientToken,
+			setupMocks: func(mockOAuth *serviceMocks.MockOAuth) {
+				ctx := security.ContextWithPrincipal(context.Background(), up)
+				mockOAuth.EXPECT().UserInfo(ctx, up.UserId, "openid email").
+					Return(&service.UserInfoClaims{Subject: up.UserId, Email: "doe.j@example.com"}, nil).Times(1)
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"sub":"1582550893222432768","email":"doe.j@example.com"}`,
+		},
+		"first_party_token": {
+			token:        setup.sampleUserToken,
+			setupMocks:   func(mockOAuth *serviceMocks.MockOAuth) {},
+			responseCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.setupMocks(setup.oauth)
+
+			request := httptest.NewRequest(http.MethodGet, "/auth/userinfo", nil)
+			request.Header.Set("Authorization", "Bearer "+tc.token)
+			recorder := httptest.NewRecorder()
+			setup.router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.responseCode, recorder.Code)
+			if tc.responseBody != "" {
+				assert.Equal(t, tc.responseBody, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/api/v1/auth/oidc"
+	// oidcStateCookieMaxAge only limits the lifetime of the cookie in the browser, the state itself expires on the server
+	oidcStateCookieMaxAge = 3600
+)
+
+func (h *Handler) initOIDCRoutes(api *gin.RouterGroup) {
+	oidc := api.Group("/oidc/:provider")
+	{
+		oidc.GET("/authorize", h.authorizeOIDC)
+		oidc.GET("/callback", h.oidcCallback)
+	}
+}
+
+// @Summary Log in with an external identity provider
+// @Tags Authentication
+// @Description redirects the browser to the authorization endpoint of the given OpenID Connect provider
+// @ModuleID authorizeOIDC
+// @Param provider path string true "provider name"
+// @Success 302
+// @Failure 404,500 {object} utils.Response
+// @Router /auth/oidc/{provider}/authorize [get]
+func (h *Handler) authorizeOIDC(ctx *gin.Context) {
+	output, err := h.services.OIDC.Authorize(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+
+	// Lax is required: the callback is a top-level cross-site navigation from the provider
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcStateCookie, output.State, oidcStateCookieMaxAge, oidcCookiePath, "", true, true)
+	ctx.Redirect(http.StatusFound, output.URL)
+}
+
+// @Summary External identity provider callback
+// @Tags Authentication
+// @Description completes the login with an OpenID Connect provider and redirects the browser to the configured post-login page. On the first login, the external account is linked to the user with the same verified email, or a new user is created. In cookie mode, the session is set in the auth cookies. Otherwise, the access token is passed in the URL fragment as access_token, token_type, expires_in and user_id, so that it is not sent to servers or logged
+// @ModuleID oidcCallback
+// @Produce  json
+// @Param provider path string true "provider name"
+// @Param state query string true "state returned by the provider"
+// @Param code query string true "authorization code"
+// @Success 302
+// @Failure 401,403,404,500 {object} utils.Response
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *Handler) oidcCallback(ctx *gin.Context) {
+	if providerErr := ctx.Query("error"); providerErr != "" {
+		err := fmt.Errorf("%w: provider responded with %s", service.ErrExternalLogin, providerErr)
+		utils.Abort(ctx, err)
+		return
+	}
+
+	state := ctx.Query("state")
+	expectedState, err := ctx.Cookie(oidcStateCookie)
+	if err != nil || state == "" || state != expectedState {
+		utils.Abort(ctx, fmt.Errorf("%w: state mismatch", service.ErrExternalLogin))
+		return
+	}
+
+	callback, err := h.services.OIDC.Callback(ctx.Request.Context(), ctx.Param("provider"), state, ctx.Query("code"))
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+
+	output, err := h.startSession(ctx, callback.User, "")
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcStateCookie, "", -1, oidcCookiePath, "", true, true)
+
+	target := callback.PostLoginURL
+	if h.bearer.CookieMode() {
+		// the page reads the CSRF token from its cookie
+		if _, err = h.bearer.SetTokenCookies(ctx, output.AccessToken); err != nil {
+			utils.Abort(ctx, err)
+			return
+		}
+	} else {
+		target += "#" + url.Values{
+			"access_token": {output.AccessToken},
+			"token_type":   {"Bearer"},
+			"expires_in":   {strconv.Itoa(output.ExpiresIn)},
+			"user_id":      {output.UserId},
+		}.Encode()
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Redirect(http.StatusFound, target)
+}
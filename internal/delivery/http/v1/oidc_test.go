@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+const testPostLoginURL = "https://example.com/login/oidc"
+
+// oidcCallback completes a login with the test provider and returns the response
+func oidcCallback(t *testing.T, setup *testSetup) *httptest.ResponseRecorder {
+	t.Helper()
+	ctx := context.Background()
+	user := &core.User{Id: sampleUserPrincipal.UserId, Roles: sampleUserPrincipal.Roles}
+	setup.oidc.EXPECT().Callback(ctx, "corp", "state", "code").Return(&service.OIDCCallbackOutput{User: user, PostLoginURL: testPostLoginURL}, nil).Times(1)
+	setup.sessions.EXPECT().Create(ctx, gomock.Any()).Return(&core.Session{Id: sampleUserPrincipal.SessionId}, nil).Times(1)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/corp/callback?state=state&code=code", nil)
+	request.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: "state"})
+	rec := httptest.NewRecorder()
+	setup.router.ServeHTTP(rec, request)
+	require.Equal(t, http.StatusFound, rec.Code)
+	return rec
+}
+
+func TestOIDCCallback_BearerMode(t *testing.T) {
+	setup := getTestSetup(t)
+
+	rec := oidcCallback(t, setup)
+
+	location := rec.Header().Get("Location")
+	require.True(t, strings.HasPrefix(location, testPostLoginURL+"#"), location)
+	fragment, err := url.ParseQuery(strings.TrimPrefix(location, testPostLoginURL+"#"))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", fragment.Get("token_type"))
+	assert.Equal(t, sampleUserPrincipal.UserId, fragment.Get("user_id"))
+	principal, err := setup.bearer.Inspect(context.Background(), fragment.Get("access_token"))
+	require.NoError(t, err)
+	assert.Equal(t, sampleUserPrincipal.SessionId, principal.SessionId)
+	assert.Nil(t, findCookie(rec.Result().Cookies(), auth.TokenCookie))
+}
+
+func TestOIDCCallback_CookieMode(t *testing.T) {
+	setup := getTestSetup(t)
+	setup.bearer.EnableCookies(auth.CookieSettings{SameSite: http.SameSiteStrictMode})
+
+	rec := oidcCallback(t, setup)
+
+	assert.Equal(t, testPostLoginURL, rec.Header().Get("Location"))
+	cookies := rec.Result().Cookies()
+	assert.NotNil(t, findCookie(cookies, auth.TokenCookie))
+	assert.NotNil(t, findCookie(cookies, auth.CSRFCookie))
+}
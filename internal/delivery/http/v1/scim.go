@@ -0,0 +1,158 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+const scimContentType = "application/scim+json"
+
+// scimErrorResponse is the SCIM error format, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.12
+type scimErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// InitSCIM registers the SCIM 2.0 provisioning endpoints. Like the authorization server endpoints, they follow the
+// protocol rather than the API conventions: fixed paths, SCIM error format and a dedicated bearer token
+func (h *Handler) InitSCIM(router gin.IRouter) {
+	scim := router.Group("/scim/v2", h.scimAuthenticate)
+	{
+		scim.GET("/ServiceProviderConfig", h.scimServiceProviderConfig)
+		scim.GET("/Users", h.scimListUsers)
+		scim.POST("/Users", h.scimCreateUser)
+		scim.GET("/Users/:id", h.scimGetUser)
+		scim.PATCH("/Users/:id", h.scimPatchUser)
+		scim.DELETE("/Users/:id", h.scimDeactivateUser)
+	}
+}
+
+func (h *Handler) scimAuthenticate(ctx *gin.Context) {
+	header := ctx.GetHeader("Authorization")
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header || !h.services.SCIM.Authenticate(token) {
+		ctx.Header("WWW-Authenticate", `Bearer realm="scim"`)
+		scimError(ctx, http.StatusUnauthorized, "", "Unauthorized")
+	}
+}
+
+func (h *Handler) scimServiceProviderConfig(ctx *gin.Context) {
+	supported := func(value bool) gin.H { return gin.H{"supported": value} }
+	scimJSON(ctx, http.StatusOK, gin.H{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          supported(true),
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": 0},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Dedicated provisioning token",
+		}},
+	})
+}
+
+func (h *Handler) scimCreateUser(ctx *gin.Context) {
+	var input service.SCIMUser
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		scimError(ctx, http.StatusBadRequest, "invalidSyntax", "body is missing or invalid")
+		return
+	}
+
+	result, err := h.services.SCIM.CreateUser(ctx.Request.Context(), &input)
+	if err != nil {
+		h.handleSCIMError(ctx, err)
+		return
+	}
+	scimJSON(ctx, http.StatusCreated, result)
+}
+
+func (h *Handler) scimGetUser(ctx *gin.Context) {
+	result, err := h.services.SCIM.GetUser(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.handleSCIMError(ctx, err)
+		return
+	}
+	scimJSON(ctx, http.StatusOK, result)
+}
+
+func (h *Handler) scimListUsers(ctx *gin.Context) {
+	var input service.SCIMListInput
+	if err := ctx.ShouldBindWith(&input, binding.Query); err != nil {
+		scimError(ctx, http.StatusBadRequest, "invalidValue", "startIndex and count must be integers")
+		return
+	}
+
+	result, err := h.services.SCIM.ListUsers(ctx.Request.Context(), &input)
+	if err != nil {
+		h.handleSCIMError(ctx, err)
+		return
+	}
+	scimJSON(ctx, http.StatusOK, result)
+}
+
+func (h *Handler) scimPatchUser(ctx *gin.Context) {
+	var input service.SCIMPatchInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		scimError(ctx, http.StatusBadRequest, "invalidSyntax", "body is missing or invalid")
+		return
+	}
+
+	result, err := h.services.SCIM.PatchUser(ctx.Request.Context(), ctx.Param("id"), &input)
+	if err != nil {
+		h.handleSCIMError(ctx, err)
+		return
+	}
+	scimJSON(ctx, http.StatusOK, result)
+}
+
+func (h *Handler) scimDeactivateUser(ctx *gin.Context) {
+	err := h.services.SCIM.DeactivateUser(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.handleSCIMError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *Handler) handleSCIMError(ctx *gin.Context, err error) {
+	var scimErr *service.SCIMError
+	switch {
+	case err == repository.ErrNotFound:
+		scimError(ctx, http.StatusNotFound, "", "user not found")
+	case errors.As(err, &scimErr) && scimErr.ScimType == "uniqueness":
+		scimError(ctx, http.StatusConflict, scimErr.ScimType, scimErr.Detail)
+	case errors.As(err, &scimErr):
+		scimError(ctx, http.StatusBadRequest, scimErr.ScimType, scimErr.Detail)
+	default:
+		utils.Abort(ctx, err)
+	}
+}
+
+func scimJSON(ctx *gin.Context, status int, body interface{}) {
+	// gin keeps an already set content type
+	ctx.Header("Content-Type", scimContentType)
+	ctx.JSON(status, body)
+}
+
+func scimError(ctx *gin.Context, status int, scimType string, detail string) {
+	ctx.Header("Content-Type", scimContentType)
+	ctx.AbortWithStatusJSON(status, &scimErrorResponse{
+		Schemas:  []string{service.SCIMErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	serviceMocks "github.com/zhuravlev-pe/course-watch/internal/service/mocks"
+)
+
+const scimToken = "scim-token"
+
+func TestSCIMEndpoints(t *testing.T) {
+	active := true
+	sampleSCIMUser := &service.SCIMUser{
+		Schemas:  []string{service.SCIMUserSchema},
+		Id:       "1582550893222432768",
+		UserName: "doe.j@example.com",
+		Active:   &active,
+		Meta:     &service.SCIMMeta{ResourceType: "User", Created: time.Date(2017, time.July, 21, 17, 32, 28, 0, time.UTC)},
+	}
+
+	cases := map[string]struct {
+		method       string
+		path         string
+		body         string
+		token        string
+		setupMocks   func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM)
+		responseCode int
+		responseBody string
+	}{
+		"no token": {
+			method: http.MethodGet,
+			path:   "/scim/v2/Users/1582550893222432768",
+			setupMocks: func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM) {
+				mockSCIM.EXPECT().Authenticate("").Return(false).Times(0)
+			},
+			responseCode: http.StatusUnauthorized,
+			responseBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"401","detail":"Unauthorized"}`,
+		},
+		"wrong token": {
+			method: http.MethodGet,
+			path:   "/scim/v2/Users/1582550893222432768",
+			token:  "wrong",
+			setupMocks: func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM) {
+				mockSCIM.EXPECT().Authenticate("wrong").Return(false).Times(1)
+			},
+			responseCode: http.StatusUnauthorized,
+			responseBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"401","detail":"Unauthorized"}`,
+		},
+		"get": {
+			method: http.MethodGet,
+			path:   "/scim/v2/Users/1582550893222432768",
+			token:  scimToken,
+			setupMocks: func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM) {
+				mockSCIM.EXPECT().GetUser(ctx, "1582550893222432768").Return(sampleSCIMUser, nil).Times(1)
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"id":"1582550893222432768","userName":"doe.j@example.com","active":true,"meta":{"resourceType":"User","created":"2017-07-21T17:32:28Z"}}`,
+		},
+		"get not found": {
+			method: http.MethodGet,
+			path:   "/scim/v2/Users/1",
+			token:  scimToken,
+			setupMocks: func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM) {
+				mockSCIM.EXPECT().GetUser(ctx, "1").Return(nil, repository.ErrNotFound).Times(1)
+			},
+			responseCode: http.StatusNotFound,
+			responseBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"404","detail":"user not found"}`,
+		},
+		"create conflict": {
+			method: http.MethodPost,
+			path:   "/scim/v2/Users",
+			body:   `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"doe.j@example.com"}`,
+			token:  scimToken,
+			setupMocks: func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM) {
+				input := &service.SCIMUser{Schemas: []string{service.SCIMUserSchema}, UserName: "doe.j@example.com"}
+				mockSCIM.EXPECT().CreateUser(ctx, input).
+					Return(nil, &service.SCIMError{ScimType: "uniqueness", Detail: "taken"}).Times(1)
+			},
+			responseCode: http.StatusConflict,
+			responseBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"409","scimType":"uniqueness","detail":"taken"}`,
+		},
+		"list with invalid filter": {
+			method: http.MethodGet,
+			path:   "/scim/v2/Users?filter=userName+co+%22doe%22&startIndex=2&count=5",
+			token:  scimToken,
+			setupMocks: func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM) {
+				count := 5
+				input := &service.SCIMListInput{Filter: `userName co "doe"`, StartIndex: 2, Count: &count}
+				mockSCIM.EXPECT().ListUsers(ctx, input).
+					Return(nil, &service.SCIMError{ScimType: "invalidFilter", Detail: "unsupported"}).Times(1)
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"400","scimType":"invalidFilter","detail":"unsupported"}`,
+		},
+		"deactivate": {
+			method: http.MethodDelete,
+			path:   "/scim/v2/Users/1582550893222432768",
+			token:  scimToken,
+			setupMocks: func(ctx context.Context, mockSCIM *serviceMocks.MockSCIM) {
+				mockSCIM.EXPECT().DeactivateUser(ctx, "1582550893222432768").Return(nil).Times(1)
+			},
+			responseCode: http.StatusNoContent,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			ctx := context.Background()
+			if tc.token == scimToken {
+				setup.scim.EXPECT().Authenticate(scimToken).Return(true).Times(1)
+			}
+			tc.setupMocks(ctx, setup.scim)
+
+			request := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+			setup.router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.responseCode, recorder.Code)
+			assert.Equal(t, tc.responseBody, recorder.Body.String())
+			if tc.responseBody != "" {
+				assert.Equal(t, "application/scim+json", recorder.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+)
+
+// @Summary List active sessions of the current user
+// @Tags User
+// @Description returns the devices the current user is logged in on. The session of the calling token is marked as current
+// @ModuleID getUserSessions
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} service.SessionOutput
+// @Failure 401,500 {object} utils.Response
+// @Router /user/sessions [get]
+func (h *Handler) getUserSessions(ctx *gin.Context) {
+	up, err := auth.GetAuthenticatedUser(ctx)
+	if err != nil {
+		err = fmt.Errorf("authentication middleware failure: %w", err)
+		utils.Abort(ctx, err)
+		return
+	}
+
+	result, err := h.services.Sessions.List(ctx.Request.Context(), up.UserId, up.SessionId)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary Revoke a session of the current user
+// @Tags User
+// @Description logs the current user out on the device identified by the session id. Tokens issued for the session stop working immediately
+// @ModuleID revokeSession
+// @Accept  json
+// @Produce  json
+// @Param id path string true "session id"
+// @Success 204
+// @Failure 401,404,500 {object} utils.Response
+// @Router /user/sessions/{id} [delete]
+func (h *Handler) revokeSession(ctx *gin.Context) {
+	up, err := auth.GetAuthenticatedUser(ctx)
+	if err != nil {
+		err = fmt.Errorf("authentication middleware failure: %w", err)
+		utils.Abort(ctx, err)
+		return
+	}
+
+	err = h.services.Sessions.Revoke(ctx.Request.Context(), up.UserId, ctx.Param("id"))
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Log out everywhere else
+// @Tags User
+// @Description revokes all sessions of the current user except the one the calling token belongs to
+// @ModuleID revokeOtherSessions
+// @Accept  json
+// @Produce  json
+// @Success 204
+// @Failure 401,500 {object} utils.Response
+// @Router /user/sessions [delete]
+func (h *Handler) revokeOtherSessions(ctx *gin.Context) {
+	up, err := auth.GetAuthenticatedUser(ctx)
+	if err != nil {
+		err = fmt.Errorf("authentication middleware failure: %w", err)
+		utils.Abort(ctx, err)
+		return
+	}
+
+	err = h.services.Sessions.RevokeOthers(ctx.Request.Context(), up.UserId, up.SessionId)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
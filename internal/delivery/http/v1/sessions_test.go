@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	serviceMocks "github.com/zhuravlev-pe/course-watch/internal/service/mocks"
+)
+
+var sampleSessions = []*service.SessionOutput{
+	{
+		Id:          "1590000000000000001",
+		DeviceLabel: "laptop",
+		IP:          "192.0.2.1",
+		UserAgent:   "Mozilla/5.0",
+		CreatedAt:   time.Date(2022, time.December, 1, 10, 0, 0, 0, time.UTC),
+		LastSeenAt:  time.Date(2022, time.December, 1, 11, 0, 0, 0, time.UTC),
+		Current:     false,
+	},
+}
+
+func TestGetUserSessions(t *testing.T) {
+	cases := map[string]struct {
+		setupMocks     func(ctx context.Context, mockSessions *serviceMocks.MockSessions)
+		prepareRequest func(request *http.Request, setup *testSetup)
+		responseCode   int
+		responseBody   string
+	}{
+		"success": {
+			setupMocks: func(ctx context.Context, mockSessions *serviceMocks.MockSessions) {
+				mockSessions.EXPECT().List(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(sampleSessions, nil).Times(1)
+			},
+			prepareRequest: addAuthorizationHeader,
+			responseCode:   http.StatusOK,
+			responseBody:   `[{"id":"1590000000000000001","device_label":"laptop","ip":"192.0.2.1","user_agent":"Mozilla/5.0","created_at":"2022-12-01T10:00:00Z","last_seen_at":"2022-12-01T11:00:00Z","current":false}]`,
+		},
+		"internal_server_err": {
+			setupMocks: func(ctx context.Context, mockSessions *serviceMocks.MockSessions) {
+				mockSessions.EXPECT().List(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(nil, someDatabaseError).Times(1)
+			},
+			prepareRequest: addAuthorizationHeader,
+			responseCode:   http.StatusInternalServerError,
+			responseBody:   `{"type":"https://course-watch.com/problems/internal_error","title":"internal server error","status":500,"code":"internal_error"}`,
+		},
+		"unauthorized": {
+			setupMocks:     func(ctx context.Context, mockSessions *serviceMocks.MockSessions) {},
+			prepareRequest: func(request *http.Request, setup *testSetup) {},
+			responseCode:   http.StatusUnauthorized,
+			responseBody:   `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			ctx := authenticatedContext()
+			tc.setupMocks(ctx, setup.sessions)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/user/sessions", nil)
+			tc.prepareRequest(request, setup)
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+			assert.Equal(t, tc.responseBody, rec.Body.String())
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	cases := map[string]struct {
+		setupMocks     func(ctx context.Context, mockSessions *serviceMocks.MockSessions)
+		prepareRequest func(request *http.Request, setup *testSetup)
+		responseCode   int
+		responseBody   string
+	}{
+		"success": {
+			setupMocks: func(ctx context.Context, mockSessions *serviceMocks.MockSessions) {
+				mockSessions.EXPECT().Revoke(ctx, sampleUserPrincipal.UserId, "1590000000000000001").Return(nil).Times(1)
+			},
+			prepareRequest: addAuthorizationHeader,
+			responseCode:   http.StatusNoContent,
+			responseBody:   "",
+		},
+		"not_found": {
+			setupMocks: func(ctx context.Context, mockSessions *serviceMocks.MockSessions) {
+				mockSessions.EXPECT().Revoke(ctx, sampleUserPrincipal.UserId, "1590000000000000001").Return(repository.ErrNotFound).Times(1)
+			},
+			prepareRequest: addAuthorizationHeader,
+			responseCode:   http.StatusNotFound,
+			responseBody:   `{"type":"https://course-watch.com/problems/not_found","title":"not found","status":404,"code":"not_found"}`,
+		},
+		"unauthorized": {
+			setupMocks:     func(ctx context.Context, mockSessions *serviceMocks.MockSessions) {},
+			prepareRequest: func(request *http.Request, setup *testSetup) {},
+			responseCode:   http.StatusUnauthorized,
+			responseBody:   `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			ctx := authenticatedContext()
+			tc.setupMocks(ctx, setup.sessions)
+
+			request := httptest.NewRequest(http.MethodDelete, "/api/v1/user/sessions/1590000000000000001", nil)
+			tc.prepareRequest(request, setup)
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+			assert.Equal(t, tc.responseBody, rec.Body.String())
+		})
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	setup := getTestSetup(t)
+	ctx := authenticatedContext()
+	setup.sessions.EXPECT().RevokeOthers(ctx, sampleUserPrincipal.UserId, sampleUserPrincipal.SessionId).Return(nil).Times(1)
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/v1/user/sessions", nil)
+	addAuthorizationHeader(request, setup)
+	rec := httptest.NewRecorder()
+
+	setup.router.ServeHTTP(rec, request)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -15,6 +15,10 @@ func (h *Handler) initUserRoutes(api *gin.RouterGroup) {
 	{
 		courses.GET("", h.getUserInfo)
 		courses.PUT("", h.updateUserInfo)
+		courses.PATCH("", h.patchUserInfo)
+		courses.GET("/sessions", h.getUserSessions)
+		courses.DELETE("/sessions", h.revokeOtherSessions)
+		courses.DELETE("/sessions/:id", h.revokeSession)
 	}
 }
 
@@ -24,21 +28,27 @@ func (h *Handler) initUserRoutes(api *gin.RouterGroup) {
 // @ModuleID getUserInfo
 // @Accept  json
 // @Produce  json
+// @Param If-None-Match header string false "ETag of the cached version"
 // @Success 200 {object} service.GetUserInfoOutput
+// @Header 200 {string} ETag "version of the user data"
+// @Success 304 "the cached version is current"
 // @Failure 401,404,500 {object} utils.Response
 // @Router /user [get]
 func (h *Handler) getUserInfo(ctx *gin.Context) {
 	up, err := auth.GetAuthenticatedUser(ctx)
 	if err != nil {
 		err = fmt.Errorf("authentication middleware failure: %w", err)
-		utils.ErrorResponseMessageOverride(ctx, http.StatusInternalServerError, err, "user data processing failure")
+		utils.Abort(ctx, err)
 		return
 	}
 
 	result, err := h.services.Users.GetUserInfo(ctx.Request.Context(), up.UserId)
 
 	if err != nil {
-		h.handleServiceError(ctx, err)
+		utils.Abort(ctx, err)
+		return
+	}
+	if notModified(ctx, result.Version) {
 		return
 	}
 	ctx.JSON(http.StatusOK, result)
@@ -51,26 +61,70 @@ func (h *Handler) getUserInfo(ctx *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param input body service.UpdateUserInfoInput true "user info"
+// @Param If-Match header string false "ETag of the version being modified"
 // @Success 204
 // @Failure 400             {object} utils.ValidationError
-// @Failure 401,404,500     {object} utils.Response
+// @Failure 401,404,412,500 {object} utils.Response
 // @Router /user [put]
 func (h *Handler) updateUserInfo(ctx *gin.Context) {
 	up, err := auth.GetAuthenticatedUser(ctx)
 	if err != nil {
 		err = fmt.Errorf("authentication middleware failure: %w", err)
-		utils.ErrorResponseMessageOverride(ctx, http.StatusInternalServerError, err, "user data processing failure")
+		utils.Abort(ctx, err)
 		return
 	}
 	var input service.UpdateUserInfoInput
 	if !h.parseRequestBody(ctx, &input) {
 		return
 	}
+	var ok bool
+	if input.Version, ok = ifMatchVersion(ctx); !ok {
+		return
+	}
 
 	err = h.services.Users.UpdateUserInfo(ctx.Request.Context(), up.UserId, &input)
 
 	if err != nil {
-		h.handleServiceError(ctx, err)
+		utils.Abort(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Partially modify current user data
+// @Tags User
+// @Description changes only the fields present in the JSON merge patch (RFC 7386). A null display_name removes it,
+// @Description first_name and last_name cannot be removed. User_id is extracted from the bearer token
+// @ModuleID patchUserInfo
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param input body service.PatchUserInfoInput true "user info fields to change"
+// @Param If-Match header string false "ETag of the version being modified"
+// @Success 204
+// @Failure 400                 {object} utils.ValidationError
+// @Failure 401,404,412,415,500 {object} utils.Response
+// @Router /user [patch]
+func (h *Handler) patchUserInfo(ctx *gin.Context) {
+	up, err := auth.GetAuthenticatedUser(ctx)
+	if err != nil {
+		err = fmt.Errorf("authentication middleware failure: %w", err)
+		utils.Abort(ctx, err)
+		return
+	}
+	var input service.PatchUserInfoInput
+	if !h.parseMergePatch(ctx, &input) {
+		return
+	}
+	var ok bool
+	if input.Version, ok = ifMatchVersion(ctx); !ok {
+		return
+	}
+
+	err = h.services.Users.PatchUserInfo(ctx.Request.Context(), up.UserId, &input)
+
+	if err != nil {
+		utils.Abort(ctx, err)
 		return
 	}
 
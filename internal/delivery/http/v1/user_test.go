@@ -8,9 +8,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
 	"github.com/zhuravlev-pe/course-watch/internal/repository"
 	"github.com/zhuravlev-pe/course-watch/internal/service"
 	serviceMocks "github.com/zhuravlev-pe/course-watch/internal/service/mocks"
+	"github.com/zhuravlev-pe/course-watch/pkg/patch"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
 	"io"
 	"net/http"
@@ -21,12 +23,14 @@ import (
 )
 
 const (
-	iss      = "course-watch"
-	aud      = "course-watch-api"
-	tokenTtl = time.Hour * 1
+	iss       = "course-watch"
+	aud       = "course-watch-api"
+	clientAud = "course-watch/userinfo"
+	tokenTtl  = time.Hour * 1
 )
 
 var validKey = []byte("1234")
+var clientKey = []byte("5678")
 
 var sampleUserInfo = &service.GetUserInfoOutput{
 	Id:               "1582550893222432768",
@@ -36,17 +40,30 @@ var sampleUserInfo = &service.GetUserInfoOutput{
 	DisplayName:      "JonnyD",
 	RegistrationDate: time.Date(2017, time.July, 21, 17, 32, 28, 0, time.UTC),
 	Roles:            []security.Role{security.Student},
+	Version:          3,
 }
 
 var sampleUserPrincipal = &security.UserPrincipal{
-	UserId: "1582550893222432768",
-	Roles:  []security.Role{security.Student},
+	UserId:    "1582550893222432768",
+	Roles:     []security.Role{security.Student},
+	SessionId: "1590000000000000000",
+}
+
+// authenticatedContext is the context the services get in requests authenticated as sampleUserPrincipal
+func authenticatedContext() context.Context {
+	return security.ContextWithPrincipal(context.Background(), sampleUserPrincipal)
 }
 
 type testSetup struct {
 	router          *gin.Engine
 	users           *serviceMocks.MockUsers
+	sessions        *serviceMocks.MockSessions
+	oauth           *serviceMocks.MockOAuth
+	scim            *serviceMocks.MockSCIM
+	oidc            *serviceMocks.MockOIDC
 	handler         *Handler
+	bearer          *auth.BearerAuthenticator
+	clients         *auth.BearerAuthenticator
 	sampleUserToken string
 }
 
@@ -54,23 +71,43 @@ func getTestSetup(t *testing.T) *testSetup {
 	t.Helper()
 	mockCtrl := gomock.NewController(t)
 	mockUsers := serviceMocks.NewMockUsers(mockCtrl)
+	mockSessions := serviceMocks.NewMockSessions(mockCtrl)
+	mockOAuth := serviceMocks.NewMockOAuth(mockCtrl)
+	mockSCIM := serviceMocks.NewMockSCIM(mockCtrl)
+	mockOIDC := serviceMocks.NewMockOIDC(mockCtrl)
 	var s service.Services
 	s.Users = mockUsers
+	s.Sessions = mockSessions
+	s.OAuth = mockOAuth
+	s.SCIM = mockSCIM
+	s.OIDC = mockOIDC
 
 	jwt := security.NewJwtHandler(iss, aud, []string{aud}, tokenTtl, validKey)
-	bearer := auth.NewBearerAuthenticator(jwt)
+	bearer := auth.NewBearerAuthenticator(jwt, nil)
 	token, err := bearer.GenerateToken(sampleUserPrincipal)
 	require.NoError(t, err)
 
-	handler := NewHandler(&s, bearer)
+	clientJwt := security.NewJwtHandler(iss, clientAud, []string{clientAud}, tokenTtl, clientKey)
+	clients := auth.NewBearerAuthenticator(clientJwt, nil)
+
+	handler := NewHandler(&s, bearer, clients)
 
 	router := gin.New()
+	router.Use(utils.Problems())
 	handler.Init(router.Group("/api"))
+	handler.InitAuthorizationServer(router)
+	handler.InitSCIM(router)
 
 	return &testSetup{
 		router:          router,
 		users:           mockUsers,
+		sessions:        mockSessions,
+		oauth:           mockOAuth,
+		scim:            mockSCIM,
+		oidc:            mockOIDC,
 		handler:         handler,
+		bearer:          bearer,
+		clients:         clients,
 		sampleUserToken: token,
 	}
 }
@@ -102,7 +139,7 @@ func TestGetUserInfo(t *testing.T) {
 			},
 			prepareRequest: addAuthorizationHeader,
 			responseCode:   http.StatusNotFound,
-			responseBody:   `{"title":"not found","status":404}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/not_found","title":"not found","status":404,"code":"not_found"}`,
 		},
 		"internal_server_err": {
 			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
@@ -110,20 +147,20 @@ func TestGetUserInfo(t *testing.T) {
 			},
 			prepareRequest: addAuthorizationHeader,
 			responseCode:   http.StatusInternalServerError,
-			responseBody:   `{"title":"internal server error","status":500}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/internal_error","title":"internal server error","status":500,"code":"internal_error"}`,
 		},
 		"unauthorized": {
 			setupMocks:     func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
 			prepareRequest: func(request *http.Request, setup *testSetup) {},
 			responseCode:   http.StatusUnauthorized,
-			responseBody:   `{"title":"Unauthorized","status":401}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			setup := getTestSetup(t)
-			ctx := context.Background()
+			ctx := authenticatedContext()
 			tc.setupMocks(ctx, setup.users)
 
 			request := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
@@ -165,14 +202,14 @@ func TestUpdateUserInfo(t *testing.T) {
 			setupMocks:     func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
 			prepareRequest: addAuthorizationHeader,
 			responseCode:   http.StatusBadRequest,
-			responseBody:   `{"title":"body is missing or invalid","status":400}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/invalid_body","title":"body is missing or invalid","status":400,"code":"invalid_body"}`,
 		},
 		"invalid_json": {
 			requestBody:    "not a valid json",
 			setupMocks:     func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
 			prepareRequest: addAuthorizationHeader,
 			responseCode:   http.StatusBadRequest,
-			responseBody:   `{"title":"body is missing or invalid","status":400}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/invalid_body","title":"body is missing or invalid","status":400,"code":"invalid_body"}`,
 		},
 		"not_found": {
 			requestBody: `{"first_name":"UpdatedFirstName","last_name":"UpdatedLastName","display_name":"UpdatedDisplayName"}`,
@@ -186,7 +223,7 @@ func TestUpdateUserInfo(t *testing.T) {
 			},
 			prepareRequest: addAuthorizationHeader,
 			responseCode:   http.StatusNotFound,
-			responseBody:   `{"title":"not found","status":404}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/not_found","title":"not found","status":404,"code":"not_found"}`,
 		},
 		"internal_server_err": {
 			requestBody: `{"first_name":"UpdatedFirstName","last_name":"UpdatedLastName","display_name":"UpdatedDisplayName"}`,
@@ -200,14 +237,14 @@ func TestUpdateUserInfo(t *testing.T) {
 			},
 			prepareRequest: addAuthorizationHeader,
 			responseCode:   http.StatusInternalServerError,
-			responseBody:   `{"title":"internal server error","status":500}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/internal_error","title":"internal server error","status":500,"code":"internal_error"}`,
 		},
 		"unauthorized": {
 			requestBody:    `{"first_name":"UpdatedFirstName","last_name":"UpdatedLastName","display_name":"UpdatedDisplayName"}`,
 			setupMocks:     func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
 			prepareRequest: func(request *http.Request, setup *testSetup) {},
 			responseCode:   http.StatusUnauthorized,
-			responseBody:   `{"title":"Unauthorized","status":401}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/unauthorized","title":"Unauthorized","status":401,"code":"unauthorized"}`,
 		},
 		"validation_failure": {
 			requestBody: `{"last_name":"UpdatedLastName","display_name":"UpdatedDisplayName"}`,
@@ -221,14 +258,14 @@ func TestUpdateUserInfo(t *testing.T) {
 			},
 			prepareRequest: addAuthorizationHeader,
 			responseCode:   http.StatusBadRequest,
-			responseBody:   `{"title":"invalid request parameters","status":400,"validation_errors":{"first_name":"cannot be blank"}}`,
+			responseBody:   `{"type":"https://course-watch.com/problems/validation_failed","title":"invalid request parameters","status":400,"code":"validation_failed","validation_errors":{"first_name":"cannot be blank"}}`,
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			setup := getTestSetup(t)
-			ctx := context.Background()
+			ctx := authenticatedContext()
 			tc.setupMocks(ctx, setup.users)
 			var body io.Reader
 			if tc.requestBody != "" {
@@ -246,3 +283,97 @@ func TestUpdateUserInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchUserInfo(t *testing.T) {
+	cases := map[string]struct {
+		contentType  string
+		ifMatch      string
+		requestBody  string
+		setupMocks   func(ctx context.Context, mockUsers *serviceMocks.MockUsers)
+		responseCode int
+		responseBody string
+	}{
+		"success": {
+			contentType: mergePatchContentType,
+			requestBody: `{"first_name":"UpdatedFirstName","display_name":null}`,
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				input := &service.PatchUserInfoInput{
+					FirstName:   patch.Value("UpdatedFirstName"),
+					DisplayName: patch.Null[string](),
+				}
+				mockUsers.EXPECT().PatchUserInfo(ctx, sampleUserPrincipal.UserId, input).Return(nil).Times(1)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		"empty_patch": {
+			contentType: mergePatchContentType,
+			requestBody: `{}`,
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				mockUsers.EXPECT().PatchUserInfo(ctx, sampleUserPrincipal.UserId, &service.PatchUserInfoInput{}).Return(nil).Times(1)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		"wrong_content_type": {
+			contentType:  "application/json",
+			requestBody:  `{"first_name":"UpdatedFirstName"}`,
+			setupMocks:   func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
+			responseCode: http.StatusUnsupportedMediaType,
+			responseBody: `{"type":"https://course-watch.com/problems/unsupported_media_type","title":"unsupported media type","status":415,"detail":"content type must be application/merge-patch+json","code":"unsupported_media_type"}`,
+		},
+		"not_an_object": {
+			contentType:  mergePatchContentType,
+			requestBody:  `null`,
+			setupMocks:   func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"type":"https://course-watch.com/problems/invalid_body","title":"body is missing or invalid","status":400,"code":"invalid_body"}`,
+		},
+		"unknown_member": {
+			contentType:  mergePatchContentType,
+			requestBody:  `{"firstname":"UpdatedFirstName"}`,
+			setupMocks:   func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"type":"https://course-watch.com/problems/invalid_body","title":"body is missing or invalid","status":400,"code":"invalid_body"}`,
+		},
+		"validation_failure": {
+			contentType: mergePatchContentType,
+			requestBody: `{"last_name":null}`,
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				input := &service.PatchUserInfoInput{LastName: patch.Null[string]()}
+				mockUsers.EXPECT().PatchUserInfo(ctx, sampleUserPrincipal.UserId, input).Return(input.Validate()).Times(1)
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"type":"https://course-watch.com/problems/validation_failed","title":"invalid request parameters","status":400,"code":"validation_failed","validation_errors":{"last_name":"cannot be blank"}}`,
+		},
+		"version_conflict": {
+			contentType: mergePatchContentType,
+			ifMatch:     `"2"`,
+			requestBody: `{"first_name":"UpdatedFirstName"}`,
+			setupMocks: func(ctx context.Context, mockUsers *serviceMocks.MockUsers) {
+				input := &service.PatchUserInfoInput{FirstName: patch.Value("UpdatedFirstName"), Version: 2}
+				mockUsers.EXPECT().PatchUserInfo(ctx, sampleUserPrincipal.UserId, input).Return(repository.ErrVersionConflict).Times(1)
+			},
+			responseCode: http.StatusPreconditionFailed,
+			responseBody: `{"type":"https://course-watch.com/problems/version_conflict","title":"the resource has been modified","status":412,"code":"version_conflict"}`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			setup := getTestSetup(t)
+			tc.setupMocks(authenticatedContext(), setup.users)
+
+			request := httptest.NewRequest(http.MethodPatch, "/api/v1/user", strings.NewReader(tc.requestBody))
+			addAuthorizationHeader(request, setup)
+			request.Header.Set("Content-Type", tc.contentType)
+			if tc.ifMatch != "" {
+				request.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			setup.router.ServeHTTP(rec, request)
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+			assert.Equal(t, tc.responseBody, rec.Body.String())
+		})
+	}
+}
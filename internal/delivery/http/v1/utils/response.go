@@ -1,8 +1,14 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rs/zerolog"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/logger"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
 	"net/http"
 )
 
@@ -17,52 +23,110 @@ import (
 //	ID interface{} `json:"id"`
 //}
 
+// ProblemContentType is the media type of error responses
+const ProblemContentType = "application/problem+json"
+
 // Response corresponds to RFC 7807 Problem Details object
 // https://www.rfc-editor.org/rfc/rfc7807
 type Response struct {
+	// Type identifies the kind of error, see Code
+	Type   string `json:"type" example:"https://course-watch.com/problems/not_found"`
 	Title  string `json:"title"`
 	Status int    `json:"status"`
+	// Detail explains this occurrence of the error, if there is anything to add to the title
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request id, which is also found in the X-Request-ID header and in the server logs
+	Instance string `json:"instance,omitempty" example:"5f0c7a3e0b1e4c8e9a7a2d6f3c1b9e44"`
+	// Code is the machine-readable error code, the last segment of Type
+	Code string `json:"code" example:"not_found"`
+	// Extensions are further members, which are rendered next to the standard ones
+	Extensions map[string]interface{} `json:"-"`
 }
 
+// ValidationError documents the problem details of requests failing validation
 type ValidationError struct {
+	Type             string            `json:"type" example:"https://course-watch.com/problems/validation_failed"`
 	Title            string            `json:"title" example:"invalid request parameters"`
 	Status           int               `json:"status" example:"400"`
+	Instance         string            `json:"instance,omitempty" example:"5f0c7a3e0b1e4c8e9a7a2d6f3c1b9e44"`
+	Code             string            `json:"code" example:"validation_failed"`
 	ValidationErrors validation.Errors `json:"validation_errors,omitempty"`
 }
 
-// ErrorResponseMessageOverride aborts the context and sends a properly formatted response with the specified message
-// TODO: log error data
-func ErrorResponseMessageOverride(c *gin.Context, statusCode int, _ error, message string) {
-	//logger.Error(err)
-	c.AbortWithStatusJSON(statusCode, Response{
-		Title:  message,
-		Status: statusCode,
-	})
+func (r Response) MarshalJSON() ([]byte, error) {
+	type members Response
+	standard, err := json.Marshal(members(r))
+	if err != nil || len(r.Extensions) == 0 {
+		return standard, err
+	}
+	extensions, err := json.Marshal(r.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	// both are objects: the closing brace of the first and the opening one of the second are replaced by a comma
+	result := append(bytes.TrimSuffix(standard, []byte("}")), ',')
+	return append(result, extensions[1:]...), nil
 }
 
-// ErrorResponse aborts the context and sends a properly formatted response with the message from supplied error
-func ErrorResponse(c *gin.Context, statusCode int, err error) {
-	//logger.Error(err)
-	c.AbortWithStatusJSON(statusCode, Response{
-		Title:  err.Error(),
-		Status: statusCode,
-	})
+// Abort stops the request with the error, which the Problems middleware renders as problem details. Errors which
+// are not service.Error are reported as internal server errors, see service.ErrorOf. The status is set right away,
+// so that it is also set without the middleware
+func Abort(c *gin.Context, err error) {
+	c.Status(service.ErrorOf(err).Status)
+	_ = c.Error(err)
+	c.Abort()
 }
 
-// ErrorResponseString aborts the context and sends a properly formatted response with the message from supplied string
-func ErrorResponseString(c *gin.Context, statusCode int, message string) {
-	//logger.Error(message)
-	c.AbortWithStatusJSON(statusCode, Response{
-		Title:  message,
-		Status: statusCode,
-	})
+// Problems renders the errors passed to Abort as problem details. It has to run before all handlers which call Abort
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		Flush(c)
+	}
+}
+
+// Flush renders the last error passed to Abort, unless a response has been written already. Middleware which needs
+// the complete response after c.Next calls it, the Problems middleware further up then has nothing left to do
+func Flush(c *gin.Context) {
+	if last := c.Errors.Last(); last != nil && !c.Writer.Written() {
+		RenderError(c, last.Err)
+	}
+}
+
+// RenderError writes the error as problem details right away and logs it. Most code should call Abort instead
+func RenderError(c *gin.Context, err error) {
+	domainErr := service.ErrorOf(err)
+	logResponseError(c, domainErr.Status).Err(err).Str("code", domainErr.Code).Msg("error response")
+	WriteProblem(c, domainErr)
 }
 
-func ValidationErrorResponse(c *gin.Context, validationErrors validation.Errors) {
-	//logger.Error(err)
-	c.AbortWithStatusJSON(http.StatusBadRequest, ValidationError{
-		Title:            "invalid request parameters",
-		Status:           http.StatusBadRequest,
-		ValidationErrors: validationErrors,
+// WriteProblem writes the error as problem details without logging it, for callers which have logged it already
+func WriteProblem(c *gin.Context, err error) {
+	domainErr := service.ErrorOf(err)
+	body, err := json.Marshal(Response{
+		Type:       domainErr.Type(),
+		Title:      domainErr.Title,
+		Status:     domainErr.Status,
+		Detail:     domainErr.Detail,
+		Instance:   requestid.FromContext(c.Request.Context()),
+		Code:       domainErr.Code,
+		Extensions: domainErr.Extensions,
 	})
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error().Err(err).Msg("failed to render the error")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Abort()
+	c.Data(domainErr.Status, ProblemContentType, body)
+}
+
+// logResponseError starts a log entry for an error the client is told about. Client errors are only logged at the
+// debug level, server errors always
+func logResponseError(c *gin.Context, statusCode int) *zerolog.Event {
+	log := logger.FromContext(c.Request.Context())
+	if statusCode >= http.StatusInternalServerError {
+		return log.Error().Int("status", statusCode)
+	}
+	return log.Debug().Int("status", statusCode)
 }
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
+)
+
+func TestProblems(t *testing.T) {
+	cases := map[string]struct {
+		handler      gin.HandlerFunc
+		responseCode int
+		responseBody string
+		contentType  string
+	}{
+		"domain_error": {
+			handler: func(c *gin.Context) {
+				Abort(c, service.ErrUserAlreadyExist)
+			},
+			responseCode: http.StatusConflict,
+			responseBody: `{"type":"https://course-watch.com/problems/user_already_exists","title":"user already exist with given mailId","status":409,"code":"user_already_exists"}`,
+			contentType:  ProblemContentType,
+		},
+		"detail": {
+			handler: func(c *gin.Context) {
+				Abort(c, service.ErrInvalidRequest.WithDetail("empty id param"))
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"type":"https://course-watch.com/problems/invalid_request","title":"invalid request","status":400,"detail":"empty id param","code":"invalid_request"}`,
+			contentType:  ProblemContentType,
+		},
+		"extensions": {
+			handler: func(c *gin.Context) {
+				Abort(c, validation.Errors{"title": errors.New("cannot be blank")})
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"type":"https://course-watch.com/problems/validation_failed","title":"invalid request parameters","status":400,"code":"validation_failed","validation_errors":{"title":"cannot be blank"}}`,
+			contentType:  ProblemContentType,
+		},
+		"internal_details_are_hidden": {
+			handler: func(c *gin.Context) {
+				Abort(c, errors.New("connection refused"))
+			},
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"type":"https://course-watch.com/problems/internal_error","title":"internal server error","status":500,"code":"internal_error"}`,
+			contentType:  ProblemContentType,
+		},
+		"written_response_is_kept": {
+			handler: func(c *gin.Context) {
+				_ = c.Error(errors.New("logged elsewhere"))
+				c.String(http.StatusAccepted, "accepted")
+			},
+			responseCode: http.StatusAccepted,
+			responseBody: "accepted",
+			contentType:  "text/plain; charset=utf-8",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Problems())
+			router.GET("/items/:id", tc.handler)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/42?q=1", nil))
+
+			assert.Equal(t, tc.responseCode, rec.Code)
+			assert.Equal(t, tc.responseBody, rec.Body.String())
+			assert.Equal(t, tc.contentType, rec.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestProblems_InstanceIsRequestId(t *testing.T) {
+	router := gin.New()
+	router.Use(Problems())
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), "5f0c7a3e0b1e4c8e9a7a2d6f3c1b9e44"))
+		Abort(c, service.ErrNotFound)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/42", nil))
+
+	assert.Equal(t, `{"type":"https://course-watch.com/problems/not_found","title":"not found","status":404,`+
+		`"instance":"5f0c7a3e0b1e4c8e9a7a2d6f3c1b9e44","code":"not_found"}`, rec.Body.String())
+}
+
+func TestAbort_StopsTheChain(t *testing.T) {
+	router := gin.New()
+	router.Use(Problems())
+	reached := false
+	router.GET("/items", func(c *gin.Context) {
+		Abort(c, service.ErrForbidden)
+	}, func(c *gin.Context) {
+		reached = true
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.False(t, reached)
+}
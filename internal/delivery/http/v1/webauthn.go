@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/auth"
+	"github.com/zhuravlev-pe/course-watch/internal/delivery/http/v1/utils"
+	"github.com/zhuravlev-pe/course-watch/internal/service"
+)
+
+func (h *Handler) initWebAuthnRoutes(api *gin.RouterGroup) {
+	webAuthn := api.Group("/webauthn")
+	{
+		webAuthn.POST("/login/begin", h.beginWebAuthnLogin)
+		webAuthn.POST("/login/finish", h.finishWebAuthnLogin)
+	}
+	registration := webAuthn.Group("/register", h.bearer.Authenticate)
+	{
+		registration.POST("/begin", h.beginWebAuthnRegistration)
+		registration.POST("/finish", h.finishWebAuthnRegistration)
+	}
+}
+
+// @Summary Start passkey registration
+// @Tags Authentication
+// @Description starts a WebAuthn registration ceremony for the current user. Options must be passed to navigator.credentials.create()
+// @ModuleID beginWebAuthnRegistration
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} service.WebAuthnBeginOutput
+// @Failure 401,404,500 {object} utils.Response
+// @Router /auth/webauthn/register/begin [post]
+func (h *Handler) beginWebAuthnRegistration(ctx *gin.Context) {
+	up, err := auth.GetAuthenticatedUser(ctx)
+	if err != nil {
+		err = fmt.Errorf("authentication middleware failure: %w", err)
+		utils.Abort(ctx, err)
+		return
+	}
+
+	result, err := h.services.WebAuthn.BeginRegistration(ctx.Request.Context(), up.UserId)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary Finish passkey registration
+// @Tags Authentication
+// @Description verifies the credential created by the authenticator and stores it for the current user
+// @ModuleID finishWebAuthnRegistration
+// @Accept  json
+// @Produce  json
+// @Param input body service.WebAuthnFinishInput true "ceremony id and the created credential"
+// @Success 204
+// @Failure 400,401,404,500 {object} utils.Response
+// @Router /auth/webauthn/register/finish [post]
+func (h *Handler) finishWebAuthnRegistration(ctx *gin.Context) {
+	up, err := auth.GetAuthenticatedUser(ctx)
+	if err != nil {
+		err = fmt.Errorf("authentication middleware failure: %w", err)
+		utils.Abort(ctx, err)
+		return
+	}
+	var input service.WebAuthnFinishInput
+	if !h.parseRequestBody(ctx, &input) {
+		return
+	}
+
+	err = h.services.WebAuthn.FinishRegistration(ctx.Request.Context(), up.UserId, &input)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Start passkey login
+// @Tags Authentication
+// @Description starts a WebAuthn authentication ceremony. Options must be passed to navigator.credentials.get()
+// @ModuleID beginWebAuthnLogin
+// @Accept  json
+// @Produce  json
+// @Param input body service.WebAuthnLoginInput true "email of the user"
+// @Success 200 {object} service.WebAuthnBeginOutput
+// @Failure 400,500 {object} utils.Response
+// @Router /auth/webauthn/login/begin [post]
+func (h *Handler) beginWebAuthnLogin(ctx *gin.Context) {
+	var input service.WebAuthnLoginInput
+	if !h.parseRequestBody(ctx, &input) {
+		return
+	}
+
+	result, err := h.services.WebAuthn.BeginLogin(ctx.Request.Context(), &input)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary Finish passkey login
+// @Tags Authentication
+// @Description verifies the assertion returned by the authenticator and logs the user in
+// @ModuleID finishWebAuthnLogin
+// @Accept  json
+// @Produce  json
+// @Param input body service.WebAuthnFinishInput true "ceremony id and the assertion"
+// @Success 200 {object} service.PostUserLoginOutput
+// @Failure 400,500 {object} utils.Response
+// @Router /auth/webauthn/login/finish [post]
+func (h *Handler) finishWebAuthnLogin(ctx *gin.Context) {
+	var input service.WebAuthnFinishInput
+	if !h.parseRequestBody(ctx, &input) {
+		return
+	}
+
+	user, err := h.services.WebAuthn.FinishLogin(ctx.Request.Context(), &input)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+
+	output, err := h.startSession(ctx, user, input.DeviceLabel)
+	if err != nil {
+		utils.Abort(ctx, err)
+		return
+	}
+	h.sessionResponse(ctx, output)
+}
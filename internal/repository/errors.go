@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrVersionConflict is returned by conditional updates when the entity has been changed since the expected
+	// version was read
+	ErrVersionConflict = errors.New("version conflict")
 )
@@ -2,12 +2,16 @@ package fake_repo
 
 import (
 	"context"
+	"sync"
 	
 	"github.com/zhuravlev-pe/course-watch/internal/core"
 	"github.com/zhuravlev-pe/course-watch/internal/repository"
 )
 
+// courses keeps the courses in memory. The API server uses it until courses are stored in Postgres, so it must be
+// safe for concurrent requests
 type courses struct {
+	mu   sync.RWMutex
 	data map[string]*core.Course
 }
 
@@ -18,14 +22,60 @@ func NewCourses() repository.Courses {
 }
 
 func (c *courses) GetById(_ context.Context, id string) (*core.Course, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	course, ok := c.data[id]
 	if !ok {
 		return nil, repository.ErrNotFound
 	}
-	return course, nil
+	result := *course
+	return &result, nil
 }
 
 func (c *courses) Insert(_ context.Context, course *core.Course) error {
-	c.data[course.Id] = course
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stored := *course
+	stored.Version = 1
+	c.data[course.Id] = &stored
+	return nil
+}
+
+func (c *courses) Update(_ context.Context, id string, input *repository.UpdateCourseInput) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	existing, ok := c.data[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if input.ExpectedVersion != 0 && input.ExpectedVersion != existing.Version {
+		return repository.ErrVersionConflict
+	}
+	stored := *existing
+	stored.Title = input.Title
+	stored.Description = input.Description
+	stored.Version++
+	c.data[id] = &stored
+	return nil
+}
+
+func (c *courses) Patch(_ context.Context, id string, input *repository.PatchCourseInput) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	existing, ok := c.data[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if input.ExpectedVersion != 0 && input.ExpectedVersion != existing.Version {
+		return repository.ErrVersionConflict
+	}
+	if !input.Title.Set && !input.Description.Set {
+		return nil
+	}
+	stored := *existing
+	input.Title.Apply(&stored.Title)
+	input.Description.Apply(&stored.Description)
+	stored.Version++
+	c.data[id] = &stored
 	return nil
 }
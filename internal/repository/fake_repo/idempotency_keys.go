@@ -0,0 +1,100 @@
+package fake_repo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+type idempotencyKeyId struct {
+	userId, route, key string
+}
+
+type idempotencyKeys struct {
+	mu   sync.Mutex
+	data map[idempotencyKeyId]*core.IdempotencyKey
+}
+
+func newIdempotencyKeys() repository.IdempotencyKeys {
+	return &idempotencyKeys{
+		data: map[idempotencyKeyId]*core.IdempotencyKey{},
+	}
+}
+
+func (i *idempotencyKeys) Reserve(ctx context.Context, record *core.IdempotencyKey, now time.Time) (*core.IdempotencyKey, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	id := idempotencyKeyId{userId: record.UserId, route: record.Route, key: record.Key}
+	if existing, ok := i.data[id]; ok && existing.ExpiresAt.After(now) {
+		result := *existing
+		return &result, nil
+	}
+	stored := *record
+	stored.StatusCode, stored.Headers, stored.Body = 0, nil, nil
+	i.data[id] = &stored
+	return nil, nil
+}
+
+func (i *idempotencyKeys) TakeOver(ctx context.Context, record *core.IdempotencyKey, token string) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	existing, ok := i.data[idempotencyKeyId{userId: record.UserId, route: record.Route, key: record.Key}]
+	if !ok || existing.Token != token || existing.StatusCode != 0 {
+		return false, nil
+	}
+	existing.Fingerprint, existing.Token = record.Fingerprint, record.Token
+	existing.CreatedAt, existing.ExpiresAt = record.CreatedAt, record.ExpiresAt
+	return true, nil
+}
+
+func (i *idempotencyKeys) Complete(ctx context.Context, record *core.IdempotencyKey) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	existing, ok := i.data[idempotencyKeyId{userId: record.UserId, route: record.Route, key: record.Key}]
+	if !ok || existing.Token != record.Token {
+		return repository.ErrNotFound
+	}
+	existing.StatusCode, existing.Headers, existing.Body = record.StatusCode, record.Headers, record.Body
+	return nil
+}
+
+func (i *idempotencyKeys) Release(ctx context.Context, userId string, route string, key string, token string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	id := idempotencyKeyId{userId: userId, route: route, key: key}
+	if existing, ok := i.data[id]; ok && existing.Token == token {
+		delete(i.data, id)
+	}
+	return nil
+}
+
+func (i *idempotencyKeys) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	count := 0
+	for id, record := range i.data {
+		if !record.ExpiresAt.After(now) {
+			delete(i.data, id)
+			count++
+		}
+	}
+	return count, nil
+}
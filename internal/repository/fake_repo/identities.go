@@ -0,0 +1,81 @@
+package fake_repo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+type identities struct {
+	mu     sync.Mutex
+	data   map[string]*core.UserIdentity
+	states map[string]*core.OIDCLoginState
+}
+
+func newIdentities() repository.Identities {
+	return &identities{
+		data:   map[string]*core.UserIdentity{},
+		states: map[string]*core.OIDCLoginState{},
+	}
+}
+
+func identityKey(provider, subject string) string {
+	return provider + "\x00" + subject
+}
+
+func (i *identities) GetByProviderSubject(ctx context.Context, provider string, subject string) (*core.UserIdentity, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	identity, ok := i.data[identityKey(provider, subject)]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	result := *identity
+	return &result, nil
+}
+
+func (i *identities) Insert(ctx context.Context, identity *core.UserIdentity) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	key := identityKey(identity.Provider, identity.Subject)
+	if _, ok := i.data[key]; ok {
+		return errors.New("identity already exists")
+	}
+	stored := *identity
+	i.data[key] = &stored
+	return nil
+}
+
+func (i *identities) InsertLoginState(ctx context.Context, state *core.OIDCLoginState) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	stored := *state
+	i.states[state.State] = &stored
+	return nil
+}
+
+func (i *identities) TakeLoginState(ctx context.Context, state string) (*core.OIDCLoginState, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	s, ok := i.states[state]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(i.states, state)
+	return s, nil
+}
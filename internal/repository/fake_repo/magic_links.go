@@ -0,0 +1,98 @@
+package fake_repo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+type magicLinkAttempts struct {
+	windowStart time.Time
+	count       int
+}
+
+type magicLinks struct {
+	mu       sync.Mutex
+	data     map[string]*core.MagicLink
+	attempts map[string]*magicLinkAttempts
+}
+
+func newMagicLinks() repository.MagicLinks {
+	return &magicLinks{
+		data:     map[string]*core.MagicLink{},
+		attempts: map[string]*magicLinkAttempts{},
+	}
+}
+
+func (m *magicLinks) GetById(ctx context.Context, id string) (*core.MagicLink, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	link, ok := m.data[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	result := *link
+	return &result, nil
+}
+
+func (m *magicLinks) Insert(ctx context.Context, link *core.MagicLink) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *link
+	m.data[link.Id] = &stored
+	return nil
+}
+
+func (m *magicLinks) MarkUsed(ctx context.Context, id string, usedAt time.Time) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	link, ok := m.data[id]
+	if !ok || link.UsedAt != nil {
+		return repository.ErrNotFound
+	}
+	link.UsedAt = &usedAt
+	return nil
+}
+
+func (m *magicLinks) CountAttempt(ctx context.Context, email string, now time.Time, window time.Duration) (int, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempts, ok := m.attempts[email]
+	if !ok || !attempts.windowStart.After(now.Add(-window)) {
+		attempts = &magicLinkAttempts{windowStart: now}
+		m.attempts[email] = attempts
+	}
+	attempts.count++
+	return attempts.count, nil
+}
+
+func (m *magicLinks) DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for email, attempts := range m.attempts {
+		if !attempts.windowStart.After(before) {
+			delete(m.attempts, email)
+			count++
+		}
+	}
+	return count, nil
+}
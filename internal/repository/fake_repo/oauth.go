@@ -0,0 +1,112 @@
+package fake_repo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+type oauth struct {
+	mu      sync.Mutex
+	clients map[string]*core.OAuthClient
+	codes   map[string]*core.OAuthAuthorizationCode
+}
+
+func newOAuth() repository.OAuth {
+	return &oauth{
+		clients: map[string]*core.OAuthClient{},
+		codes:   map[string]*core.OAuthAuthorizationCode{},
+	}
+}
+
+func (o *oauth) GetClient(ctx context.Context, id string) (*core.OAuthClient, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	c, ok := o.clients[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	result := *c
+	return &result, nil
+}
+
+func (o *oauth) ListClients(ctx context.Context) ([]*core.OAuthClient, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	result := make([]*core.OAuthClient, 0, len(o.clients))
+	for _, c := range o.clients {
+		copied := *c
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (o *oauth) InsertClient(ctx context.Context, client *core.OAuthClient) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.clients[client.Id]; ok {
+		return errors.New("client already exists")
+	}
+	stored := *client
+	o.clients[client.Id] = &stored
+	return nil
+}
+
+func (o *oauth) DeleteClient(ctx context.Context, id string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.clients[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(o.clients, id)
+	for hash, code := range o.codes {
+		if code.ClientId == id {
+			delete(o.codes, hash)
+		}
+	}
+	return nil
+}
+
+func (o *oauth) InsertAuthorizationCode(ctx context.Context, code *core.OAuthAuthorizationCode) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	stored := *code
+	o.codes[code.CodeHash] = &stored
+	return nil
+}
+
+func (o *oauth) TakeAuthorizationCode(ctx context.Context, codeHash string) (*core.OAuthAuthorizationCode, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	code, ok := o.codes[codeHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(o.codes, codeHash)
+	return code, nil
+}
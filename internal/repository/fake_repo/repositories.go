@@ -10,11 +10,20 @@ import (
 
 func New() *repository.Repositories {
 	result := &repository.Repositories{
-		Courses: NewCourses(),
-		Users:   newUsers(),
+		Courses:         NewCourses(),
+		Users:           newUsers(),
+		Sessions:        newSessions(),
+		MagicLinks:      newMagicLinks(),
+		WebAuthn:        newWebAuthn(),
+		Identities:      newIdentities(),
+		OAuth:           newOAuth(),
+		IdempotencyKeys: newIdempotencyKeys(),
 	}
+	result.Transactor = newTransactor(result)
 	
-	err := result.Users.Insert(context.Background(), &SampleUser)
+	// a copy, so that updates do not leak into SampleUser and other instances
+	sampleUser := SampleUser
+	err := result.Users.Insert(context.Background(), &sampleUser)
 	if err != nil {
 		panic(err)
 	}
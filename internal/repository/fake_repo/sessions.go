@@ -0,0 +1,108 @@
+package fake_repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+type sessions struct {
+	mu   sync.RWMutex
+	data map[string]*core.Session
+}
+
+func newSessions() repository.Sessions {
+	return &sessions{
+		data: map[string]*core.Session{},
+	}
+}
+
+func (s *sessions) GetById(ctx context.Context, id string) (*core.Session, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.data[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	result := *session
+	return &result, nil
+}
+
+func (s *sessions) Insert(ctx context.Context, session *core.Session) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *session
+	s.data[session.Id] = &stored
+	return nil
+}
+
+func (s *sessions) ListActiveByUser(ctx context.Context, userId string, now time.Time) ([]*core.Session, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*core.Session, 0)
+	for _, session := range s.data {
+		if session.UserId == userId && session.IsActive(now) {
+			item := *session
+			result = append(result, &item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeenAt.After(result[j].LastSeenAt)
+	})
+	return result, nil
+}
+
+func (s *sessions) UpdateLastSeen(ctx context.Context, id string, lastSeen time.Time) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.data[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	session.LastSeenAt = lastSeen
+	return nil
+}
+
+func (s *sessions) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.data[id]
+	if !ok || session.RevokedAt != nil {
+		return repository.ErrNotFound
+	}
+	session.RevokedAt = &revokedAt
+	return nil
+}
+
+func (s *sessions) RevokeAllByUser(ctx context.Context, userId string, exceptId string, revokedAt time.Time) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.data {
+		if session.UserId == userId && id != exceptId && session.RevokedAt == nil {
+			session.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
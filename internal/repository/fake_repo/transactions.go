@@ -0,0 +1,169 @@
+package fake_repo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+// transactor runs the units of work one at a time. Failed units of work are undone by restoring a snapshot of the
+// repositories taken before they started. Changes made outside of units of work are not isolated from them
+type transactor struct {
+	mu    *sync.Mutex
+	repos *repository.Repositories
+	// nested is set for the transactor passed to a unit of work, whose WithTx works like a savepoint
+	nested bool
+}
+
+func newTransactor(repos *repository.Repositories) *transactor {
+	return &transactor{mu: &sync.Mutex{}, repos: repos}
+}
+
+func (t *transactor) WithTx(ctx context.Context, fn func(repos *repository.Repositories) error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if !t.nested {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+
+	restore := t.snapshot()
+	repos := *t.repos
+	repos.Transactor = &transactor{mu: t.mu, repos: t.repos, nested: true}
+	committed := false
+	defer func() {
+		// also undoes the changes of a panicking unit of work
+		if !committed {
+			restore()
+		}
+	}()
+	if err := fn(&repos); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// snapshotter is implemented by the fake repositories. snapshot copies the data and returns a function which puts the
+// copy back
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+func (t *transactor) snapshot() func() {
+	var restores []func()
+	for _, repo := range []interface{}{
+		t.repos.Courses, t.repos.Users, t.repos.Sessions, t.repos.MagicLinks, t.repos.WebAuthn, t.repos.Identities,
+		t.repos.OAuth, t.repos.IdempotencyKeys,
+	} {
+		if s, ok := repo.(snapshotter); ok {
+			restores = append(restores, s.snapshot())
+		}
+	}
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
+// cloneValues copies the map and the values its pointers point to, since the fakes modify stored values in place
+func cloneValues[K comparable, V any](m map[K]*V) map[K]*V {
+	result := make(map[K]*V, len(m))
+	for k, v := range m {
+		c := *v
+		result[k] = &c
+	}
+	return result
+}
+
+func (c *courses) snapshot() func() {
+	c.mu.RLock()
+	data := cloneValues(c.data)
+	c.mu.RUnlock()
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.data = data
+	}
+}
+
+func (u *users) snapshot() func() {
+	byIds := cloneValues(u.byIds)
+	byEmail := make(map[string]*core.User, len(byIds))
+	for _, user := range byIds {
+		byEmail[user.Email] = user
+	}
+	return func() {
+		u.byIds, u.byEmail = byIds, byEmail
+	}
+}
+
+func (s *sessions) snapshot() func() {
+	s.mu.RLock()
+	data := cloneValues(s.data)
+	s.mu.RUnlock()
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.data = data
+	}
+}
+
+func (m *magicLinks) snapshot() func() {
+	m.mu.Lock()
+	data, attempts := cloneValues(m.data), cloneValues(m.attempts)
+	m.mu.Unlock()
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.data, m.attempts = data, attempts
+	}
+}
+
+func (w *webAuthn) snapshot() func() {
+	w.mu.Lock()
+	credentials, ceremonies := cloneValues(w.credentials), cloneValues(w.ceremonies)
+	w.mu.Unlock()
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.credentials, w.ceremonies = credentials, ceremonies
+	}
+}
+
+func (i *identities) snapshot() func() {
+	i.mu.Lock()
+	data, states := cloneValues(i.data), cloneValues(i.states)
+	i.mu.Unlock()
+	return func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		i.data, i.states = data, states
+	}
+}
+
+func (o *oauth) snapshot() func() {
+	o.mu.Lock()
+	clients, codes := cloneValues(o.clients), cloneValues(o.codes)
+	o.mu.Unlock()
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.clients, o.codes = clients, codes
+	}
+}
+
+func (i *idempotencyKeys) snapshot() func() {
+	i.mu.Lock()
+	data := cloneValues(i.data)
+	i.mu.Unlock()
+	return func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		i.data = data
+	}
+}
@@ -0,0 +1,106 @@
+package fake_repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+func TestTransactor_WithTx(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("failure")
+	session := &core.Session{Id: "s1", UserId: SampleUser.Id, ExpiresAt: time.Now().Add(time.Hour)}
+	idempotencyKey := &core.IdempotencyKey{UserId: SampleUser.Id, Route: "POST /", Key: "k1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	cases := map[string]struct {
+		fn              func(repos *repository.Repositories) error
+		expectedErr     error
+		expectedName    string
+		expectedSession bool
+		expectedKey     bool
+	}{
+		"committed": {
+			fn: func(repos *repository.Repositories) error {
+				if err := repos.Users.Update(ctx, SampleUser.Id, &repository.UpdateUserInput{FirstName: "Jane"}); err != nil {
+					return err
+				}
+				if _, err := repos.IdempotencyKeys.Reserve(ctx, idempotencyKey, time.Now()); err != nil {
+					return err
+				}
+				return repos.Sessions.Insert(ctx, session)
+			},
+			expectedName:    "Jane",
+			expectedSession: true,
+			expectedKey:     true,
+		},
+		"rolled_back": {
+			fn: func(repos *repository.Repositories) error {
+				if err := repos.Users.Update(ctx, SampleUser.Id, &repository.UpdateUserInput{FirstName: "Jane"}); err != nil {
+					return err
+				}
+				if err := repos.Sessions.Insert(ctx, session); err != nil {
+					return err
+				}
+				return failure
+			},
+			expectedErr:  failure,
+			expectedName: SampleUser.FirstName,
+		},
+		"rolled_back_idempotency_key": {
+			fn: func(repos *repository.Repositories) error {
+				if _, err := repos.IdempotencyKeys.Reserve(ctx, idempotencyKey, time.Now()); err != nil {
+					return err
+				}
+				return failure
+			},
+			expectedErr:  failure,
+			expectedName: SampleUser.FirstName,
+		},
+		"failed_savepoint": {
+			fn: func(repos *repository.Repositories) error {
+				if err := repos.Sessions.Insert(ctx, session); err != nil {
+					return err
+				}
+				err := repos.Transactor.WithTx(ctx, func(repos *repository.Repositories) error {
+					if err := repos.Users.Update(ctx, SampleUser.Id, &repository.UpdateUserInput{FirstName: "Jane"}); err != nil {
+						return err
+					}
+					return failure
+				})
+				assert.Equal(t, failure, err)
+				return nil
+			},
+			expectedName:    SampleUser.FirstName,
+			expectedSession: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			repos := New()
+
+			err := repos.Transactor.WithTx(ctx, tc.fn)
+
+			assert.Equal(t, tc.expectedErr, err)
+			user, err := repos.Users.GetById(ctx, SampleUser.Id)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedName, user.FirstName)
+			_, err = repos.Sessions.GetById(ctx, session.Id)
+			if tc.expectedSession {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, repository.ErrNotFound, err)
+			}
+			// reserving the key again returns the existing record
+			existing, err := repos.IdempotencyKeys.Reserve(ctx, idempotencyKey, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedKey, existing != nil)
+		})
+	}
+}
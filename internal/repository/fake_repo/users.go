@@ -3,9 +3,11 @@ package fake_repo
 import (
 	"context"
 	"errors"
+	"sort"
 
 	"github.com/zhuravlev-pe/course-watch/internal/core"
 	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
 )
 
 type users struct {
@@ -39,8 +41,10 @@ func (u *users) Insert(ctx context.Context, user *core.User) error {
 	if ok {
 		return errors.New("user with the specified id already exists")
 	}
-	u.byIds[user.Id] = user
-	u.byEmail[user.Email] = user
+	stored := *user
+	stored.Version = 1
+	u.byIds[user.Id] = &stored
+	u.byEmail[user.Email] = &stored
 	return nil
 }
 
@@ -48,13 +52,44 @@ func (u *users) Update(ctx context.Context, id string, input *repository.UpdateU
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
-	user, ok := u.byIds[id]
+	existing, ok := u.byIds[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if input.ExpectedVersion != 0 && input.ExpectedVersion != existing.Version {
+		return repository.ErrVersionConflict
+	}
+	stored := *existing
+	stored.FirstName = input.FirstName
+	stored.LastName = input.LastName
+	stored.DisplayName = input.DisplayName
+	stored.Version++
+	u.byIds[id] = &stored
+	u.byEmail[stored.Email] = &stored
+	return nil
+}
+
+func (u *users) Patch(ctx context.Context, id string, input *repository.PatchUserInput) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	existing, ok := u.byIds[id]
 	if !ok {
 		return repository.ErrNotFound
 	}
-	user.FirstName = input.FirstName
-	user.LastName = input.LastName
-	user.DisplayName = input.DisplayName
+	if input.ExpectedVersion != 0 && input.ExpectedVersion != existing.Version {
+		return repository.ErrVersionConflict
+	}
+	if !input.FirstName.Set && !input.LastName.Set && !input.DisplayName.Set {
+		return nil
+	}
+	stored := *existing
+	input.FirstName.Apply(&stored.FirstName)
+	input.LastName.Apply(&stored.LastName)
+	input.DisplayName.Apply(&stored.DisplayName)
+	stored.Version++
+	u.byIds[id] = &stored
+	u.byEmail[stored.Email] = &stored
 	return nil
 }
 
@@ -68,3 +103,90 @@ func (u *users) GetByEmail(ctx context.Context, email string) (*core.User, error
 	}
 	return user, nil
 }
+
+func (u *users) UpdateAccount(ctx context.Context, user *core.User) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	existing, ok := u.byIds[user.Id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	// a copy is stored, so that the caller cannot change the stored user afterwards (e.g. SampleUser)
+	stored := *existing
+	stored.Email = user.Email
+	stored.FirstName = user.FirstName
+	stored.LastName = user.LastName
+	stored.DisplayName = user.DisplayName
+	stored.ExternalId = user.ExternalId
+	stored.Disabled = user.Disabled
+	stored.Version++
+	delete(u.byEmail, existing.Email)
+	u.byIds[user.Id] = &stored
+	u.byEmail[stored.Email] = &stored
+	return nil
+}
+
+func (u *users) UpdatePassword(ctx context.Context, id string, hashedPassword []byte) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	existing, ok := u.byIds[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored := *existing
+	stored.HashedPassword = hashedPassword
+	stored.Version++
+	u.byIds[id] = &stored
+	u.byEmail[stored.Email] = &stored
+	return nil
+}
+
+func (u *users) UpdateRoles(ctx context.Context, id string, roles []security.Role) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	existing, ok := u.byIds[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored := *existing
+	stored.Roles = append([]security.Role(nil), roles...)
+	stored.Version++
+	u.byIds[id] = &stored
+	u.byEmail[stored.Email] = &stored
+	return nil
+}
+
+func (u *users) List(ctx context.Context, filter *repository.UserFilter, offset int, limit int) ([]*core.User, int, error) {
+	if ctx.Err() != nil {
+		return nil, 0, ctx.Err()
+	}
+	matches := func(value string, expected *string) bool {
+		return expected == nil || value == *expected
+	}
+	var found []*core.User
+	for _, user := range u.byIds {
+		if matches(user.Email, filter.Email) &&
+			matches(user.ExternalId, filter.ExternalId) &&
+			matches(user.FirstName, filter.FirstName) &&
+			matches(user.LastName, filter.LastName) &&
+			matches(user.DisplayName, filter.DisplayName) &&
+			(filter.Disabled == nil || user.Disabled == *filter.Disabled) {
+			found = append(found, user)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].RegistrationDate.Equal(found[j].RegistrationDate) {
+			return found[i].Id < found[j].Id
+		}
+		return found[i].RegistrationDate.Before(found[j].RegistrationDate)
+	})
+
+	result := make([]*core.User, 0)
+	for i := offset; i < len(found) && len(result) < limit; i++ {
+		result = append(result, found[i])
+	}
+	return result, len(found), nil
+}
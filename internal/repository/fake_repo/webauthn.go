@@ -0,0 +1,94 @@
+package fake_repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+type webAuthn struct {
+	mu          sync.Mutex
+	credentials map[string]*core.WebAuthnCredential
+	ceremonies  map[string]*core.WebAuthnCeremony
+}
+
+func newWebAuthn() repository.WebAuthn {
+	return &webAuthn{
+		credentials: map[string]*core.WebAuthnCredential{},
+		ceremonies:  map[string]*core.WebAuthnCeremony{},
+	}
+}
+
+func (w *webAuthn) ListCredentialsByUser(ctx context.Context, userId string) ([]*core.WebAuthnCredential, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	result := make([]*core.WebAuthnCredential, 0)
+	for _, c := range w.credentials {
+		if c.UserId == userId {
+			item := *c
+			result = append(result, &item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (w *webAuthn) InsertCredential(ctx context.Context, credential *core.WebAuthnCredential) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stored := *credential
+	w.credentials[credential.Id] = &stored
+	return nil
+}
+
+func (w *webAuthn) UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, usedAt time.Time) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c, ok := w.credentials[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	c.SignCount = signCount
+	c.LastUsedAt = &usedAt
+	return nil
+}
+
+func (w *webAuthn) InsertCeremony(ctx context.Context, ceremony *core.WebAuthnCeremony) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stored := *ceremony
+	w.ceremonies[ceremony.Id] = &stored
+	return nil
+}
+
+func (w *webAuthn) TakeCeremony(ctx context.Context, id string) (*core.WebAuthnCeremony, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c, ok := w.ceremonies[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(w.ceremonies, id)
+	return c, nil
+}
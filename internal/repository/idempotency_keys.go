@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+// IdempotencyKeysRepo reads from the primary only, since the retries of a request arrive right after it
+type IdempotencyKeysRepo struct {
+	db postgres.Router
+}
+
+func NewIdempotencyKeysRepo(db postgres.Router) *IdempotencyKeysRepo {
+	return &IdempotencyKeysRepo{db: db}
+}
+
+func (r *IdempotencyKeysRepo) Reserve(ctx context.Context, record *core.IdempotencyKey, now time.Time) (*core.IdempotencyKey, error) {
+	// an expired record is replaced as if it did not exist
+	query := `
+		INSERT INTO public.idempotency_keys
+		    (user_id, route, key, fingerprint, token, status_code, headers, body, created_at, expires_at)
+		VALUES
+		    ($1, $2, $3, $4, $5, NULL, NULL, NULL, $6, $7)
+		ON CONFLICT (user_id, route, key) DO UPDATE
+		  SET (fingerprint, token, status_code, headers, body, created_at, expires_at) =
+		      (EXCLUDED.fingerprint, EXCLUDED.token, NULL, NULL, NULL, EXCLUDED.created_at, EXCLUDED.expires_at)
+		  WHERE idempotency_keys.expires_at <= $8
+		`
+
+	for {
+		tag, err := r.db.Writer(ctx).Exec(ctx, query, record.UserId, record.Route, record.Key, record.Fingerprint,
+			record.Token, record.CreatedAt, record.ExpiresAt, now)
+		if err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+		existing, err := r.get(ctx, record.UserId, record.Route, record.Key)
+		// the record may have been released in the meantime, then the key can be reserved again
+		if !errors.Is(err, ErrNotFound) {
+			return existing, err
+		}
+	}
+}
+
+func (r *IdempotencyKeysRepo) get(ctx context.Context, userId string, route string, key string) (*core.IdempotencyKey, error) {
+	query := `
+		SELECT user_id, route, key, fingerprint, token, status_code, headers, body, created_at, expires_at
+		FROM public.idempotency_keys
+		WHERE user_id = $1 AND route = $2 AND key = $3;
+		`
+
+	var record core.IdempotencyKey
+	var statusCode *int
+	err := r.db.Writer(ctx).QueryRow(ctx, query, userId, route, key).Scan(
+		&record.UserId,
+		&record.Route,
+		&record.Key,
+		&record.Fingerprint,
+		&record.Token,
+		&statusCode,
+		&record.Headers,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if statusCode != nil {
+		record.StatusCode = *statusCode
+	}
+	return &record, nil
+}
+
+func (r *IdempotencyKeysRepo) TakeOver(ctx context.Context, record *core.IdempotencyKey, token string) (bool, error) {
+	query := `
+		UPDATE public.idempotency_keys
+		  SET (fingerprint, token, created_at, expires_at) = ($1, $2, $3, $4)
+		  WHERE user_id = $5 AND route = $6 AND key = $7 AND token = $8 AND status_code IS NULL
+		`
+
+	tag, err := r.db.Writer(ctx).Exec(ctx, query, record.Fingerprint, record.Token, record.CreatedAt, record.ExpiresAt,
+		record.UserId, record.Route, record.Key, token)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *IdempotencyKeysRepo) Complete(ctx context.Context, record *core.IdempotencyKey) error {
+	query := `
+		UPDATE public.idempotency_keys
+		  SET (status_code, headers, body) = ($1, $2, $3)
+		  WHERE user_id = $4 AND route = $5 AND key = $6 AND token = $7
+		`
+
+	tag, err := r.db.Writer(ctx).Exec(ctx, query, record.StatusCode, record.Headers, record.Body, record.UserId,
+		record.Route, record.Key, record.Token)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *IdempotencyKeysRepo) Release(ctx context.Context, userId string, route string, key string, token string) error {
+	query := `
+		DELETE FROM public.idempotency_keys
+		WHERE user_id = $1 AND route = $2 AND key = $3 AND token = $4;
+		`
+
+	_, err := r.db.Writer(ctx).Exec(ctx, query, userId, route, key, token)
+	return err
+}
+
+func (r *IdempotencyKeysRepo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	query := `
+		DELETE FROM public.idempotency_keys
+		WHERE expires_at <= $1;
+		`
+
+	tag, err := r.db.Writer(ctx).Exec(ctx, query, now)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+type IdentitiesRepo struct {
+	db postgres.Router
+}
+
+func NewIdentitiesRepo(db postgres.Router) *IdentitiesRepo {
+	return &IdentitiesRepo{db: db}
+}
+
+func (i *IdentitiesRepo) GetByProviderSubject(ctx context.Context, provider string, subject string) (*core.UserIdentity, error) {
+	query := `
+		SELECT provider, subject, user_id, email, created_at
+		FROM public.user_identities
+		WHERE provider = $1 AND subject = $2;
+		`
+
+	var identity core.UserIdentity
+	err := i.db.Reader(ctx).QueryRow(ctx, query, provider, subject).Scan(
+		&identity.Provider,
+		&identity.Subject,
+		&identity.UserId,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (i *IdentitiesRepo) Insert(ctx context.Context, identity *core.UserIdentity) error {
+	query := `
+		INSERT INTO public.user_identities
+		    (provider, subject, user_id, email, created_at)
+		VALUES
+		    ($1, $2, $3, $4, $5);
+		`
+
+	_, err := i.db.Writer(ctx).Exec(ctx, query, identity.Provider, identity.Subject, identity.UserId, identity.Email,
+		identity.CreatedAt)
+	return err
+}
+
+func (i *IdentitiesRepo) InsertLoginState(ctx context.Context, state *core.OIDCLoginState) error {
+	query := `
+		INSERT INTO public.oidc_login_states
+		    (state, provider, nonce, code_verifier, expires_at)
+		VALUES
+		    ($1, $2, $3, $4, $5);
+		`
+
+	_, err := i.db.Writer(ctx).Exec(ctx, query, state.State, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt)
+	return err
+}
+
+func (i *IdentitiesRepo) TakeLoginState(ctx context.Context, state string) (*core.OIDCLoginState, error) {
+	query := `
+		DELETE FROM public.oidc_login_states
+		WHERE state = $1
+		RETURNING state, provider, nonce, code_verifier, expires_at;
+		`
+
+	var s core.OIDCLoginState
+	err := i.db.Writer(ctx).QueryRow(ctx, query, state).Scan(&s.State, &s.Provider, &s.Nonce, &s.CodeVerifier, &s.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
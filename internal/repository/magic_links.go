@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+// MagicLinksRepo reads from the primary only, since links are exchanged a moment after they are created
+type MagicLinksRepo struct {
+	db postgres.Router
+}
+
+func NewMagicLinksRepo(db postgres.Router) *MagicLinksRepo {
+	return &MagicLinksRepo{db: db}
+}
+
+func (m *MagicLinksRepo) Insert(ctx context.Context, link *core.MagicLink) error {
+	query := `
+		INSERT INTO public.magic_links
+		    (id, user_id, email, created_at, expires_at, used_at)
+		VALUES
+		    ($1, $2, $3, $4, $5, $6);
+		`
+
+	_, err := m.db.Writer(ctx).Exec(ctx, query, link.Id, link.UserId, link.Email, link.CreatedAt, link.ExpiresAt, link.UsedAt)
+	return err
+}
+
+func (m *MagicLinksRepo) GetById(ctx context.Context, id string) (*core.MagicLink, error) {
+	query := `
+		SELECT id, user_id, email, created_at, expires_at, used_at
+		FROM public.magic_links
+		WHERE id = $1;
+		`
+
+	var link core.MagicLink
+	err := m.db.Writer(ctx).QueryRow(ctx, query, id).Scan(
+		&link.Id,
+		&link.UserId,
+		&link.Email,
+		&link.CreatedAt,
+		&link.ExpiresAt,
+		&link.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (m *MagicLinksRepo) MarkUsed(ctx context.Context, id string, usedAt time.Time) error {
+	query := `
+		UPDATE public.magic_links
+		  SET used_at = $1
+		  WHERE id = $2 AND used_at IS NULL
+		`
+
+	tag, err := m.db.Writer(ctx).Exec(ctx, query, usedAt, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *MagicLinksRepo) CountAttempt(ctx context.Context, email string, now time.Time, window time.Duration) (int, error) {
+	// a window which has ended is restarted by the request
+	query := `
+		INSERT INTO public.magic_link_attempts AS a
+		    (email, window_start, count)
+		VALUES
+		    ($1, $2, 1)
+		ON CONFLICT (email) DO UPDATE
+		  SET (window_start, count) =
+		      (CASE WHEN a.window_start <= $3 THEN EXCLUDED.window_start ELSE a.window_start END,
+		       CASE WHEN a.window_start <= $3 THEN 1 ELSE a.count + 1 END)
+		RETURNING count;
+		`
+
+	var count int
+	err := m.db.Writer(ctx).QueryRow(ctx, query, email, now, now.Add(-window)).Scan(&count)
+	return count, err
+}
+
+func (m *MagicLinksRepo) DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error) {
+	query := `
+		DELETE FROM public.magic_link_attempts
+		WHERE window_start <= $1;
+		`
+
+	tag, err := m.db.Writer(ctx).Exec(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -7,10 +7,12 @@ package mock_repository
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	core "github.com/zhuravlev-pe/course-watch/internal/core"
 	repository "github.com/zhuravlev-pe/course-watch/internal/repository"
+	security "github.com/zhuravlev-pe/course-watch/pkg/security"
 )
 
 // MockCourses is a mock of Courses interface.
@@ -65,6 +67,34 @@ func (mr *MockCoursesMockRecorder) Insert(ctx, course interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockCourses)(nil).Insert), ctx, course)
 }
 
+// Patch mocks base method.
+func (m *MockCourses) Patch(ctx context.Context, id string, input *repository.PatchCourseInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockCoursesMockRecorder) Patch(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockCourses)(nil).Patch), ctx, id, input)
+}
+
+// Update mocks base method.
+func (m *MockCourses) Update(ctx context.Context, id string, input *repository.UpdateCourseInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCoursesMockRecorder) Update(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCourses)(nil).Update), ctx, id, input)
+}
+
 // MockUsers is a mock of Users interface.
 type MockUsers struct {
 	ctrl     *gomock.Controller
@@ -132,6 +162,36 @@ func (mr *MockUsersMockRecorder) Insert(ctx, user interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockUsers)(nil).Insert), ctx, user)
 }
 
+// List mocks base method.
+func (m *MockUsers) List(ctx context.Context, filter *repository.UserFilter, offset, limit int) ([]*core.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter, offset, limit)
+	ret0, _ := ret[0].([]*core.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockUsersMockRecorder) List(ctx, filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUsers)(nil).List), ctx, filter, offset, limit)
+}
+
+// Patch mocks base method.
+func (m *MockUsers) Patch(ctx context.Context, id string, input *repository.PatchUserInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockUsersMockRecorder) Patch(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockUsers)(nil).Patch), ctx, id, input)
+}
+
 // Update mocks base method.
 func (m *MockUsers) Update(ctx context.Context, id string, input *repository.UpdateUserInput) error {
 	m.ctrl.T.Helper()
@@ -145,3 +205,669 @@ func (mr *MockUsersMockRecorder) Update(ctx, id, input interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUsers)(nil).Update), ctx, id, input)
 }
+
+// UpdateAccount mocks base method.
+func (m *MockUsers) UpdateAccount(ctx context.Context, user *core.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccount", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccount indicates an expected call of UpdateAccount.
+func (mr *MockUsersMockRecorder) UpdateAccount(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockUsers)(nil).UpdateAccount), ctx, user)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUsers) UpdatePassword(ctx context.Context, id string, hashedPassword []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, hashedPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUsersMockRecorder) UpdatePassword(ctx, id, hashedPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUsers)(nil).UpdatePassword), ctx, id, hashedPassword)
+}
+
+// UpdateRoles mocks base method.
+func (m *MockUsers) UpdateRoles(ctx context.Context, id string, roles []security.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRoles", ctx, id, roles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRoles indicates an expected call of UpdateRoles.
+func (mr *MockUsersMockRecorder) UpdateRoles(ctx, id, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRoles", reflect.TypeOf((*MockUsers)(nil).UpdateRoles), ctx, id, roles)
+}
+
+// MockSessions is a mock of Sessions interface.
+type MockSessions struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionsMockRecorder
+}
+
+// MockSessionsMockRecorder is the mock recorder for MockSessions.
+type MockSessionsMockRecorder struct {
+	mock *MockSessions
+}
+
+// NewMockSessions creates a new mock instance.
+func NewMockSessions(ctrl *gomock.Controller) *MockSessions {
+	mock := &MockSessions{ctrl: ctrl}
+	mock.recorder = &MockSessionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessions) EXPECT() *MockSessionsMockRecorder {
+	return m.recorder
+}
+
+// GetById mocks base method.
+func (m *MockSessions) GetById(ctx context.Context, id string) (*core.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", ctx, id)
+	ret0, _ := ret[0].(*core.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockSessionsMockRecorder) GetById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockSessions)(nil).GetById), ctx, id)
+}
+
+// Insert mocks base method.
+func (m *MockSessions) Insert(ctx context.Context, session *core.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockSessionsMockRecorder) Insert(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockSessions)(nil).Insert), ctx, session)
+}
+
+// ListActiveByUser mocks base method.
+func (m *MockSessions) ListActiveByUser(ctx context.Context, userId string, now time.Time) ([]*core.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveByUser", ctx, userId, now)
+	ret0, _ := ret[0].([]*core.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveByUser indicates an expected call of ListActiveByUser.
+func (mr *MockSessionsMockRecorder) ListActiveByUser(ctx, userId, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveByUser", reflect.TypeOf((*MockSessions)(nil).ListActiveByUser), ctx, userId, now)
+}
+
+// Revoke mocks base method.
+func (m *MockSessions) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockSessionsMockRecorder) Revoke(ctx, id, revokedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockSessions)(nil).Revoke), ctx, id, revokedAt)
+}
+
+// RevokeAllByUser mocks base method.
+func (m *MockSessions) RevokeAllByUser(ctx context.Context, userId, exceptId string, revokedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllByUser", ctx, userId, exceptId, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllByUser indicates an expected call of RevokeAllByUser.
+func (mr *MockSessionsMockRecorder) RevokeAllByUser(ctx, userId, exceptId, revokedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllByUser", reflect.TypeOf((*MockSessions)(nil).RevokeAllByUser), ctx, userId, exceptId, revokedAt)
+}
+
+// UpdateLastSeen mocks base method.
+func (m *MockSessions) UpdateLastSeen(ctx context.Context, id string, lastSeen time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastSeen", ctx, id, lastSeen)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastSeen indicates an expected call of UpdateLastSeen.
+func (mr *MockSessionsMockRecorder) UpdateLastSeen(ctx, id, lastSeen interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastSeen", reflect.TypeOf((*MockSessions)(nil).UpdateLastSeen), ctx, id, lastSeen)
+}
+
+// MockMagicLinks is a mock of MagicLinks interface.
+type MockMagicLinks struct {
+	ctrl     *gomock.Controller
+	recorder *MockMagicLinksMockRecorder
+}
+
+// MockMagicLinksMockRecorder is the mock recorder for MockMagicLinks.
+type MockMagicLinksMockRecorder struct {
+	mock *MockMagicLinks
+}
+
+// NewMockMagicLinks creates a new mock instance.
+func NewMockMagicLinks(ctrl *gomock.Controller) *MockMagicLinks {
+	mock := &MockMagicLinks{ctrl: ctrl}
+	mock.recorder = &MockMagicLinksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMagicLinks) EXPECT() *MockMagicLinksMockRecorder {
+	return m.recorder
+}
+
+// CountAttempt mocks base method.
+func (m *MockMagicLinks) CountAttempt(ctx context.Context, email string, now time.Time, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAttempt", ctx, email, now, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAttempt indicates an expected call of CountAttempt.
+func (mr *MockMagicLinksMockRecorder) CountAttempt(ctx, email, now, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAttempt", reflect.TypeOf((*MockMagicLinks)(nil).CountAttempt), ctx, email, now, window)
+}
+
+// DeleteAttemptsBefore mocks base method.
+func (m *MockMagicLinks) DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttemptsBefore", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAttemptsBefore indicates an expected call of DeleteAttemptsBefore.
+func (mr *MockMagicLinksMockRecorder) DeleteAttemptsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttemptsBefore", reflect.TypeOf((*MockMagicLinks)(nil).DeleteAttemptsBefore), ctx, before)
+}
+
+// GetById mocks base method.
+func (m *MockMagicLinks) GetById(ctx context.Context, id string) (*core.MagicLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", ctx, id)
+	ret0, _ := ret[0].(*core.MagicLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockMagicLinksMockRecorder) GetById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockMagicLinks)(nil).GetById), ctx, id)
+}
+
+// Insert mocks base method.
+func (m *MockMagicLinks) Insert(ctx context.Context, link *core.MagicLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockMagicLinksMockRecorder) Insert(ctx, link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockMagicLinks)(nil).Insert), ctx, link)
+}
+
+// MarkUsed mocks base method.
+func (m *MockMagicLinks) MarkUsed(ctx context.Context, id string, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUsed", ctx, id, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkUsed indicates an expected call of MarkUsed.
+func (mr *MockMagicLinksMockRecorder) MarkUsed(ctx, id, usedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUsed", reflect.TypeOf((*MockMagicLinks)(nil).MarkUsed), ctx, id, usedAt)
+}
+
+// MockWebAuthn is a mock of WebAuthn interface.
+type MockWebAuthn struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnMockRecorder
+}
+
+// MockWebAuthnMockRecorder is the mock recorder for MockWebAuthn.
+type MockWebAuthnMockRecorder struct {
+	mock *MockWebAuthn
+}
+
+// NewMockWebAuthn creates a new mock instance.
+func NewMockWebAuthn(ctrl *gomock.Controller) *MockWebAuthn {
+	mock := &MockWebAuthn{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthn) EXPECT() *MockWebAuthnMockRecorder {
+	return m.recorder
+}
+
+// InsertCeremony mocks base method.
+func (m *MockWebAuthn) InsertCeremony(ctx context.Context, ceremony *core.WebAuthnCeremony) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertCeremony", ctx, ceremony)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertCeremony indicates an expected call of InsertCeremony.
+func (mr *MockWebAuthnMockRecorder) InsertCeremony(ctx, ceremony interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertCeremony", reflect.TypeOf((*MockWebAuthn)(nil).InsertCeremony), ctx, ceremony)
+}
+
+// InsertCredential mocks base method.
+func (m *MockWebAuthn) InsertCredential(ctx context.Context, credential *core.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertCredential", ctx, credential)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertCredential indicates an expected call of InsertCredential.
+func (mr *MockWebAuthnMockRecorder) InsertCredential(ctx, credential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertCredential", reflect.TypeOf((*MockWebAuthn)(nil).InsertCredential), ctx, credential)
+}
+
+// ListCredentialsByUser mocks base method.
+func (m *MockWebAuthn) ListCredentialsByUser(ctx context.Context, userId string) ([]*core.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCredentialsByUser", ctx, userId)
+	ret0, _ := ret[0].([]*core.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCredentialsByUser indicates an expected call of ListCredentialsByUser.
+func (mr *MockWebAuthnMockRecorder) ListCredentialsByUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCredentialsByUser", reflect.TypeOf((*MockWebAuthn)(nil).ListCredentialsByUser), ctx, userId)
+}
+
+// TakeCeremony mocks base method.
+func (m *MockWebAuthn) TakeCeremony(ctx context.Context, id string) (*core.WebAuthnCeremony, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeCeremony", ctx, id)
+	ret0, _ := ret[0].(*core.WebAuthnCeremony)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeCeremony indicates an expected call of TakeCeremony.
+func (mr *MockWebAuthnMockRecorder) TakeCeremony(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeCeremony", reflect.TypeOf((*MockWebAuthn)(nil).TakeCeremony), ctx, id)
+}
+
+// UpdateCredentialUsage mocks base method.
+func (m *MockWebAuthn) UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCredentialUsage", ctx, id, signCount, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCredentialUsage indicates an expected call of UpdateCredentialUsage.
+func (mr *MockWebAuthnMockRecorder) UpdateCredentialUsage(ctx, id, signCount, usedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCredentialUsage", reflect.TypeOf((*MockWebAuthn)(nil).UpdateCredentialUsage), ctx, id, signCount, usedAt)
+}
+
+// MockIdentities is a mock of Identities interface.
+type MockIdentities struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentitiesMockRecorder
+}
+
+// MockIdentitiesMockRecorder is the mock recorder for MockIdentities.
+type MockIdentitiesMockRecorder struct {
+	mock *MockIdentities
+}
+
+// NewMockIdentities creates a new mock instance.
+func NewMockIdentities(ctrl *gomock.Controller) *MockIdentities {
+	mock := &MockIdentities{ctrl: ctrl}
+	mock.recorder = &MockIdentitiesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentities) EXPECT() *MockIdentitiesMockRecorder {
+	return m.recorder
+}
+
+// GetByProviderSubject mocks base method.
+func (m *MockIdentities) GetByProviderSubject(ctx context.Context, provider, subject string) (*core.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByProviderSubject", ctx, provider, subject)
+	ret0, _ := ret[0].(*core.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByProviderSubject indicates an expected call of GetByProviderSubject.
+func (mr *MockIdentitiesMockRecorder) GetByProviderSubject(ctx, provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByProviderSubject", reflect.TypeOf((*MockIdentities)(nil).GetByProviderSubject), ctx, provider, subject)
+}
+
+// Insert mocks base method.
+func (m *MockIdentities) Insert(ctx context.Context, identity *core.UserIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockIdentitiesMockRecorder) Insert(ctx, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockIdentities)(nil).Insert), ctx, identity)
+}
+
+// InsertLoginState mocks base method.
+func (m *MockIdentities) InsertLoginState(ctx context.Context, state *core.OIDCLoginState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertLoginState", ctx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertLoginState indicates an expected call of InsertLoginState.
+func (mr *MockIdentitiesMockRecorder) InsertLoginState(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertLoginState", reflect.TypeOf((*MockIdentities)(nil).InsertLoginState), ctx, state)
+}
+
+// TakeLoginState mocks base method.
+func (m *MockIdentities) TakeLoginState(ctx context.Context, state string) (*core.OIDCLoginState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeLoginState", ctx, state)
+	ret0, _ := ret[0].(*core.OIDCLoginState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeLoginState indicates an expected call of TakeLoginState.
+func (mr *MockIdentitiesMockRecorder) TakeLoginState(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeLoginState", reflect.TypeOf((*MockIdentities)(nil).TakeLoginState), ctx, state)
+}
+
+// MockOAuth is a mock of OAuth interface.
+type MockOAuth struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthMockRecorder
+}
+
+// MockOAuthMockRecorder is the mock recorder for MockOAuth.
+type MockOAuthMockRecorder struct {
+	mock *MockOAuth
+}
+
+// NewMockOAuth creates a new mock instance.
+func NewMockOAuth(ctrl *gomock.Controller) *MockOAuth {
+	mock := &MockOAuth{ctrl: ctrl}
+	mock.recorder = &MockOAuthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth) EXPECT() *MockOAuthMockRecorder {
+	return m.recorder
+}
+
+// DeleteClient mocks base method.
+func (m *MockOAuth) DeleteClient(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteClient", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockOAuthMockRecorder) DeleteClient(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockOAuth)(nil).DeleteClient), ctx, id)
+}
+
+// GetClient mocks base method.
+func (m *MockOAuth) GetClient(ctx context.Context, id string) (*core.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient", ctx, id)
+	ret0, _ := ret[0].(*core.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockOAuthMockRecorder) GetClient(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockOAuth)(nil).GetClient), ctx, id)
+}
+
+// InsertAuthorizationCode mocks base method.
+func (m *MockOAuth) InsertAuthorizationCode(ctx context.Context, code *core.OAuthAuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertAuthorizationCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertAuthorizationCode indicates an expected call of InsertAuthorizationCode.
+func (mr *MockOAuthMockRecorder) InsertAuthorizationCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertAuthorizationCode", reflect.TypeOf((*MockOAuth)(nil).InsertAuthorizationCode), ctx, code)
+}
+
+// InsertClient mocks base method.
+func (m *MockOAuth) InsertClient(ctx context.Context, client *core.OAuthClient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertClient", ctx, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertClient indicates an expected call of InsertClient.
+func (mr *MockOAuthMockRecorder) InsertClient(ctx, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertClient", reflect.TypeOf((*MockOAuth)(nil).InsertClient), ctx, client)
+}
+
+// ListClients mocks base method.
+func (m *MockOAuth) ListClients(ctx context.Context) ([]*core.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", ctx)
+	ret0, _ := ret[0].([]*core.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockOAuthMockRecorder) ListClients(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockOAuth)(nil).ListClients), ctx)
+}
+
+// TakeAuthorizationCode mocks base method.
+func (m *MockOAuth) TakeAuthorizationCode(ctx context.Context, codeHash string) (*core.OAuthAuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeAuthorizationCode", ctx, codeHash)
+	ret0, _ := ret[0].(*core.OAuthAuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeAuthorizationCode indicates an expected call of TakeAuthorizationCode.
+func (mr *MockOAuthMockRecorder) TakeAuthorizationCode(ctx, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeAuthorizationCode", reflect.TypeOf((*MockOAuth)(nil).TakeAuthorizationCode), ctx, codeHash)
+}
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// WithTx mocks base method.
+func (m *MockTransactor) WithTx(ctx context.Context, fn func(*repository.Repositories) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockTransactorMockRecorder) WithTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockTransactor)(nil).WithTx), ctx, fn)
+}
+
+// MockIdempotencyKeys is a mock of IdempotencyKeys interface.
+type MockIdempotencyKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyKeysMockRecorder
+}
+
+// MockIdempotencyKeysMockRecorder is the mock recorder for MockIdempotencyKeys.
+type MockIdempotencyKeysMockRecorder struct {
+	mock *MockIdempotencyKeys
+}
+
+// NewMockIdempotencyKeys creates a new mock instance.
+func NewMockIdempotencyKeys(ctrl *gomock.Controller) *MockIdempotencyKeys {
+	mock := &MockIdempotencyKeys{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyKeys) EXPECT() *MockIdempotencyKeysMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockIdempotencyKeys) Complete(ctx context.Context, record *core.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIdempotencyKeysMockRecorder) Complete(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotencyKeys)(nil).Complete), ctx, record)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockIdempotencyKeys) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIdempotencyKeysMockRecorder) DeleteExpired(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIdempotencyKeys)(nil).DeleteExpired), ctx, now)
+}
+
+// Release mocks base method.
+func (m *MockIdempotencyKeys) Release(ctx context.Context, userId, route, key, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, userId, route, key, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIdempotencyKeysMockRecorder) Release(ctx, userId, route, key, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIdempotencyKeys)(nil).Release), ctx, userId, route, key, token)
+}
+
+// Reserve mocks base method.
+func (m *MockIdempotencyKeys) Reserve(ctx context.Context, record *core.IdempotencyKey, now time.Time) (*core.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, record, now)
+	ret0, _ := ret[0].(*core.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockIdempotencyKeysMockRecorder) Reserve(ctx, record, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockIdempotencyKeys)(nil).Reserve), ctx, record, now)
+}
+
+// TakeOver mocks base method.
+func (m *MockIdempotencyKeys) TakeOver(ctx context.Context, record *core.IdempotencyKey, token string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeOver", ctx, record, token)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeOver indicates an expected call of TakeOver.
+func (mr *MockIdempotencyKeysMockRecorder) TakeOver(ctx, record, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeOver", reflect.TypeOf((*MockIdempotencyKeys)(nil).TakeOver), ctx, record, token)
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+type OAuthRepo struct {
+	db postgres.Router
+}
+
+func NewOAuthRepo(db postgres.Router) *OAuthRepo {
+	return &OAuthRepo{db: db}
+}
+
+func scanOAuthClient(row pgx.Row) (*core.OAuthClient, error) {
+	var c core.OAuthClient
+	err := row.Scan(&c.Id, &c.Name, &c.HashedSecret, &c.RedirectURIs, &c.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (o *OAuthRepo) GetClient(ctx context.Context, id string) (*core.OAuthClient, error) {
+	query := `
+		SELECT id, name, hashed_secret, redirect_uris, created_at
+		FROM public.oauth_clients
+		WHERE id = $1;
+		`
+
+	return scanOAuthClient(o.db.Reader(ctx).QueryRow(ctx, query, id))
+}
+
+func (o *OAuthRepo) ListClients(ctx context.Context) ([]*core.OAuthClient, error) {
+	query := `
+		SELECT id, name, hashed_secret, redirect_uris, created_at
+		FROM public.oauth_clients
+		ORDER BY created_at;
+		`
+
+	rows, err := o.db.Reader(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*core.OAuthClient, 0)
+	for rows.Next() {
+		c, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+func (o *OAuthRepo) InsertClient(ctx context.Context, c *core.OAuthClient) error {
+	query := `
+		INSERT INTO public.oauth_clients
+		    (id, name, hashed_secret, redirect_uris, created_at)
+		VALUES
+		    ($1, $2, $3, $4, $5);
+		`
+
+	_, err := o.db.Writer(ctx).Exec(ctx, query, c.Id, c.Name, c.HashedSecret, c.RedirectURIs, c.CreatedAt)
+	return err
+}
+
+func (o *OAuthRepo) DeleteClient(ctx context.Context, id string) error {
+	query := `
+		DELETE FROM public.oauth_clients
+		WHERE id = $1;
+		`
+
+	tag, err := o.db.Writer(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (o *OAuthRepo) InsertAuthorizationCode(ctx context.Context, c *core.OAuthAuthorizationCode) error {
+	query := `
+		INSERT INTO public.oauth_authorization_codes
+		    (code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, expires_at)
+		VALUES
+		    ($1, $2, $3, $4, $5, $6, $7, $8);
+		`
+
+	_, err := o.db.Writer(ctx).Exec(ctx, query, c.CodeHash, c.ClientId, c.UserId, c.RedirectURI, c.Scope, c.Nonce,
+		c.CodeChallenge, c.ExpiresAt)
+	return err
+}
+
+func (o *OAuthRepo) TakeAuthorizationCode(ctx context.Context, codeHash string) (*core.OAuthAuthorizationCode, error) {
+	query := `
+		DELETE FROM public.oauth_authorization_codes
+		WHERE code_hash = $1
+		RETURNING code_hash, client_id, user_id, redirect_uri, scope, nonce, code_challenge, expires_at;
+		`
+
+	var c core.OAuthAuthorizationCode
+	err := o.db.Writer(ctx).QueryRow(ctx, query, codeHash).Scan(&c.CodeHash, &c.ClientId, &c.UserId, &c.RedirectURI,
+		&c.Scope, &c.Nonce, &c.CodeChallenge, &c.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
@@ -4,29 +4,169 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/patch"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
 )
 
+type UpdateCourseInput struct {
+	Title       string
+	Description string
+	// ExpectedVersion makes the update fail with ErrVersionConflict unless the course has this version. Zero
+	// updates any version
+	ExpectedVersion int64
+}
+
 type Courses interface {
 	GetById(ctx context.Context, id string) (*core.Course, error)
 	Insert(ctx context.Context, course *core.Course) error
+	// Update changes the course and increments its version. Returns ErrNotFound if the course does not exist
+	Update(ctx context.Context, id string, input *UpdateCourseInput) error
+	// Patch changes only the fields which are set in input, a null description clears it. Returns ErrNotFound if the
+	// course does not exist
+	Patch(ctx context.Context, id string, input *PatchCourseInput) error
+}
+
+type PatchCourseInput struct {
+	Title           patch.Field[string]
+	Description     patch.Field[string]
+	ExpectedVersion int64
 }
 
 type UpdateUserInput struct {
 	FirstName   string
 	LastName    string
 	DisplayName string
+	// ExpectedVersion makes the update fail with ErrVersionConflict unless the user has this version. Zero updates
+	// any version
+	ExpectedVersion int64
+}
+
+type PatchUserInput struct {
+	FirstName   patch.Field[string]
+	LastName    patch.Field[string]
+	DisplayName patch.Field[string]
+	// ExpectedVersion works as in UpdateUserInput
+	ExpectedVersion int64
+}
+
+// UserFilter selects users by exact match on the non-nil fields
+type UserFilter struct {
+	Email       *string
+	ExternalId  *string
+	FirstName   *string
+	LastName    *string
+	DisplayName *string
+	Disabled    *bool
 }
 
 type Users interface {
 	GetById(ctx context.Context, id string) (*core.User, error)
 	Insert(ctx context.Context, user *core.User) error
+	// Update changes the names of the user. Like all other updates, it increments the version of the user. Returns
+	// ErrNotFound if the user does not exist
 	Update(ctx context.Context, id string, input *UpdateUserInput) error
+	// Patch changes only the names which are set in input, a null display name removes it. Returns ErrNotFound if the
+	// user does not exist. A patch without names changes nothing, not even the version
+	Patch(ctx context.Context, id string, input *PatchUserInput) error
 	GetByEmail(ctx context.Context, email string) (*core.User, error)
+	// UpdateAccount stores the email, names, external id and disabled flag of the user. Returns ErrNotFound if
+	// the user does not exist
+	UpdateAccount(ctx context.Context, user *core.User) error
+	// UpdatePassword replaces the password hash of the user. Returns ErrNotFound if the user does not exist
+	UpdatePassword(ctx context.Context, id string, hashedPassword []byte) error
+	// UpdateRoles replaces the roles of the user. Returns ErrNotFound if the user does not exist
+	UpdateRoles(ctx context.Context, id string, roles []security.Role) error
+	// List returns a page of users matching the filter, ordered by registration date, and the total number of matches
+	List(ctx context.Context, filter *UserFilter, offset int, limit int) ([]*core.User, int, error)
+}
+
+type Sessions interface {
+	GetById(ctx context.Context, id string) (*core.Session, error)
+	Insert(ctx context.Context, session *core.Session) error
+	ListActiveByUser(ctx context.Context, userId string, now time.Time) ([]*core.Session, error)
+	UpdateLastSeen(ctx context.Context, id string, lastSeen time.Time) error
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	// RevokeAllByUser revokes all active sessions of the user except the one with exceptId (may be empty)
+	RevokeAllByUser(ctx context.Context, userId string, exceptId string, revokedAt time.Time) error
+}
+
+type MagicLinks interface {
+	GetById(ctx context.Context, id string) (*core.MagicLink, error)
+	Insert(ctx context.Context, link *core.MagicLink) error
+	// MarkUsed marks an unused link as used. Returns ErrNotFound if the link does not exist or has already been used
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) error
+	// CountAttempt records a request for a link to the address, registered or not, and returns the number of requests
+	// in the current window, including this one. A window lasts for the given duration from its first request
+	CountAttempt(ctx context.Context, email string, now time.Time, window time.Duration) (int, error)
+	// DeleteAttemptsBefore forgets the windows which started at or before the given time and returns their number
+	DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type WebAuthn interface {
+	ListCredentialsByUser(ctx context.Context, userId string) ([]*core.WebAuthnCredential, error)
+	InsertCredential(ctx context.Context, credential *core.WebAuthnCredential) error
+	UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, usedAt time.Time) error
+	InsertCeremony(ctx context.Context, ceremony *core.WebAuthnCeremony) error
+	// TakeCeremony deletes the ceremony and returns it, so that each ceremony can only be finished once
+	TakeCeremony(ctx context.Context, id string) (*core.WebAuthnCeremony, error)
+}
+
+type Identities interface {
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*core.UserIdentity, error)
+	Insert(ctx context.Context, identity *core.UserIdentity) error
+	InsertLoginState(ctx context.Context, state *core.OIDCLoginState) error
+	// TakeLoginState deletes the login state and returns it, so that each state can only be used once
+	TakeLoginState(ctx context.Context, state string) (*core.OIDCLoginState, error)
+}
+
+type OAuth interface {
+	GetClient(ctx context.Context, id string) (*core.OAuthClient, error)
+	ListClients(ctx context.Context) ([]*core.OAuthClient, error)
+	InsertClient(ctx context.Context, client *core.OAuthClient) error
+	// DeleteClient returns ErrNotFound if the client does not exist
+	DeleteClient(ctx context.Context, id string) error
+	InsertAuthorizationCode(ctx context.Context, code *core.OAuthAuthorizationCode) error
+	// TakeAuthorizationCode deletes the code and returns it, so that each code can only be redeemed once
+	TakeAuthorizationCode(ctx context.Context, codeHash string) (*core.OAuthAuthorizationCode, error)
+}
+
+// Transactor makes changes to several repositories atomic
+type Transactor interface {
+	// WithTx runs fn with repositories whose changes are committed together when fn returns nil, and rolled back
+	// otherwise. fn is run again when the transaction conflicts with a concurrent one, so it must not have effects
+	// outside the repositories. Calling WithTx of the repositories passed to fn creates a savepoint: if the nested fn
+	// fails, only its changes are rolled back
+	WithTx(ctx context.Context, fn func(repos *Repositories) error) error
+}
+
+type IdempotencyKeys interface {
+	// Reserve stores the record of a request which is about to be processed, unless an unexpired record with the
+	// same user, route and key exists. Returns nil after storing, and the existing record otherwise
+	Reserve(ctx context.Context, record *core.IdempotencyKey, now time.Time) (*core.IdempotencyKey, error)
+	// TakeOver replaces an incomplete record which still has the given token, i.e. which no other request completed,
+	// released or took over since it was read. Returns false if the record has changed
+	TakeOver(ctx context.Context, record *core.IdempotencyKey, token string) (bool, error)
+	// Complete stores the response of a reserved record with the token of the given one. Returns ErrNotFound if the
+	// record does not exist or has been taken over
+	Complete(ctx context.Context, record *core.IdempotencyKey) error
+	// Release deletes the record if it still has the token, so that the request with the key is processed again
+	Release(ctx context.Context, userId string, route string, key string, token string) error
+	// DeleteExpired deletes the records which have expired by now and returns their number
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
 }
 
 type Repositories struct {
-	Courses Courses
-	Users   Users
+	Courses    Courses
+	Users      Users
+	Sessions   Sessions
+	MagicLinks MagicLinks
+	WebAuthn   WebAuthn
+	Identities Identities
+	OAuth      OAuth
+	Transactor Transactor
+	// IdempotencyKeys are kept out of transactions: a record must outlive the unit of work of its request
+	IdempotencyKeys IdempotencyKeys
 }
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+// SessionsRepo reads from the primary only: sessions are checked on every request, and a revoked session must not
+// be accepted by a lagging replica
+type SessionsRepo struct {
+	db postgres.Router
+}
+
+func NewSessionsRepo(db postgres.Router) *SessionsRepo {
+	return &SessionsRepo{db: db}
+}
+
+const sessionColumns = `id, user_id, device_label, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at`
+
+func (s *SessionsRepo) Insert(ctx context.Context, session *core.Session) error {
+	query := `
+		INSERT INTO public.sessions
+		    (` + sessionColumns + `)
+		VALUES
+		    ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+		`
+
+	_, err := s.db.Writer(ctx).Exec(ctx, query, session.Id, session.UserId, session.DeviceLabel, session.IP,
+		session.UserAgent, session.CreatedAt, session.LastSeenAt, session.ExpiresAt, session.RevokedAt)
+
+	return err
+}
+
+func (s *SessionsRepo) GetById(ctx context.Context, id string) (*core.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM public.sessions
+		WHERE id = $1;
+		`
+
+	session, err := scanSession(s.db.Writer(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *SessionsRepo) ListActiveByUser(ctx context.Context, userId string, now time.Time) ([]*core.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM public.sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC;
+		`
+
+	rows, err := s.db.Writer(ctx).Query(ctx, query, userId, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*core.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, session)
+	}
+	return result, rows.Err()
+}
+
+func (s *SessionsRepo) UpdateLastSeen(ctx context.Context, id string, lastSeen time.Time) error {
+	query := `
+		UPDATE public.sessions
+		  SET last_seen_at = $1
+		  WHERE id = $2
+		`
+
+	_, err := s.db.Writer(ctx).Exec(ctx, query, lastSeen, id)
+	return err
+}
+
+func (s *SessionsRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	query := `
+		UPDATE public.sessions
+		  SET revoked_at = $1
+		  WHERE id = $2 AND revoked_at IS NULL
+		`
+
+	tag, err := s.db.Writer(ctx).Exec(ctx, query, revokedAt, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SessionsRepo) RevokeAllByUser(ctx context.Context, userId string, exceptId string, revokedAt time.Time) error {
+	query := `
+		UPDATE public.sessions
+		  SET revoked_at = $1
+		  WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL
+		`
+
+	_, err := s.db.Writer(ctx).Exec(ctx, query, revokedAt, userId, exceptId)
+	return err
+}
+
+func scanSession(row pgx.Row) (*core.Session, error) {
+	var session core.Session
+	err := row.Scan(
+		&session.Id,
+		&session.UserId,
+		&session.DeviceLabel,
+		&session.IP,
+		&session.UserAgent,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+// TransactionsRepo runs units of work in Postgres transactions on the primary. Repositories without a Postgres
+// implementation, e.g. Courses, are passed to the units of work as they are and do not take part in the transactions
+type TransactionsRepo struct {
+	db   *postgres.Cluster
+	base *Repositories
+	opts postgres.TxOptions
+	// tx is the transaction of the unit of work this transactor was passed to. Nested units of work use savepoints
+	tx pgx.Tx
+}
+
+func NewTransactionsRepo(db *postgres.Cluster, base *Repositories, opts postgres.TxOptions) *TransactionsRepo {
+	return &TransactionsRepo{db: db, base: base, opts: opts}
+}
+
+func (t *TransactionsRepo) WithTx(ctx context.Context, fn func(repos *Repositories) error) error {
+	run := func(tx pgx.Tx) error {
+		return fn(t.repositories(tx))
+	}
+	if t.tx != nil {
+		return postgres.RunInSavepoint(ctx, t.tx, run)
+	}
+	t.db.NoteWrite(ctx)
+	return postgres.RunInTx(ctx, t.db.Primary(), t.opts, run)
+}
+
+func (t *TransactionsRepo) repositories(tx pgx.Tx) *Repositories {
+	db := postgres.TxRouter(tx)
+	repos := *t.base
+	repos.Users = NewUsersRepo(db)
+	repos.Sessions = NewSessionsRepo(db)
+	repos.MagicLinks = NewMagicLinksRepo(db)
+	repos.WebAuthn = NewWebAuthnRepo(db)
+	repos.Identities = NewIdentitiesRepo(db)
+	repos.OAuth = NewOAuthRepo(db)
+	repos.IdempotencyKeys = NewIdempotencyKeysRepo(db)
+	repos.Transactor = &TransactionsRepo{db: t.db, base: t.base, opts: t.opts, tx: tx}
+	return &repos
+}
@@ -3,31 +3,34 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/patch"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
+	"strings"
 )
 
 type UsersRepo struct {
-	client *pgxpool.Pool
+	db postgres.Router
 }
 
-func NewUsersRepo(client *pgxpool.Pool) *UsersRepo {
-	return &UsersRepo{client: client}
+func NewUsersRepo(db postgres.Router) *UsersRepo {
+	return &UsersRepo{db: db}
 }
 
 func (u *UsersRepo) Insert(ctx context.Context, user *core.User) error {
 	query := `
 		INSERT INTO public.users
 		    (id, email, firstname, lastname, display_name,
-		     registration_date, hashed_password, roles)
+		     registration_date, hashed_password, roles, disabled, external_id)
 		VALUES
-		    ($1, $2, $3, $4, $5, $6, $7, $8);
+		    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 		`
 	
-	_, err := u.client.Exec(ctx, query, user.Id, user.Email, user.FirstName, user.LastName,
-		user.DisplayName, user.RegistrationDate, user.HashedPassword, user.Roles)
+	_, err := u.db.Writer(ctx).Exec(ctx, query, user.Id, user.Email, user.FirstName, user.LastName,
+		user.DisplayName, user.RegistrationDate, user.HashedPassword, user.Roles, user.Disabled, user.ExternalId)
 	
 	return err
 }
@@ -35,21 +38,88 @@ func (u *UsersRepo) Insert(ctx context.Context, user *core.User) error {
 func (u *UsersRepo) Update(ctx context.Context, id string, input *UpdateUserInput) error {
 	query := `
 		UPDATE public.users
-		  SET (firstname, lastname, display_name) = ($1, $2, $3)
-          WHERE id = $4
+		  SET (firstname, lastname, display_name, version) = ($1, $2, $3, version + 1)
+          WHERE id = $4 AND ($5::bigint = 0 OR version = $5)
 		`
 	
-	_, err := u.client.Exec(ctx, query, input.FirstName, input.LastName, input.DisplayName, id)
+	tag, err := u.db.Writer(ctx).Exec(ctx, query, input.FirstName, input.LastName, input.DisplayName, id,
+		input.ExpectedVersion)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return u.missingOrConflict(ctx, id)
+	}
 	return nil
 }
 
+func (u *UsersRepo) Patch(ctx context.Context, id string, input *PatchUserInput) error {
+	var columns []string
+	var args []interface{}
+	for _, field := range []struct {
+		column string
+		value  patch.Field[string]
+	}{
+		{"firstname", input.FirstName},
+		{"lastname", input.LastName},
+		{"display_name", input.DisplayName},
+	} {
+		if field.value.Set {
+			// Ptr is nil for null members, which stores NULL
+			args = append(args, field.value.Ptr())
+			columns = append(columns, fmt.Sprintf("%s = $%d", field.column, len(args)))
+		}
+	}
+	
+	if len(columns) == 0 {
+		version, err := u.currentVersion(ctx, id)
+		if err != nil {
+			return err
+		}
+		if input.ExpectedVersion != 0 && input.ExpectedVersion != version {
+			return ErrVersionConflict
+		}
+		return nil
+	}
+	
+	args = append(args, id, input.ExpectedVersion)
+	query := fmt.Sprintf(`
+		UPDATE public.users
+		  SET %s, version = version + 1
+		  WHERE id = $%d AND ($%d::bigint = 0 OR version = $%d)
+		`, strings.Join(columns, ", "), len(args)-1, len(args), len(args))
+	
+	tag, err := u.db.Writer(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return u.missingOrConflict(ctx, id)
+	}
+	return nil
+}
+
+// missingOrConflict tells why a conditional update did not change the user
+func (u *UsersRepo) missingOrConflict(ctx context.Context, id string) error {
+	if _, err := u.currentVersion(ctx, id); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+func (u *UsersRepo) currentVersion(ctx context.Context, id string) (int64, error) {
+	var version int64
+	err := u.db.Writer(ctx).QueryRow(ctx, "SELECT version FROM public.users WHERE id = $1", id).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return version, err
+}
+
 func (u *UsersRepo) GetById(ctx context.Context, id string) (*core.User, error) {
 	query := `
 		SELECT id, email, firstname, lastname, display_name,
-		       registration_date, hashed_password, roles
+		       registration_date, hashed_password, roles, disabled, external_id, version
 		FROM public.users
 		WHERE id = $1;
 		`
@@ -60,7 +130,7 @@ func (u *UsersRepo) GetById(ctx context.Context, id string) (*core.User, error)
 func (u *UsersRepo) GetByEmail(ctx context.Context, email string) (*core.User, error) {
 	query := `
 		SELECT id, email, firstname, lastname, display_name,
-		       registration_date, hashed_password, roles
+		       registration_date, hashed_password, roles, disabled, external_id, version
 		FROM public.users
 		WHERE email = $1
 		`
@@ -69,19 +139,27 @@ func (u *UsersRepo) GetByEmail(ctx context.Context, email string) (*core.User, e
 }
 
 func (u *UsersRepo) getByField(ctx context.Context, query string, field string) (*core.User, error) {
+	return scanUser(u.db.Reader(ctx).QueryRow(ctx, query, field))
+}
+
+func scanUser(row pgx.Row) (*core.User, error) {
 	var user core.User
 	var r []uint8
+	var displayName *string
 	
 	//TODO to think of a better way of scanning/storing []Role
-	err := u.client.QueryRow(ctx, query, field).Scan(
+	err := row.Scan(
 		&user.Id,
 		&user.Email,
 		&user.FirstName,
 		&user.LastName,
-		&user.DisplayName,
+		&displayName,
 		&user.RegistrationDate,
 		&user.HashedPassword,
 		&r,
+		&user.Disabled,
+		&user.ExternalId,
+		&user.Version,
 	)
 	
 	if err != nil {
@@ -91,7 +169,128 @@ func (u *UsersRepo) getByField(ctx context.Context, query string, field string)
 		return nil, err
 	}
 	
+	if displayName != nil {
+		user.DisplayName = *displayName
+	}
 	user.Roles = security.ToRoles(r)
 	
 	return &user, nil
 }
+
+func (u *UsersRepo) UpdateAccount(ctx context.Context, user *core.User) error {
+	query := `
+		UPDATE public.users
+		  SET (email, firstname, lastname, display_name, external_id, disabled, version) =
+		      ($1, $2, $3, $4, $5, $6, version + 1)
+		  WHERE id = $7
+		`
+	
+	tag, err := u.db.Writer(ctx).Exec(ctx, query, user.Email, user.FirstName, user.LastName, user.DisplayName,
+		user.ExternalId, user.Disabled, user.Id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (u *UsersRepo) UpdatePassword(ctx context.Context, id string, hashedPassword []byte) error {
+	query := `
+		UPDATE public.users
+		  SET (hashed_password, version) = ($1, version + 1)
+		  WHERE id = $2
+		`
+	
+	tag, err := u.db.Writer(ctx).Exec(ctx, query, hashedPassword, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (u *UsersRepo) UpdateRoles(ctx context.Context, id string, roles []security.Role) error {
+	query := `
+		UPDATE public.users
+		  SET (roles, version) = ($1, version + 1)
+		  WHERE id = $2
+		`
+	
+	tag, err := u.db.Writer(ctx).Exec(ctx, query, roles, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (u *UsersRepo) List(ctx context.Context, filter *UserFilter, offset int, limit int) ([]*core.User, int, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if filter.Email != nil {
+		addCondition("email", *filter.Email)
+	}
+	if filter.ExternalId != nil {
+		addCondition("external_id", *filter.ExternalId)
+	}
+	if filter.FirstName != nil {
+		addCondition("firstname", *filter.FirstName)
+	}
+	if filter.LastName != nil {
+		addCondition("lastname", *filter.LastName)
+	}
+	if filter.DisplayName != nil {
+		addCondition("display_name", *filter.DisplayName)
+	}
+	if filter.Disabled != nil {
+		addCondition("disabled", *filter.Disabled)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	
+	// both statements must see the same replica
+	db := u.db.Reader(ctx)
+	var total int
+	countQuery := "SELECT count(*) FROM public.users " + where
+	if err := db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT id, email, firstname, lastname, display_name,
+		       registration_date, hashed_password, roles, disabled, external_id, version
+		FROM public.users
+		%s
+		ORDER BY registration_date, id
+		LIMIT $%d OFFSET $%d;
+		`, where, len(args)-1, len(args))
+	
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	
+	result := make([]*core.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, user)
+	}
+	return result, total, rows.Err()
+}
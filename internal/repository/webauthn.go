@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/postgres"
+)
+
+// WebAuthnRepo reads from the primary only, since a stale signature counter would look like a cloned authenticator
+type WebAuthnRepo struct {
+	db postgres.Router
+}
+
+func NewWebAuthnRepo(db postgres.Router) *WebAuthnRepo {
+	return &WebAuthnRepo{db: db}
+}
+
+func (w *WebAuthnRepo) ListCredentialsByUser(ctx context.Context, userId string) ([]*core.WebAuthnCredential, error) {
+	query := `
+		SELECT id, user_id, public_key, attestation_type, aaguid,
+		       sign_count, transports, created_at, last_used_at
+		FROM public.webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY created_at;
+		`
+
+	rows, err := w.db.Writer(ctx).Query(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*core.WebAuthnCredential, 0)
+	for rows.Next() {
+		var c core.WebAuthnCredential
+		var signCount int64
+		err = rows.Scan(
+			&c.Id,
+			&c.UserId,
+			&c.PublicKey,
+			&c.AttestationType,
+			&c.AAGUID,
+			&signCount,
+			&c.Transports,
+			&c.CreatedAt,
+			&c.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.SignCount = uint32(signCount)
+		result = append(result, &c)
+	}
+	return result, rows.Err()
+}
+
+func (w *WebAuthnRepo) InsertCredential(ctx context.Context, c *core.WebAuthnCredential) error {
+	query := `
+		INSERT INTO public.webauthn_credentials
+		    (id, user_id, public_key, attestation_type, aaguid,
+		     sign_count, transports, created_at, last_used_at)
+		VALUES
+		    ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+		`
+
+	_, err := w.db.Writer(ctx).Exec(ctx, query, c.Id, c.UserId, c.PublicKey, c.AttestationType, c.AAGUID,
+		int64(c.SignCount), c.Transports, c.CreatedAt, c.LastUsedAt)
+	return err
+}
+
+func (w *WebAuthnRepo) UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, usedAt time.Time) error {
+	query := `
+		UPDATE public.webauthn_credentials
+		  SET (sign_count, last_used_at) = ($1, $2)
+		  WHERE id = $3
+		`
+
+	_, err := w.db.Writer(ctx).Exec(ctx, query, int64(signCount), usedAt, id)
+	return err
+}
+
+func (w *WebAuthnRepo) InsertCeremony(ctx context.Context, c *core.WebAuthnCeremony) error {
+	query := `
+		INSERT INTO public.webauthn_ceremonies
+		    (id, user_id, kind, data, expires_at)
+		VALUES
+		    ($1, $2, $3, $4, $5);
+		`
+
+	_, err := w.db.Writer(ctx).Exec(ctx, query, c.Id, c.UserId, string(c.Kind), c.Data, c.ExpiresAt)
+	return err
+}
+
+func (w *WebAuthnRepo) TakeCeremony(ctx context.Context, id string) (*core.WebAuthnCeremony, error) {
+	query := `
+		DELETE FROM public.webauthn_ceremonies
+		WHERE id = $1
+		RETURNING id, user_id, kind, data, expires_at;
+		`
+
+	var c core.WebAuthnCeremony
+	var kind string
+	err := w.db.Writer(ctx).QueryRow(ctx, query, id).Scan(&c.Id, &c.UserId, &kind, &c.Data, &c.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	c.Kind = core.WebAuthnCeremonyKind(kind)
+	return &c, nil
+}
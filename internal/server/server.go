@@ -2,12 +2,18 @@ package server
 
 import (
 	"context"
+	"errors"
 	"github.com/zhuravlev-pe/course-watch/internal/config"
+	"net"
 	"net/http"
+	"time"
 )
 
 type Server struct {
 	httpServer *http.Server
+	// onShutdown is called when the shutdown starts, readinessDelay before the server stops accepting connections
+	onShutdown     []func()
+	readinessDelay time.Duration
 }
 
 func NewServer(cfg *config.Config, handler http.Handler) *Server {
@@ -19,6 +25,7 @@ func NewServer(cfg *config.Config, handler http.Handler) *Server {
 			WriteTimeout:   cfg.HTTP.WriteTimeout,
 			MaxHeaderBytes: cfg.HTTP.MaxHeaderMegabytes << 20,
 		},
+		readinessDelay: cfg.HTTP.ShutdownReadinessDelay,
 	}
 }
 
@@ -29,3 +36,54 @@ func (s *Server) Run() error {
 func (s *Server) Stop(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
+
+// OnShutdown registers a function to call when RunUntil starts the shutdown, e.g. to report the server as not ready.
+// The server keeps accepting connections for the readiness delay after that, so that load balancers notice first
+func (s *Server) OnShutdown(f func()) {
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+// RunUntil serves until ctx is done, then calls the OnShutdown functions and keeps serving for the readiness delay.
+// Then it stops accepting connections and waits up to drainTimeout for the requests in flight. Returns nil after a
+// clean shutdown, and the drain error, e.g. context.DeadlineExceeded, otherwise
+func (s *Server) RunUntil(ctx context.Context, drainTimeout time.Duration) error {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.serveUntil(ctx, ln, drainTimeout)
+}
+
+func (s *Server) serveUntil(ctx context.Context, ln net.Listener, drainTimeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	for _, f := range s.onShutdown {
+		f()
+	}
+	select {
+	case err := <-serveErr:
+		return err
+	case <-time.After(s.readinessDelay):
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := s.Stop(drainCtx); err != nil {
+		// connections still open after the timeout are dropped
+		_ = s.httpServer.Close()
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/config"
+)
+
+func TestServer_RunUntil(t *testing.T) {
+	cases := map[string]struct {
+		requestDuration time.Duration
+		drainTimeout    time.Duration
+		// whether the request in flight gets its response
+		completed bool
+		err       error
+	}{
+		"drained": {
+			requestDuration: 100 * time.Millisecond,
+			drainTimeout:    5 * time.Second,
+			completed:       true,
+		},
+		"drain_timeout": {
+			requestDuration: 5 * time.Second,
+			drainTimeout:    100 * time.Millisecond,
+			completed:       false,
+			err:             context.DeadlineExceeded,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			started := make(chan struct{})
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tc.requestDuration):
+				case <-r.Context().Done():
+					return
+				}
+				_, _ = io.WriteString(w, "done")
+			})
+			srv := NewServer(&config.Config{}, handler)
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			url := "http://" + ln.Addr().String()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stopped := make(chan error, 1)
+			go func() {
+				stopped <- srv.serveUntil(ctx, ln, tc.drainTimeout)
+			}()
+
+			type result struct {
+				body string
+				err  error
+			}
+			inFlight := make(chan result, 1)
+			go func() {
+				resp, err := http.Get(url)
+				if err != nil {
+					inFlight <- result{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				inFlight <- result{body: string(body), err: err}
+			}()
+			<-started
+			cancel()
+
+			select {
+			case err = <-stopped:
+				assert.ErrorIs(t, err, tc.err)
+			case <-time.After(3 * time.Second):
+				t.Fatal("server did not stop")
+			}
+			res := <-inFlight
+			if tc.completed {
+				require.NoError(t, res.err)
+				assert.Equal(t, "done", res.body)
+			} else {
+				assert.Error(t, res.err)
+			}
+
+			// no new connections are accepted after the shutdown
+			_, err = net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestServer_RunUntil_ReadinessDelay(t *testing.T) {
+	srv := NewServer(&config.Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	srv.readinessDelay = 300 * time.Millisecond
+	notReady := make(chan time.Time, 1)
+	srv.OnShutdown(func() {
+		notReady <- time.Now()
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- srv.serveUntil(ctx, ln, time.Second)
+	}()
+	cancel()
+	shutdownStarted := <-notReady
+
+	// the server still accepts requests after reporting not ready
+	resp, err := http.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+
+	select {
+	case err = <-stopped:
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(shutdownStarted), srv.readinessDelay)
+	case <-time.After(3 * time.Second):
+		t.Fatal("server did not stop")
+	}
+	_, err = net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+	assert.Error(t, err)
+}
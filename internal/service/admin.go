@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/password"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+type adminService struct {
+	users    repository.Users
+	sessions repository.Sessions
+	tx       repository.Transactor
+	idGen    *idgen.IdGen
+	hasher   password.Hasher
+	now      func() time.Time
+}
+
+func newAdminService(
+	users repository.Users,
+	sessions repository.Sessions,
+	tx repository.Transactor,
+	idGen *idgen.IdGen,
+	hasher password.Hasher,
+) Admin {
+	return &adminService{
+		users:    users,
+		sessions: sessions,
+		tx:       tx,
+		idGen:    idGen,
+		hasher:   hasher,
+		now:      time.Now,
+	}
+}
+
+func toAdminUser(user *core.User) *AdminUserOutput {
+	return &AdminUserOutput{
+		Id:               user.Id,
+		Email:            user.Email,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		DisplayName:      user.DisplayName,
+		RegistrationDate: user.RegistrationDate,
+		Roles:            user.Roles,
+		Disabled:         user.Disabled,
+	}
+}
+
+func (i *AdminCreateUserInput) Validate() error {
+	return validation.ValidateStruct(i,
+		validation.Field(&i.Email, validation.Required, is.EmailFormat),
+		validation.Field(&i.Password, validation.Required),
+		validation.Field(&i.FirstName, validation.Required),
+		validation.Field(&i.LastName, validation.Required),
+		validation.Field(&i.Roles, validation.Required, validation.Each(validation.By(validateRole))),
+	)
+}
+
+func validateRole(value interface{}) error {
+	role, _ := value.(security.Role)
+	return role.Valid()
+}
+
+func (s *adminService) CreateUser(ctx context.Context, input *AdminCreateUserInput) (*AdminUserOutput, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	_, err := s.users.GetByEmail(ctx, input.Email)
+	if err == nil {
+		return nil, ErrUserAlreadyExist
+	}
+	if err != repository.ErrNotFound {
+		return nil, err
+	}
+	hash, err := s.hasher.Hash(input.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &core.User{
+		Id:               s.idGen.Generate(),
+		Email:            input.Email,
+		FirstName:        input.FirstName,
+		LastName:         input.LastName,
+		DisplayName:      input.DisplayName,
+		RegistrationDate: s.now(),
+		HashedPassword:   hash,
+		Roles:            uniqueRoles(input.Roles),
+	}
+	if err = s.users.Insert(ctx, user); err != nil {
+		return nil, err
+	}
+	return toAdminUser(user), nil
+}
+
+func (s *adminService) GrantRole(ctx context.Context, ref string, role security.Role) (*AdminUserOutput, error) {
+	if err := role.Valid(); err != nil {
+		return nil, err
+	}
+	user, err := s.find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if hasRole(user.Roles, role) {
+		return toAdminUser(user), nil
+	}
+	return s.updateRoles(ctx, user, append(append([]security.Role(nil), user.Roles...), role))
+}
+
+func (s *adminService) RevokeRole(ctx context.Context, ref string, role security.Role) (*AdminUserOutput, error) {
+	if err := role.Valid(); err != nil {
+		return nil, err
+	}
+	user, err := s.find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !hasRole(user.Roles, role) {
+		return toAdminUser(user), nil
+	}
+	roles := make([]security.Role, 0, len(user.Roles))
+	for _, r := range user.Roles {
+		if r != role {
+			roles = append(roles, r)
+		}
+	}
+	return s.updateRoles(ctx, user, roles)
+}
+
+// updateRoles also revokes the sessions of the user, since their access tokens carry the old roles
+func (s *adminService) updateRoles(ctx context.Context, user *core.User, roles []security.Role) (*AdminUserOutput, error) {
+	err := s.tx.WithTx(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Users.UpdateRoles(ctx, user.Id, roles); err != nil {
+			return err
+		}
+		return repos.Sessions.RevokeAllByUser(ctx, user.Id, "", s.now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated := *user
+	updated.Roles = roles
+	return toAdminUser(&updated), nil
+}
+
+func (s *adminService) ResetPassword(ctx context.Context, ref string, plainPassword string) error {
+	if err := validation.Validate(plainPassword, validation.Required); err != nil {
+		return validation.Errors{"password": err}
+	}
+	user, err := s.find(ctx, ref)
+	if err != nil {
+		return err
+	}
+	hash, err := s.hasher.Hash(plainPassword)
+	if err != nil {
+		return err
+	}
+	// the sessions logged in with the old password must not survive the reset
+	return s.tx.WithTx(ctx, func(repos *repository.Repositories) error {
+		if err := repos.Users.UpdatePassword(ctx, user.Id, hash); err != nil {
+			return err
+		}
+		return repos.Sessions.RevokeAllByUser(ctx, user.Id, "", s.now())
+	})
+}
+
+func (s *adminService) DisableUser(ctx context.Context, ref string) (*AdminUserOutput, error) {
+	user, err := s.find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	updated := *user
+	err = s.tx.WithTx(ctx, func(repos *repository.Repositories) error {
+		if !user.Disabled {
+			updated.Disabled = true
+			if err := repos.Users.UpdateAccount(ctx, &updated); err != nil {
+				return err
+			}
+		}
+		return repos.Sessions.RevokeAllByUser(ctx, user.Id, "", s.now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAdminUser(&updated), nil
+}
+
+func (s *adminService) ListUsers(ctx context.Context, offset int, limit int) (*AdminUserList, error) {
+	if offset < 0 || limit < 1 {
+		return nil, errors.New("offset must not be negative and limit must be positive")
+	}
+	users, total, err := s.users.List(ctx, &repository.UserFilter{}, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := &AdminUserList{Total: total, Users: make([]*AdminUserOutput, 0, len(users))}
+	for _, user := range users {
+		result.Users = append(result.Users, toAdminUser(user))
+	}
+	return result, nil
+}
+
+// find looks the user up by email address if ref contains @, and by id otherwise
+func (s *adminService) find(ctx context.Context, ref string) (*core.User, error) {
+	if strings.Contains(ref, "@") {
+		return s.users.GetByEmail(ctx, ref)
+	}
+	return s.users.GetById(ctx, ref)
+}
+
+func hasRole(roles []security.Role, role security.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueRoles(roles []security.Role) []security.Role {
+	result := make([]security.Role, 0, len(roles))
+	for _, role := range roles {
+		if !hasRole(result, role) {
+			result = append(result, role)
+		}
+	}
+	return result
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+func getAdminService(t *testing.T) (*adminService, *repository.Repositories) {
+	t.Helper()
+	repos := fake_repo.New()
+	gen, err := idgen.New(1)
+	require.NoError(t, err)
+	return newAdminService(repos.Users, repos.Sessions, repos.Transactor, gen, testHasher).(*adminService), repos
+}
+
+func insertActiveSession(t *testing.T, repos *repository.Repositories, userId string) *core.Session {
+	t.Helper()
+	now := time.Now()
+	session := &core.Session{Id: "s-" + userId, UserId: userId, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	require.NoError(t, repos.Sessions.Insert(context.Background(), session))
+	return session
+}
+
+func TestAdminService_CreateUser(t *testing.T) {
+	s, repos := getAdminService(t)
+	ctx := context.Background()
+	valid := func() *AdminCreateUserInput {
+		return &AdminCreateUserInput{
+			Email:     "root@example.com",
+			Password:  "initial-password",
+			FirstName: "Ada",
+			LastName:  "Admin",
+			Roles:     []security.Role{security.Admin, security.Student, security.Admin},
+		}
+	}
+
+	created, err := s.CreateUser(ctx, valid())
+	require.NoError(t, err)
+	assert.Equal(t, []security.Role{security.Admin, security.Student}, created.Roles)
+	stored, err := repos.Users.GetByEmail(ctx, "root@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, stored.Id)
+	ok, _, err := testHasher.Verify("initial-password", stored.HashedPassword)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = s.CreateUser(ctx, valid())
+	assert.ErrorIs(t, err, ErrUserAlreadyExist)
+
+	cases := map[string]func(*AdminCreateUserInput){
+		"invalid_email": func(i *AdminCreateUserInput) { i.Email = "root" },
+		"no_password":   func(i *AdminCreateUserInput) { i.Password = "" },
+		"no_roles":      func(i *AdminCreateUserInput) { i.Roles = nil },
+		"unknown_role":  func(i *AdminCreateUserInput) { i.Roles = []security.Role{security.UndefinedRole} },
+	}
+	for name, modify := range cases {
+		t.Run(name, func(t *testing.T) {
+			input := valid()
+			input.Email = name + "@example.com"
+			modify(input)
+			_, err := s.CreateUser(ctx, input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAdminService_Roles(t *testing.T) {
+	s, repos := getAdminService(t)
+	ctx := context.Background()
+	sample := fake_repo.SampleUser
+
+	cases := map[string]struct {
+		action   func() (*AdminUserOutput, error)
+		expected []security.Role
+		wantErr  error
+	}{
+		"grant_by_email": {
+			action:   func() (*AdminUserOutput, error) { return s.GrantRole(ctx, sample.Email, security.Admin) },
+			expected: []security.Role{security.Student, security.Admin},
+		},
+		"grant_twice": {
+			action:   func() (*AdminUserOutput, error) { return s.GrantRole(ctx, sample.Id, security.Admin) },
+			expected: []security.Role{security.Student, security.Admin},
+		},
+		"revoke_by_id": {
+			action:   func() (*AdminUserOutput, error) { return s.RevokeRole(ctx, sample.Id, security.Student) },
+			expected: []security.Role{security.Admin},
+		},
+		"revoke_missing_role": {
+			action:   func() (*AdminUserOutput, error) { return s.RevokeRole(ctx, sample.Id, security.Student) },
+			expected: []security.Role{security.Admin},
+		},
+		"unknown_user": {
+			action:  func() (*AdminUserOutput, error) { return s.GrantRole(ctx, "nobody@example.com", security.Admin) },
+			wantErr: repository.ErrNotFound,
+		},
+	}
+	// the cases build on each other
+	for _, name := range []string{"grant_by_email", "grant_twice", "revoke_by_id", "revoke_missing_role", "unknown_user"} {
+		tc := cases[name]
+		t.Run(name, func(t *testing.T) {
+			output, err := tc.action()
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output.Roles)
+			stored, err := repos.Users.GetById(ctx, sample.Id)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stored.Roles)
+		})
+	}
+}
+
+func TestAdminService_RoleChangeRevokesSessions(t *testing.T) {
+	cases := map[string]struct {
+		action        func(s *adminService) (*AdminUserOutput, error)
+		expectRevoked bool
+	}{
+		"grant": {
+			action: func(s *adminService) (*AdminUserOutput, error) {
+				return s.GrantRole(context.Background(), fake_repo.SampleUser.Id, security.Admin)
+			},
+			expectRevoked: true,
+		},
+		"revoke": {
+			action: func(s *adminService) (*AdminUserOutput, error) {
+				return s.RevokeRole(context.Background(), fake_repo.SampleUser.Id, security.Student)
+			},
+			expectRevoked: true,
+		},
+		"unchanged": {
+			action: func(s *adminService) (*AdminUserOutput, error) {
+				return s.GrantRole(context.Background(), fake_repo.SampleUser.Id, security.Student)
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, repos := getAdminService(t)
+			session := insertActiveSession(t, repos, fake_repo.SampleUser.Id)
+
+			_, err := tc.action(s)
+
+			require.NoError(t, err)
+			stored, err := repos.Sessions.GetById(context.Background(), session.Id)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectRevoked, !stored.IsActive(time.Now()))
+		})
+	}
+}
+
+func TestAdminService_ResetPassword(t *testing.T) {
+	s, repos := getAdminService(t)
+	ctx := context.Background()
+	session := insertActiveSession(t, repos, fake_repo.SampleUser.Id)
+
+	require.NoError(t, s.ResetPassword(ctx, fake_repo.SampleUser.Email, "new-password"))
+
+	stored, err := repos.Users.GetById(ctx, fake_repo.SampleUser.Id)
+	require.NoError(t, err)
+	ok, _, err := testHasher.Verify("new-password", stored.HashedPassword)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	revoked, err := repos.Sessions.GetById(ctx, session.Id)
+	require.NoError(t, err)
+	assert.False(t, revoked.IsActive(time.Now()))
+
+	assert.Error(t, s.ResetPassword(ctx, fake_repo.SampleUser.Email, ""))
+}
+
+func TestAdminService_DisableUser(t *testing.T) {
+	s, repos := getAdminService(t)
+	ctx := context.Background()
+	session := insertActiveSession(t, repos, fake_repo.SampleUser.Id)
+
+	output, err := s.DisableUser(ctx, fake_repo.SampleUser.Id)
+	require.NoError(t, err)
+	assert.True(t, output.Disabled)
+
+	stored, err := repos.Users.GetById(ctx, fake_repo.SampleUser.Id)
+	require.NoError(t, err)
+	assert.True(t, stored.Disabled)
+	revoked, err := repos.Sessions.GetById(ctx, session.Id)
+	require.NoError(t, err)
+	assert.False(t, revoked.IsActive(time.Now()))
+}
+
+func TestAdminService_ListUsers(t *testing.T) {
+	s, _ := getAdminService(t)
+	ctx := context.Background()
+
+	list, err := s.ListUsers(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.Total)
+	require.Len(t, list.Users, 1)
+	assert.Equal(t, fake_repo.SampleUser.Email, list.Users[0].Email)
+
+	_, err = s.ListUsers(ctx, 0, 0)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/zhuravlev-pe/course-watch/internal/core"
 	"github.com/zhuravlev-pe/course-watch/internal/repository"
 	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
@@ -34,3 +35,37 @@ func (s *CoursesService) Create(ctx context.Context, input CreateCourseInput) (*
 	}
 	return course, nil
 }
+
+func (i *UpdateCourseInput) Validate() error {
+	return validation.ValidateStruct(i,
+		validation.Field(&i.Title, validation.Required),
+	)
+}
+
+func (s *CoursesService) Update(ctx context.Context, id string, input *UpdateCourseInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, id, &repository.UpdateCourseInput{
+		Title:           input.Title,
+		Description:     input.Description,
+		ExpectedVersion: input.Version,
+	})
+}
+
+func (i *PatchCourseInput) Validate() error {
+	return validation.ValidateStruct(i,
+		validation.Field(&i.Title, validation.When(i.Title.Set, validation.Required)),
+	)
+}
+
+func (s *CoursesService) Patch(ctx context.Context, id string, input *PatchCourseInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return s.repo.Patch(ctx, id, &repository.PatchCourseInput{
+		Title:           input.Title,
+		Description:     input.Description,
+		ExpectedVersion: input.Version,
+	})
+}
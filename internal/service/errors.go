@@ -1,8 +1,170 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+// ProblemTypeBase is the prefix of the type URIs of the errors, which end with the error code
+const ProblemTypeBase = "https://course-watch.com/problems/"
+
+// Error is a domain error, reported to API clients as RFC 7807 problem details. Errors with the same code are equal
+// for errors.Is, whatever their detail
+type Error struct {
+	// Code identifies the kind of error for machines, e.g. user_already_exists
+	Code string
+	// Status is the HTTP status code the error is reported with
+	Status int
+	// Title is a short summary, the same for every occurrence of the error
+	Title string
+	// Detail explains this occurrence of the error, if there is anything to add to the title
+	Detail string
+	// Extensions are further members of the problem details, e.g. the validation errors of the fields
+	Extensions map[string]interface{}
+	// cause is what went wrong underneath. It is logged, but not shown to clients
+	cause error
+}
+
+func newError(code string, status int, title string) *Error {
+	return &Error{Code: code, Status: status, Title: title}
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return e.Title
+	}
+	return e.Title + ": " + e.Detail
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Type is the URI identifying the kind of error
+func (e *Error) Type() string {
+	return ProblemTypeBase + e.Code
+}
+
+// WithDetail returns a copy of the error explaining this occurrence
+func (e *Error) WithDetail(detail string) *Error {
+	c := *e
+	c.Detail = detail
+	return &c
+}
+
+// WithCause returns a copy of the error caused by err
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.cause = err
+	return &c
+}
+
+// WithExtension returns a copy of the error with an additional member of the problem details
+func (e *Error) WithExtension(name string, value interface{}) *Error {
+	c := *e
+	c.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		c.Extensions[k] = v
+	}
+	c.Extensions[name] = value
+	return &c
+}
 
 var (
-	ErrUserAlreadyExist   = errors.New("user already exist with given mailId")
-	ErrInvalidCredentials = errors.New("mail or password are incorrect")
+	ErrInternal = newError("internal_error", http.StatusInternalServerError, "internal server error")
+	// ErrInvalidRequest is a malformed request. The detail tells what is wrong with it
+	ErrInvalidRequest = newError("invalid_request", http.StatusBadRequest, "invalid request")
+	ErrInvalidBody    = newError("invalid_body", http.StatusBadRequest, "body is missing or invalid")
+	// ErrValidation carries the errors of the fields in the validation_errors member
+	ErrValidation           = newError("validation_failed", http.StatusBadRequest, "invalid request parameters")
+	ErrUnsupportedMediaType = newError("unsupported_media_type", http.StatusUnsupportedMediaType, "unsupported media type")
+	ErrUnauthorized         = newError("unauthorized", http.StatusUnauthorized, "Unauthorized")
+	ErrForbidden            = newError("forbidden", http.StatusForbidden, "Forbidden")
+	ErrNotFound             = newError("not_found", http.StatusNotFound, "not found")
+	// ErrVersionConflict is a conditional update of a resource which has been modified since the expected version
+	ErrVersionConflict = newError("version_conflict", http.StatusPreconditionFailed, "the resource has been modified")
+	// ErrPreconditionFailed is a precondition which cannot be evaluated, e.g. an unsupported If-Match header
+	ErrPreconditionFailed = newError("precondition_failed", http.StatusPreconditionFailed, "precondition failed")
+
+	ErrUserAlreadyExist   = newError("user_already_exists", http.StatusConflict, "user already exist with given mailId")
+	ErrInvalidCredentials = newError("invalid_credentials", http.StatusBadRequest, "mail or password are incorrect")
+	ErrSessionInactive    = newError("session_inactive", http.StatusUnauthorized, "session is revoked or expired")
+	ErrTooManyRequests    = newError("too_many_requests", http.StatusTooManyRequests, "too many requests, try again later")
+	ErrInvalidMagicLink   = newError("invalid_magic_link", http.StatusUnauthorized, "login link is invalid or expired")
+	ErrWebAuthnFailed     = newError("webauthn_failed", http.StatusBadRequest, "webauthn ceremony failed")
+	ErrUnknownProvider    = newError("unknown_provider", http.StatusNotFound, "unknown identity provider")
+	ErrExternalLogin      = newError("external_login_failed", http.StatusUnauthorized, "external login failed")
+	ErrEmailNotVerified   = newError("email_not_verified", http.StatusForbidden, "identity provider did not confirm the email address")
+	ErrLoginRequired      = newError("login_required", http.StatusUnauthorized, "login required")
+	ErrUserDisabled       = newError("user_disabled", http.StatusForbidden, "user account is disabled")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is reused for a request with a different body
+	ErrIdempotencyKeyReused = newError("idempotency_key_reused", http.StatusUnprocessableEntity,
+		"idempotency key has already been used for a different request")
+	// ErrIdempotencyKeyInUse is returned for retries arriving while the first request with the key is processed
+	ErrIdempotencyKeyInUse = newError("idempotency_key_in_use", http.StatusConflict,
+		"a request with the same idempotency key is still being processed")
 )
+
+// ErrorOf converts any error returned by the services into the domain error it is reported as. Repository and
+// validation errors get their own codes, errors of unknown kinds are internal errors. The result is caused by err
+func ErrorOf(err error) *Error {
+	var domainErr *Error
+	var validationErrs validation.Errors
+	switch {
+	case errors.As(err, &domainErr):
+		if domainErr.Detail == "" && domainErr.Status < http.StatusInternalServerError && err != domainErr {
+			// the context added by wrapping, e.g. fmt.Errorf("%w: state mismatch", ErrExternalLogin)
+			domainErr = domainErr.WithDetail(strings.TrimPrefix(err.Error(), domainErr.Error()+": "))
+		}
+	case errors.Is(err, repository.ErrNotFound):
+		domainErr = ErrNotFound
+	case errors.Is(err, repository.ErrVersionConflict):
+		domainErr = ErrVersionConflict
+	case errors.As(err, &validationErrs):
+		domainErr = ErrValidation.WithExtension("validation_errors", validationErrs)
+	default:
+		domainErr = ErrInternal
+	}
+	return domainErr.WithCause(err)
+}
+
+// OAuthError is a protocol error reported to OAuth clients, see https://datatracker.ietf.org/doc/html/rfc6749#section-5.2
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// SCIMError is a protocol error reported to SCIM clients, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.12
+type SCIMError struct {
+	// ScimType is the SCIM detail error keyword, e.g. invalidFilter or uniqueness
+	ScimType string
+	Detail   string
+}
+
+func (e *SCIMError) Error() string {
+	return e.ScimType + ": " + e.Detail
+}
+
+func newSCIMError(scimType, detail string) *SCIMError {
+	return &SCIMError{ScimType: scimType, Detail: detail}
+}
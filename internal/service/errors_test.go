@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+func TestErrorOf(t *testing.T) {
+	cases := map[string]struct {
+		err        error
+		code       string
+		status     int
+		detail     string
+		extensions map[string]interface{}
+	}{
+		"domain_error": {
+			err:    ErrUserAlreadyExist,
+			code:   "user_already_exists",
+			status: http.StatusConflict,
+		},
+		"domain_error_with_detail": {
+			err:    ErrInvalidRequest.WithDetail("empty id param"),
+			code:   "invalid_request",
+			status: http.StatusBadRequest,
+			detail: "empty id param",
+		},
+		"wrapped_domain_error": {
+			err:    fmt.Errorf("%w: state mismatch", ErrExternalLogin),
+			code:   "external_login_failed",
+			status: http.StatusUnauthorized,
+			detail: "state mismatch",
+		},
+		"repository_not_found": {
+			err:    fmt.Errorf("session: %w", repository.ErrNotFound),
+			code:   "not_found",
+			status: http.StatusNotFound,
+		},
+		"repository_version_conflict": {
+			err:    repository.ErrVersionConflict,
+			code:   "version_conflict",
+			status: http.StatusPreconditionFailed,
+		},
+		"validation_errors": {
+			err:        validation.Errors{"email": errors.New("cannot be blank")},
+			code:       "validation_failed",
+			status:     http.StatusBadRequest,
+			extensions: map[string]interface{}{"validation_errors": validation.Errors{"email": errors.New("cannot be blank")}},
+		},
+		"unknown_error": {
+			err:    errors.New("connection refused"),
+			code:   "internal_error",
+			status: http.StatusInternalServerError,
+		},
+		"internal_details_are_hidden": {
+			err:    fmt.Errorf("%w: connection refused", ErrInternal),
+			code:   "internal_error",
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			result := ErrorOf(tc.err)
+
+			assert.Equal(t, tc.code, result.Code)
+			assert.Equal(t, ProblemTypeBase+tc.code, result.Type())
+			assert.Equal(t, tc.status, result.Status)
+			assert.Equal(t, tc.detail, result.Detail)
+			assert.Equal(t, tc.extensions, result.Extensions)
+			assert.Equal(t, tc.err, errors.Unwrap(result))
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	err := ErrForbidden.WithDetail("required user role: admin").WithCause(errors.New("no roles"))
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, fmt.Errorf("authorize: %w", err), ErrForbidden)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, "Forbidden: required user role: admin", err.Error())
+	assert.Empty(t, ErrForbidden.Detail, "the sentinel must not be modified")
+}
+
+func TestError_WithExtension(t *testing.T) {
+	first := ErrValidation.WithExtension("a", 1)
+	second := first.WithExtension("b", 2)
+
+	assert.Equal(t, map[string]interface{}{"a": 1}, first.Extensions)
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, second.Extensions)
+	assert.Nil(t, ErrValidation.Extensions)
+}
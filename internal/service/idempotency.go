@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+)
+
+// idempotencyWriteTimeout bounds storing the outcome of a request, which goes on when the client disconnects
+const idempotencyWriteTimeout = 10 * time.Second
+
+type idempotencyService struct {
+	keys     repository.IdempotencyKeys
+	settings IdempotencySettings
+	now      func() time.Time
+}
+
+func newIdempotencyService(keys repository.IdempotencyKeys, settings IdempotencySettings) Idempotency {
+	return &idempotencyService{
+		keys:     keys,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, request *IdempotentRequest) (*IdempotentResponse, error) {
+	fingerprint := s.fingerprintOf(request)
+	for {
+		now := s.now()
+		record := &core.IdempotencyKey{
+			UserId:      scopeOf(request),
+			Route:       request.Route,
+			Key:         request.Key,
+			Fingerprint: fingerprint,
+			Token:       randomString(),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.settings.TTL),
+		}
+		existing, err := s.keys.Reserve(ctx, record, now)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			request.Token = record.Token
+			return nil, nil
+		}
+		if existing.Fingerprint != fingerprint {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if existing.StatusCode != 0 {
+			return &IdempotentResponse{
+				StatusCode: existing.StatusCode,
+				Headers:    existing.Headers,
+				Body:       existing.Body,
+			}, nil
+		}
+		if now.Sub(existing.CreatedAt) < s.settings.LockTimeout {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		// the request holding the key has been abandoned. Of concurrent retries only one takes it over, the others
+		// read it again and see it in use. The abandoned request can no longer complete or release it
+		taken, err := s.keys.TakeOver(ctx, record, existing.Token)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			request.Token = record.Token
+			return nil, nil
+		}
+	}
+}
+
+// fingerprintOf is a keyed hash of the body, since bodies may contain secrets, e.g. the password of a signup, which a
+// plain hash would expose to guessing. The scope and the route are part of the key already
+func (s *idempotencyService) fingerprintOf(request *IdempotentRequest) string {
+	mac := hmac.New(sha256.New, s.settings.FingerprintKey)
+	mac.Write(bytes.TrimSpace(request.Body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// scopeOf is the user the key belongs to. Anonymous callers get a scope of their own, so that one cannot be replayed
+// the response to another who happens to use the same key
+func scopeOf(request *IdempotentRequest) string {
+	if request.UserId != "" {
+		return request.UserId
+	}
+	return "anonymous:" + request.Caller
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, request *IdempotentRequest, response *IdempotentResponse) error {
+	ctx, cancel := context.WithTimeout(detached{ctx}, idempotencyWriteTimeout)
+	defer cancel()
+	return s.keys.Complete(ctx, &core.IdempotencyKey{
+		UserId:     scopeOf(request),
+		Route:      request.Route,
+		Key:        request.Key,
+		Token:      request.Token,
+		StatusCode: response.StatusCode,
+		Headers:    response.Headers,
+		Body:       response.Body,
+	})
+}
+
+func (s *idempotencyService) Release(ctx context.Context, request *IdempotentRequest) error {
+	ctx, cancel := context.WithTimeout(detached{ctx}, idempotencyWriteTimeout)
+	defer cancel()
+	return s.keys.Release(ctx, scopeOf(request), request.Route, request.Key, request.Token)
+}
+
+func (s *idempotencyService) PurgeExpired(ctx context.Context) (int, error) {
+	return s.keys.DeleteExpired(ctx, s.now())
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+)
+
+func getIdempotencyService(t *testing.T) (*idempotencyService, *time.Time) {
+	t.Helper()
+	repos := fake_repo.New()
+	s := newIdempotencyService(repos.IdempotencyKeys, IdempotencySettings{
+		TTL:            time.Hour,
+		LockTimeout:    time.Minute,
+		FingerprintKey: []byte("fingerprint key"),
+	}).(*idempotencyService)
+	now := time.Date(2022, time.December, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		return now
+	}
+	return s, &now
+}
+
+func sampleIdempotentRequest() *IdempotentRequest {
+	return &IdempotentRequest{
+		UserId: fake_repo.SampleUser.Id,
+		Route:  "POST /api/v1/courses/",
+		Key:    "4f1d8c9e-2b7a-4c1e-9f3d-5a6b7c8d9e0f",
+		Body:   []byte(`{"Title":"Go"}`),
+	}
+}
+
+var sampleIdempotentResponse = &IdempotentResponse{
+	StatusCode: http.StatusCreated,
+	Headers:    map[string]string{"Location": "/1"},
+}
+
+func TestIdempotencyService_Replay(t *testing.T) {
+	s, _ := getIdempotencyService(t)
+	ctx := context.Background()
+
+	request := sampleIdempotentRequest()
+	response, err := s.Begin(ctx, request)
+	require.NoError(t, err)
+	assert.Nil(t, response)
+	require.NoError(t, s.Complete(ctx, request, sampleIdempotentResponse))
+
+	response, err = s.Begin(ctx, sampleIdempotentRequest())
+	require.NoError(t, err)
+	assert.Equal(t, sampleIdempotentResponse, response)
+}
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	cases := map[string]struct {
+		// prepare runs after the first request with the sample key has begun
+		prepare          func(t *testing.T, s *idempotencyService, first *IdempotentRequest, now *time.Time)
+		request          func(request *IdempotentRequest)
+		expectedResponse *IdempotentResponse
+		expectedErr      error
+	}{
+		"different_body": {
+			prepare: complete,
+			request: func(request *IdempotentRequest) {
+				request.Body = []byte(`{"Title":"Rust"}`)
+			},
+			expectedErr: ErrIdempotencyKeyReused,
+		},
+		"in_progress": {
+			prepare:     func(t *testing.T, s *idempotencyService, first *IdempotentRequest, now *time.Time) {},
+			expectedErr: ErrIdempotencyKeyInUse,
+		},
+		"abandoned": {
+			prepare: func(t *testing.T, s *idempotencyService, first *IdempotentRequest, now *time.Time) {
+				*now = now.Add(time.Minute)
+			},
+		},
+		"released": {
+			prepare: func(t *testing.T, s *idempotencyService, first *IdempotentRequest, now *time.Time) {
+				require.NoError(t, s.Release(context.Background(), first))
+			},
+		},
+		"expired": {
+			prepare: func(t *testing.T, s *idempotencyService, first *IdempotentRequest, now *time.Time) {
+				complete(t, s, first, now)
+				*now = now.Add(time.Hour)
+			},
+		},
+		"other_user": {
+			prepare: complete,
+			request: func(request *IdempotentRequest) {
+				request.UserId = ""
+			},
+		},
+		"other_anonymous_caller": {
+			prepare: func(t *testing.T, s *idempotencyService, first *IdempotentRequest, now *time.Time) {
+				anonymous := sampleIdempotentRequest()
+				anonymous.UserId, anonymous.Caller = "", "192.0.2.1"
+				_, err := s.Begin(context.Background(), anonymous)
+				require.NoError(t, err)
+				complete(t, s, anonymous, now)
+			},
+			request: func(request *IdempotentRequest) {
+				request.UserId, request.Caller = "", "192.0.2.2"
+			},
+		},
+		"other_route": {
+			prepare: complete,
+			request: func(request *IdempotentRequest) {
+				request.Route = "POST /api/v1/auth/signup"
+			},
+		},
+		"whitespace_only_difference": {
+			prepare: complete,
+			request: func(request *IdempotentRequest) {
+				request.Body = append(request.Body, '\n')
+			},
+			expectedResponse: sampleIdempotentResponse,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, now := getIdempotencyService(t)
+			ctx := context.Background()
+			first := sampleIdempotentRequest()
+			_, err := s.Begin(ctx, first)
+			require.NoError(t, err)
+			tc.prepare(t, s, first, now)
+			request := sampleIdempotentRequest()
+			if tc.request != nil {
+				tc.request(request)
+			}
+
+			response, err := s.Begin(ctx, request)
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedResponse, response)
+		})
+	}
+}
+
+func TestIdempotencyService_CompleteAfterCancel(t *testing.T) {
+	s, _ := getIdempotencyService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	request := sampleIdempotentRequest()
+	_, err := s.Begin(ctx, request)
+	require.NoError(t, err)
+
+	// the client disconnected while the request was processed
+	cancel()
+	require.NoError(t, s.Complete(ctx, request, sampleIdempotentResponse))
+
+	response, err := s.Begin(context.Background(), sampleIdempotentRequest())
+	require.NoError(t, err)
+	assert.Equal(t, sampleIdempotentResponse, response)
+}
+
+func TestIdempotencyService_ReleaseAfterCancel(t *testing.T) {
+	s, _ := getIdempotencyService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	request := sampleIdempotentRequest()
+	_, err := s.Begin(ctx, request)
+	require.NoError(t, err)
+
+	cancel()
+	require.NoError(t, s.Release(ctx, request))
+
+	response, err := s.Begin(context.Background(), sampleIdempotentRequest())
+	require.NoError(t, err)
+	assert.Nil(t, response)
+}
+
+func complete(t *testing.T, s *idempotencyService, first *IdempotentRequest, _ *time.Time) {
+	require.NoError(t, s.Complete(context.Background(), first, sampleIdempotentResponse))
+}
+
+func TestIdempotencyService_TakeOver(t *testing.T) {
+	s, now := getIdempotencyService(t)
+	ctx := context.Background()
+	abandoned := sampleIdempotentRequest()
+	_, err := s.Begin(ctx, abandoned)
+	require.NoError(t, err)
+	*now = now.Add(time.Minute)
+
+	retry := sampleIdempotentRequest()
+	response, err := s.Begin(ctx, retry)
+	require.NoError(t, err)
+	assert.Nil(t, response)
+	assert.NotEqual(t, abandoned.Token, retry.Token)
+
+	// a concurrent retry lost the race for the key
+	_, err = s.Begin(ctx, sampleIdempotentRequest())
+	assert.Equal(t, ErrIdempotencyKeyInUse, err)
+
+	// the abandoned request finishing late neither releases nor completes the key of the retry
+	require.NoError(t, s.Release(ctx, abandoned))
+	assert.ErrorIs(t, s.Complete(ctx, abandoned, &IdempotentResponse{StatusCode: http.StatusConflict}), repository.ErrNotFound)
+	_, err = s.Begin(ctx, sampleIdempotentRequest())
+	assert.Equal(t, ErrIdempotencyKeyInUse, err)
+
+	require.NoError(t, s.Complete(ctx, retry, sampleIdempotentResponse))
+	response, err = s.Begin(ctx, sampleIdempotentRequest())
+	require.NoError(t, err)
+	assert.Equal(t, sampleIdempotentResponse, response)
+}
+
+func TestIdempotencyService_TakeOverRace(t *testing.T) {
+	s, now := getIdempotencyService(t)
+	ctx := context.Background()
+	abandoned := sampleIdempotentRequest()
+	_, err := s.Begin(ctx, abandoned)
+	require.NoError(t, err)
+	// retries read the record the way Begin does, by failing to reserve the key
+	stale, err := s.keys.Reserve(ctx, &core.IdempotencyKey{
+		UserId: abandoned.UserId,
+		Route:  abandoned.Route,
+		Key:    abandoned.Key,
+	}, *now)
+	require.NoError(t, err)
+	*now = now.Add(time.Minute)
+
+	// both retries read the abandoned record, the second takes it over after the first did
+	first := &core.IdempotencyKey{UserId: stale.UserId, Route: stale.Route, Key: stale.Key, Token: "first"}
+	taken, err := s.keys.TakeOver(ctx, first, stale.Token)
+	require.NoError(t, err)
+	assert.True(t, taken)
+	second := &core.IdempotencyKey{UserId: stale.UserId, Route: stale.Route, Key: stale.Key, Token: "second"}
+	taken, err = s.keys.TakeOver(ctx, second, stale.Token)
+	require.NoError(t, err)
+	assert.False(t, taken)
+}
+
+func TestIdempotencyService_FingerprintIsKeyed(t *testing.T) {
+	s, _ := getIdempotencyService(t)
+	other, _ := getIdempotencyService(t)
+	other.settings.FingerprintKey = []byte("other key")
+	request := sampleIdempotentRequest()
+
+	plain := sha256.Sum256(request.Body)
+	assert.NotEqual(t, hex.EncodeToString(plain[:]), s.fingerprintOf(request))
+	assert.NotEqual(t, s.fingerprintOf(request), other.fingerprintOf(request))
+}
+
+func TestIdempotencyService_PurgeExpired(t *testing.T) {
+	s, now := getIdempotencyService(t)
+	ctx := context.Background()
+	_, err := s.Begin(ctx, sampleIdempotentRequest())
+	require.NoError(t, err)
+
+	purged, err := s.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	*now = now.Add(time.Hour)
+	purged, err = s.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/background"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/logger"
+	"github.com/zhuravlev-pe/course-watch/pkg/mailer"
+)
+
+type magicLinksService struct {
+	links    repository.MagicLinks
+	users    repository.Users
+	idGen    *idgen.IdGen
+	mailer   mailer.Sender
+	settings MagicLinkSettings
+	now      func() time.Time
+	// background runs the work Request does not wait for
+	background func(task func())
+}
+
+// magicLinkSendTimeout bounds the creation and sending of a link, which outlive the request
+const magicLinkSendTimeout = time.Minute
+
+func newMagicLinksService(
+	links repository.MagicLinks,
+	users repository.Users,
+	idGen *idgen.IdGen,
+	sender mailer.Sender,
+	settings MagicLinkSettings,
+	tasks *background.Tasks,
+) MagicLinks {
+	return &magicLinksService{
+		links:      links,
+		users:      users,
+		idGen:      idGen,
+		mailer:     sender,
+		settings:   settings,
+		now:        time.Now,
+		background: tasks.Go,
+	}
+}
+
+func (i *MagicLinkRequestInput) Validate() error {
+	return validation.ValidateStruct(i,
+		validation.Field(&i.Email, validation.Required),
+	)
+}
+
+func (s *magicLinksService) Request(ctx context.Context, input *MagicLinkRequestInput, nonce string) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	now := s.now()
+	// every request counts, so that the limit does not reveal whether the address is registered
+	count, err := s.links.CountAttempt(ctx, strings.ToLower(input.Email), now, s.settings.RateInterval)
+	if err != nil {
+		return err
+	}
+	if count > s.settings.RateLimit {
+		return ErrTooManyRequests
+	}
+
+	// neither does the response time, the link is created and sent after responding
+	ctx = detached{ctx}
+	s.background(func() {
+		ctx, cancel := context.WithTimeout(ctx, magicLinkSendTimeout)
+		defer cancel()
+		if err := s.send(ctx, input.Email, nonce, now); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("failed to send the magic link")
+		}
+	})
+	return nil
+}
+
+// send emails a link to the user with the address, if there is one
+func (s *magicLinksService) send(ctx context.Context, email string, nonce string, now time.Time) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if err == repository.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	link := &core.MagicLink{
+		Id:        s.idGen.Generate(),
+		UserId:    user.Id,
+		Email:     user.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.settings.TTL),
+	}
+	if err = s.links.Insert(ctx, link); err != nil {
+		return err
+	}
+
+	token := link.Id + "." + s.sign(link.Id, nonce)
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "Your Course Watch login link",
+		Body: fmt.Sprintf("Follow the link below to log in to Course Watch. The link is valid for %s and can be "+
+			"used only once, from the same browser it was requested in.\n\n%s?token=%s\n",
+			s.settings.TTL, s.settings.URL, url.QueryEscape(token)),
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+func (s *magicLinksService) PurgeAttempts(ctx context.Context) (int, error) {
+	return s.links.DeleteAttemptsBefore(ctx, s.now().Add(-s.settings.RateInterval))
+}
+
+// detached keeps the values of the context, e.g. the logger and the trace, but not its deadline and cancellation, for
+// work which outlives the request
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func (s *magicLinksService) Exchange(ctx context.Context, token string, nonce string) (*core.User, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || nonce == "" || !hmac.Equal([]byte(signature), []byte(s.sign(id, nonce))) {
+		return nil, ErrInvalidMagicLink
+	}
+
+	link, err := s.links.GetById(ctx, id)
+	if err == repository.ErrNotFound {
+		return nil, ErrInvalidMagicLink
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if !now.Before(link.ExpiresAt) {
+		return nil, ErrInvalidMagicLink
+	}
+	if err = s.links.MarkUsed(ctx, link.Id, now); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+	return s.users.GetById(ctx, link.UserId)
+}
+
+// sign binds the link id to the browser nonce, so that a leaked link cannot be exchanged from another browser
+func (s *magicLinksService) sign(id, nonce string) string {
+	mac := hmac.New(sha256.New, s.settings.SigningKey)
+	mac.Write([]byte(id))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+	"github.com/zhuravlev-pe/course-watch/pkg/background"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/mailer"
+)
+
+const testNonce = "browser-nonce"
+
+type capturingMailer struct {
+	messages []*mailer.Message
+}
+
+func (m *capturingMailer) Send(_ context.Context, msg *mailer.Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`https://example\.com/login/magic\?token=(\S+)`)
+
+func (m *capturingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, m.messages)
+	match := linkPattern.FindStringSubmatch(m.messages[len(m.messages)-1].Body)
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func getMagicLinksService(t *testing.T) (*magicLinksService, *capturingMailer) {
+	t.Helper()
+	repos := fake_repo.New()
+	gen, err := idgen.New(1)
+	require.NoError(t, err)
+	m := &capturingMailer{}
+	s := newMagicLinksService(repos.MagicLinks, repos.Users, gen, m, MagicLinkSettings{
+		URL:          "https://example.com/login/magic",
+		TTL:          15 * time.Minute,
+		RateLimit:    2,
+		RateInterval: time.Hour,
+		SigningKey:   []byte("magic-link-test-key"),
+	}, background.NewTasks(1)).(*magicLinksService)
+	// the links are sent before Request returns, so that the tests see them
+	s.background = func(task func()) {
+		task()
+	}
+	return s, m
+}
+
+func TestMagicLinksService_RequestAndExchange(t *testing.T) {
+	s, m := getMagicLinksService(t)
+	ctx := context.Background()
+
+	err := s.Request(ctx, &MagicLinkRequestInput{Email: fake_repo.SampleUser.Email}, testNonce)
+	require.NoError(t, err)
+	require.Len(t, m.messages, 1)
+	assert.Equal(t, fake_repo.SampleUser.Email, m.messages[0].To)
+
+	token := m.lastToken(t)
+	user, err := s.Exchange(ctx, token, testNonce)
+	require.NoError(t, err)
+	assert.Equal(t, fake_repo.SampleUser.Id, user.Id)
+
+	// single use
+	_, err = s.Exchange(ctx, token, testNonce)
+	assert.ErrorIs(t, err, ErrInvalidMagicLink)
+}
+
+func TestMagicLinksService_Exchange_Failures(t *testing.T) {
+	cases := map[string]struct {
+		tokenFunc func(token string) string
+		nonce     string
+		advance   time.Duration
+	}{
+		"other_browser": {
+			tokenFunc: func(token string) string { return token },
+			nonce:     "another-nonce",
+		},
+		"missing_nonce": {
+			tokenFunc: func(token string) string { return token },
+			nonce:     "",
+		},
+		"tampered_token": {
+			tokenFunc: func(token string) string { return "1" + token },
+			nonce:     testNonce,
+		},
+		"malformed_token": {
+			tokenFunc: func(token string) string { return "garbage" },
+			nonce:     testNonce,
+		},
+		"expired": {
+			tokenFunc: func(token string) string { return token },
+			nonce:     testNonce,
+			advance:   16 * time.Minute,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, m := getMagicLinksService(t)
+			ctx := context.Background()
+			require.NoError(t, s.Request(ctx, &MagicLinkRequestInput{Email: fake_repo.SampleUser.Email}, testNonce))
+
+			requested := time.Now()
+			s.now = func() time.Time { return requested.Add(tc.advance) }
+
+			_, err := s.Exchange(ctx, tc.tokenFunc(m.lastToken(t)), tc.nonce)
+			assert.ErrorIs(t, err, ErrInvalidMagicLink)
+		})
+	}
+}
+
+func TestMagicLinksService_Request_UnknownEmail(t *testing.T) {
+	s, m := getMagicLinksService(t)
+
+	err := s.Request(context.Background(), &MagicLinkRequestInput{Email: "nobody@example.com"}, testNonce)
+
+	assert.NoError(t, err)
+	assert.Empty(t, m.messages)
+}
+
+func TestMagicLinksService_Request_RateLimit(t *testing.T) {
+	s, m := getMagicLinksService(t)
+	ctx := context.Background()
+	input := &MagicLinkRequestInput{Email: fake_repo.SampleUser.Email}
+
+	require.NoError(t, s.Request(ctx, input, testNonce))
+	require.NoError(t, s.Request(ctx, input, testNonce))
+	err := s.Request(ctx, input, testNonce)
+
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+	assert.Len(t, m.messages, 2)
+}
+
+func TestMagicLinksService_Request_RateLimitUnknownEmail(t *testing.T) {
+	s, _ := getMagicLinksService(t)
+	ctx := context.Background()
+	input := &MagicLinkRequestInput{Email: "nobody@example.com"}
+
+	require.NoError(t, s.Request(ctx, input, testNonce))
+	require.NoError(t, s.Request(ctx, &MagicLinkRequestInput{Email: "Nobody@Example.com"}, testNonce))
+	err := s.Request(ctx, input, testNonce)
+
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+}
+
+func TestMagicLinksService_Request_RateLimitWindow(t *testing.T) {
+	s, m := getMagicLinksService(t)
+	ctx := context.Background()
+	input := &MagicLinkRequestInput{Email: fake_repo.SampleUser.Email}
+	start := time.Now()
+	s.now = func() time.Time { return start }
+	require.NoError(t, s.Request(ctx, input, testNonce))
+	require.NoError(t, s.Request(ctx, input, testNonce))
+
+	s.now = func() time.Time { return start.Add(time.Hour) }
+	require.NoError(t, s.Request(ctx, input, testNonce))
+	assert.Len(t, m.messages, 3)
+
+	s.now = func() time.Time { return start.Add(90 * time.Minute) }
+	purged, err := s.PurgeAttempts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
+	s.now = func() time.Time { return start.Add(2 * time.Hour) }
+	purged, err = s.PurgeAttempts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestMagicLinksService_Request_SendsInBackground(t *testing.T) {
+	s, m := getMagicLinksService(t)
+	var task func()
+	s.background = func(t func()) {
+		task = t
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	require.NoError(t, s.Request(ctx, &MagicLinkRequestInput{Email: fake_repo.SampleUser.Email}, testNonce))
+	assert.Empty(t, m.messages)
+
+	// the request is over by the time the link is sent
+	cancel()
+	require.NotNil(t, task)
+	task()
+	assert.Len(t, m.messages, 1)
+}
+
+func TestMagicLinksService_Request_Validation(t *testing.T) {
+	s, _ := getMagicLinksService(t)
+
+	err := s.Request(context.Background(), &MagicLinkRequestInput{}, testNonce)
+
+	var errs validation.Errors
+	require.ErrorAs(t, err, &errs)
+	_, ok := errs["email"]
+	assert.True(t, ok)
+}
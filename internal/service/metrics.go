@@ -0,0 +1,18 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	loginSuccess = "success"
+	loginFailure = "failure"
+	loginError   = "error"
+)
+
+var loginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "course_watch",
+	Name:      "password_logins_total",
+	Help:      "Number of password login attempts by result: success, failure (invalid credentials) or error.",
+}, []string{"result"})
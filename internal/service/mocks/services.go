@@ -11,6 +11,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	core "github.com/zhuravlev-pe/course-watch/internal/core"
 	service "github.com/zhuravlev-pe/course-watch/internal/service"
+	security "github.com/zhuravlev-pe/course-watch/pkg/security"
 )
 
 // MockCourses is a mock of Courses interface.
@@ -66,6 +67,34 @@ func (mr *MockCoursesMockRecorder) GetById(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockCourses)(nil).GetById), ctx, id)
 }
 
+// Patch mocks base method.
+func (m *MockCourses) Patch(ctx context.Context, id string, input *service.PatchCourseInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockCoursesMockRecorder) Patch(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockCourses)(nil).Patch), ctx, id, input)
+}
+
+// Update mocks base method.
+func (m *MockCourses) Update(ctx context.Context, id string, input *service.UpdateCourseInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCoursesMockRecorder) Update(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCourses)(nil).Update), ctx, id, input)
+}
+
 // MockUsers is a mock of Users interface.
 type MockUsers struct {
 	ctrl     *gomock.Controller
@@ -119,6 +148,20 @@ func (mr *MockUsersMockRecorder) Login(ctx, input interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUsers)(nil).Login), ctx, input)
 }
 
+// PatchUserInfo mocks base method.
+func (m *MockUsers) PatchUserInfo(ctx context.Context, id string, input *service.PatchUserInfoInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUserInfo", ctx, id, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchUserInfo indicates an expected call of PatchUserInfo.
+func (mr *MockUsersMockRecorder) PatchUserInfo(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUserInfo", reflect.TypeOf((*MockUsers)(nil).PatchUserInfo), ctx, id, input)
+}
+
 // Signup mocks base method.
 func (m *MockUsers) Signup(ctx context.Context, input *service.SignupUserInput) error {
 	m.ctrl.T.Helper()
@@ -146,3 +189,759 @@ func (mr *MockUsersMockRecorder) UpdateUserInfo(ctx, id, input interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserInfo", reflect.TypeOf((*MockUsers)(nil).UpdateUserInfo), ctx, id, input)
 }
+
+// MockSessions is a mock of Sessions interface.
+type MockSessions struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionsMockRecorder
+}
+
+// MockSessionsMockRecorder is the mock recorder for MockSessions.
+type MockSessionsMockRecorder struct {
+	mock *MockSessions
+}
+
+// NewMockSessions creates a new mock instance.
+func NewMockSessions(ctrl *gomock.Controller) *MockSessions {
+	mock := &MockSessions{ctrl: ctrl}
+	mock.recorder = &MockSessionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessions) EXPECT() *MockSessionsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSessions) Create(ctx context.Context, input *service.CreateSessionInput) (*core.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, input)
+	ret0, _ := ret[0].(*core.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSessionsMockRecorder) Create(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSessions)(nil).Create), ctx, input)
+}
+
+// List mocks base method.
+func (m *MockSessions) List(ctx context.Context, userId, currentSessionId string) ([]*service.SessionOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userId, currentSessionId)
+	ret0, _ := ret[0].([]*service.SessionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockSessionsMockRecorder) List(ctx, userId, currentSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSessions)(nil).List), ctx, userId, currentSessionId)
+}
+
+// Revoke mocks base method.
+func (m *MockSessions) Revoke(ctx context.Context, userId, sessionId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockSessionsMockRecorder) Revoke(ctx, userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockSessions)(nil).Revoke), ctx, userId, sessionId)
+}
+
+// RevokeOthers mocks base method.
+func (m *MockSessions) RevokeOthers(ctx context.Context, userId, currentSessionId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOthers", ctx, userId, currentSessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeOthers indicates an expected call of RevokeOthers.
+func (mr *MockSessionsMockRecorder) RevokeOthers(ctx, userId, currentSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOthers", reflect.TypeOf((*MockSessions)(nil).RevokeOthers), ctx, userId, currentSessionId)
+}
+
+// ValidateSession mocks base method.
+func (m *MockSessions) ValidateSession(ctx context.Context, principal *security.UserPrincipal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateSession", ctx, principal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateSession indicates an expected call of ValidateSession.
+func (mr *MockSessionsMockRecorder) ValidateSession(ctx, principal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateSession", reflect.TypeOf((*MockSessions)(nil).ValidateSession), ctx, principal)
+}
+
+// MockMagicLinks is a mock of MagicLinks interface.
+type MockMagicLinks struct {
+	ctrl     *gomock.Controller
+	recorder *MockMagicLinksMockRecorder
+}
+
+// MockMagicLinksMockRecorder is the mock recorder for MockMagicLinks.
+type MockMagicLinksMockRecorder struct {
+	mock *MockMagicLinks
+}
+
+// NewMockMagicLinks creates a new mock instance.
+func NewMockMagicLinks(ctrl *gomock.Controller) *MockMagicLinks {
+	mock := &MockMagicLinks{ctrl: ctrl}
+	mock.recorder = &MockMagicLinksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMagicLinks) EXPECT() *MockMagicLinksMockRecorder {
+	return m.recorder
+}
+
+// Exchange mocks base method.
+func (m *MockMagicLinks) Exchange(ctx context.Context, token, nonce string) (*core.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", ctx, token, nonce)
+	ret0, _ := ret[0].(*core.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockMagicLinksMockRecorder) Exchange(ctx, token, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockMagicLinks)(nil).Exchange), ctx, token, nonce)
+}
+
+// PurgeAttempts mocks base method.
+func (m *MockMagicLinks) PurgeAttempts(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeAttempts", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeAttempts indicates an expected call of PurgeAttempts.
+func (mr *MockMagicLinksMockRecorder) PurgeAttempts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeAttempts", reflect.TypeOf((*MockMagicLinks)(nil).PurgeAttempts), ctx)
+}
+
+// Request mocks base method.
+func (m *MockMagicLinks) Request(ctx context.Context, input *service.MagicLinkRequestInput, nonce string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Request", ctx, input, nonce)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Request indicates an expected call of Request.
+func (mr *MockMagicLinksMockRecorder) Request(ctx, input, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockMagicLinks)(nil).Request), ctx, input, nonce)
+}
+
+// MockWebAuthn is a mock of WebAuthn interface.
+type MockWebAuthn struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnMockRecorder
+}
+
+// MockWebAuthnMockRecorder is the mock recorder for MockWebAuthn.
+type MockWebAuthnMockRecorder struct {
+	mock *MockWebAuthn
+}
+
+// NewMockWebAuthn creates a new mock instance.
+func NewMockWebAuthn(ctrl *gomock.Controller) *MockWebAuthn {
+	mock := &MockWebAuthn{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthn) EXPECT() *MockWebAuthnMockRecorder {
+	return m.recorder
+}
+
+// BeginLogin mocks base method.
+func (m *MockWebAuthn) BeginLogin(ctx context.Context, input *service.WebAuthnLoginInput) (*service.WebAuthnBeginOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin", ctx, input)
+	ret0, _ := ret[0].(*service.WebAuthnBeginOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockWebAuthnMockRecorder) BeginLogin(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockWebAuthn)(nil).BeginLogin), ctx, input)
+}
+
+// BeginRegistration mocks base method.
+func (m *MockWebAuthn) BeginRegistration(ctx context.Context, userId string) (*service.WebAuthnBeginOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRegistration", ctx, userId)
+	ret0, _ := ret[0].(*service.WebAuthnBeginOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRegistration indicates an expected call of BeginRegistration.
+func (mr *MockWebAuthnMockRecorder) BeginRegistration(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRegistration", reflect.TypeOf((*MockWebAuthn)(nil).BeginRegistration), ctx, userId)
+}
+
+// FinishLogin mocks base method.
+func (m *MockWebAuthn) FinishLogin(ctx context.Context, input *service.WebAuthnFinishInput) (*core.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", ctx, input)
+	ret0, _ := ret[0].(*core.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockWebAuthnMockRecorder) FinishLogin(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockWebAuthn)(nil).FinishLogin), ctx, input)
+}
+
+// FinishRegistration mocks base method.
+func (m *MockWebAuthn) FinishRegistration(ctx context.Context, userId string, input *service.WebAuthnFinishInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRegistration", ctx, userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishRegistration indicates an expected call of FinishRegistration.
+func (mr *MockWebAuthnMockRecorder) FinishRegistration(ctx, userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRegistration", reflect.TypeOf((*MockWebAuthn)(nil).FinishRegistration), ctx, userId, input)
+}
+
+// MockOIDC is a mock of OIDC interface.
+type MockOIDC struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCMockRecorder
+}
+
+// MockOIDCMockRecorder is the mock recorder for MockOIDC.
+type MockOIDCMockRecorder struct {
+	mock *MockOIDC
+}
+
+// NewMockOIDC creates a new mock instance.
+func NewMockOIDC(ctrl *gomock.Controller) *MockOIDC {
+	mock := &MockOIDC{ctrl: ctrl}
+	mock.recorder = &MockOIDCMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDC) EXPECT() *MockOIDCMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockOIDC) Authorize(ctx context.Context, provider string) (*service.OIDCAuthorizeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, provider)
+	ret0, _ := ret[0].(*service.OIDCAuthorizeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockOIDCMockRecorder) Authorize(ctx, provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockOIDC)(nil).Authorize), ctx, provider)
+}
+
+// Callback mocks base method.
+func (m *MockOIDC) Callback(ctx context.Context, provider, state, code string) (*service.OIDCCallbackOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Callback", ctx, provider, state, code)
+	ret0, _ := ret[0].(*service.OIDCCallbackOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Callback indicates an expected call of Callback.
+func (mr *MockOIDCMockRecorder) Callback(ctx, provider, state, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Callback", reflect.TypeOf((*MockOIDC)(nil).Callback), ctx, provider, state, code)
+}
+
+// MockOAuth is a mock of OAuth interface.
+type MockOAuth struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthMockRecorder
+}
+
+// MockOAuthMockRecorder is the mock recorder for MockOAuth.
+type MockOAuthMockRecorder struct {
+	mock *MockOAuth
+}
+
+// NewMockOAuth creates a new mock instance.
+func NewMockOAuth(ctrl *gomock.Controller) *MockOAuth {
+	mock := &MockOAuth{ctrl: ctrl}
+	mock.recorder = &MockOAuthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth) EXPECT() *MockOAuthMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateConfidentialClient mocks base method.
+func (m *MockOAuth) AuthenticateConfidentialClient(ctx context.Context, clientId, secret string) (*service.OAuthClientOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateConfidentialClient", ctx, clientId, secret)
+	ret0, _ := ret[0].(*service.OAuthClientOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateConfidentialClient indicates an expected call of AuthenticateConfidentialClient.
+func (mr *MockOAuthMockRecorder) AuthenticateConfidentialClient(ctx, clientId, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateConfidentialClient", reflect.TypeOf((*MockOAuth)(nil).AuthenticateConfidentialClient), ctx, clientId, secret)
+}
+
+// Authorize mocks base method.
+func (m *MockOAuth) Authorize(ctx context.Context, input *service.OAuthAuthorizeInput, userId string) (*service.OAuthAuthorizeOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, input, userId)
+	ret0, _ := ret[0].(*service.OAuthAuthorizeOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockOAuthMockRecorder) Authorize(ctx, input, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockOAuth)(nil).Authorize), ctx, input, userId)
+}
+
+// DeleteClient mocks base method.
+func (m *MockOAuth) DeleteClient(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteClient", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockOAuthMockRecorder) DeleteClient(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockOAuth)(nil).DeleteClient), ctx, id)
+}
+
+// Discovery mocks base method.
+func (m *MockOAuth) Discovery() *service.OpenIDConfiguration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Discovery")
+	ret0, _ := ret[0].(*service.OpenIDConfiguration)
+	return ret0
+}
+
+// Discovery indicates an expected call of Discovery.
+func (mr *MockOAuthMockRecorder) Discovery() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discovery", reflect.TypeOf((*MockOAuth)(nil).Discovery))
+}
+
+// ExchangeCode mocks base method.
+func (m *MockOAuth) ExchangeCode(ctx context.Context, input *service.OAuthTokenInput) (*service.OAuthGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeCode", ctx, input)
+	ret0, _ := ret[0].(*service.OAuthGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeCode indicates an expected call of ExchangeCode.
+func (mr *MockOAuthMockRecorder) ExchangeCode(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeCode", reflect.TypeOf((*MockOAuth)(nil).ExchangeCode), ctx, input)
+}
+
+// KeySet mocks base method.
+func (m *MockOAuth) KeySet() *security.JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeySet")
+	ret0, _ := ret[0].(*security.JSONWebKeySet)
+	return ret0
+}
+
+// KeySet indicates an expected call of KeySet.
+func (mr *MockOAuthMockRecorder) KeySet() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeySet", reflect.TypeOf((*MockOAuth)(nil).KeySet))
+}
+
+// ListClients mocks base method.
+func (m *MockOAuth) ListClients(ctx context.Context) ([]*service.OAuthClientOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", ctx)
+	ret0, _ := ret[0].([]*service.OAuthClientOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockOAuthMockRecorder) ListClients(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockOAuth)(nil).ListClients), ctx)
+}
+
+// RegisterClient mocks base method.
+func (m *MockOAuth) RegisterClient(ctx context.Context, input *service.RegisterOAuthClientInput) (*service.RegisterOAuthClientOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterClient", ctx, input)
+	ret0, _ := ret[0].(*service.RegisterOAuthClientOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterClient indicates an expected call of RegisterClient.
+func (mr *MockOAuthMockRecorder) RegisterClient(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterClient", reflect.TypeOf((*MockOAuth)(nil).RegisterClient), ctx, input)
+}
+
+// UserInfo mocks base method.
+func (m *MockOAuth) UserInfo(ctx context.Context, userId, scope string) (*service.UserInfoClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInfo", ctx, userId, scope)
+	ret0, _ := ret[0].(*service.UserInfoClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockOAuthMockRecorder) UserInfo(ctx, userId, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockOAuth)(nil).UserInfo), ctx, userId, scope)
+}
+
+// MockSCIM is a mock of SCIM interface.
+type MockSCIM struct {
+	ctrl     *gomock.Controller
+	recorder *MockSCIMMockRecorder
+}
+
+// MockSCIMMockRecorder is the mock recorder for MockSCIM.
+type MockSCIMMockRecorder struct {
+	mock *MockSCIM
+}
+
+// NewMockSCIM creates a new mock instance.
+func NewMockSCIM(ctrl *gomock.Controller) *MockSCIM {
+	mock := &MockSCIM{ctrl: ctrl}
+	mock.recorder = &MockSCIMMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSCIM) EXPECT() *MockSCIMMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockSCIM) Authenticate(token string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", token)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockSCIMMockRecorder) Authenticate(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockSCIM)(nil).Authenticate), token)
+}
+
+// CreateUser mocks base method.
+func (m *MockSCIM) CreateUser(ctx context.Context, input *service.SCIMUser) (*service.SCIMUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, input)
+	ret0, _ := ret[0].(*service.SCIMUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockSCIMMockRecorder) CreateUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockSCIM)(nil).CreateUser), ctx, input)
+}
+
+// DeactivateUser mocks base method.
+func (m *MockSCIM) DeactivateUser(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivateUser indicates an expected call of DeactivateUser.
+func (mr *MockSCIMMockRecorder) DeactivateUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateUser", reflect.TypeOf((*MockSCIM)(nil).DeactivateUser), ctx, id)
+}
+
+// GetUser mocks base method.
+func (m *MockSCIM) GetUser(ctx context.Context, id string) (*service.SCIMUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, id)
+	ret0, _ := ret[0].(*service.SCIMUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockSCIMMockRecorder) GetUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockSCIM)(nil).GetUser), ctx, id)
+}
+
+// ListUsers mocks base method.
+func (m *MockSCIM) ListUsers(ctx context.Context, input *service.SCIMListInput) (*service.SCIMListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, input)
+	ret0, _ := ret[0].(*service.SCIMListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockSCIMMockRecorder) ListUsers(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockSCIM)(nil).ListUsers), ctx, input)
+}
+
+// PatchUser mocks base method.
+func (m *MockSCIM) PatchUser(ctx context.Context, id string, input *service.SCIMPatchInput) (*service.SCIMUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUser", ctx, id, input)
+	ret0, _ := ret[0].(*service.SCIMUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchUser indicates an expected call of PatchUser.
+func (mr *MockSCIMMockRecorder) PatchUser(ctx, id, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockSCIM)(nil).PatchUser), ctx, id, input)
+}
+
+// MockAdmin is a mock of Admin interface.
+type MockAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminMockRecorder
+}
+
+// MockAdminMockRecorder is the mock recorder for MockAdmin.
+type MockAdminMockRecorder struct {
+	mock *MockAdmin
+}
+
+// NewMockAdmin creates a new mock instance.
+func NewMockAdmin(ctrl *gomock.Controller) *MockAdmin {
+	mock := &MockAdmin{ctrl: ctrl}
+	mock.recorder = &MockAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdmin) EXPECT() *MockAdminMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockAdmin) CreateUser(ctx context.Context, input *service.AdminCreateUserInput) (*service.AdminUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, input)
+	ret0, _ := ret[0].(*service.AdminUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockAdminMockRecorder) CreateUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAdmin)(nil).CreateUser), ctx, input)
+}
+
+// DisableUser mocks base method.
+func (m *MockAdmin) DisableUser(ctx context.Context, user string) (*service.AdminUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUser", ctx, user)
+	ret0, _ := ret[0].(*service.AdminUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockAdminMockRecorder) DisableUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockAdmin)(nil).DisableUser), ctx, user)
+}
+
+// GrantRole mocks base method.
+func (m *MockAdmin) GrantRole(ctx context.Context, user string, role security.Role) (*service.AdminUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantRole", ctx, user, role)
+	ret0, _ := ret[0].(*service.AdminUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantRole indicates an expected call of GrantRole.
+func (mr *MockAdminMockRecorder) GrantRole(ctx, user, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantRole", reflect.TypeOf((*MockAdmin)(nil).GrantRole), ctx, user, role)
+}
+
+// ListUsers mocks base method.
+func (m *MockAdmin) ListUsers(ctx context.Context, offset, limit int) (*service.AdminUserList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, offset, limit)
+	ret0, _ := ret[0].(*service.AdminUserList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAdminMockRecorder) ListUsers(ctx, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAdmin)(nil).ListUsers), ctx, offset, limit)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAdmin) ResetPassword(ctx context.Context, user, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, user, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAdminMockRecorder) ResetPassword(ctx, user, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAdmin)(nil).ResetPassword), ctx, user, password)
+}
+
+// RevokeRole mocks base method.
+func (m *MockAdmin) RevokeRole(ctx context.Context, user string, role security.Role) (*service.AdminUserOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, user, role)
+	ret0, _ := ret[0].(*service.AdminUserOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockAdminMockRecorder) RevokeRole(ctx, user, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockAdmin)(nil).RevokeRole), ctx, user, role)
+}
+
+// MockIdempotency is a mock of Idempotency interface.
+type MockIdempotency struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyMockRecorder
+}
+
+// MockIdempotencyMockRecorder is the mock recorder for MockIdempotency.
+type MockIdempotencyMockRecorder struct {
+	mock *MockIdempotency
+}
+
+// NewMockIdempotency creates a new mock instance.
+func NewMockIdempotency(ctrl *gomock.Controller) *MockIdempotency {
+	mock := &MockIdempotency{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotency) EXPECT() *MockIdempotencyMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method.
+func (m *MockIdempotency) Begin(ctx context.Context, request *service.IdempotentRequest) (*service.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx, request)
+	ret0, _ := ret[0].(*service.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockIdempotencyMockRecorder) Begin(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockIdempotency)(nil).Begin), ctx, request)
+}
+
+// Complete mocks base method.
+func (m *MockIdempotency) Complete(ctx context.Context, request *service.IdempotentRequest, response *service.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, request, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIdempotencyMockRecorder) Complete(ctx, request, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotency)(nil).Complete), ctx, request, response)
+}
+
+// PurgeExpired mocks base method.
+func (m *MockIdempotency) PurgeExpired(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpired", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpired indicates an expected call of PurgeExpired.
+func (mr *MockIdempotencyMockRecorder) PurgeExpired(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpired", reflect.TypeOf((*MockIdempotency)(nil).PurgeExpired), ctx)
+}
+
+// Release mocks base method.
+func (m *MockIdempotency) Release(ctx context.Context, request *service.IdempotentRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIdempotencyMockRecorder) Release(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIdempotency)(nil).Release), ctx, request)
+}
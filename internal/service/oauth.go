@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+const (
+	scopeOpenId  = "openid"
+	scopeProfile = "profile"
+	scopeEmail   = "email"
+)
+
+var supportedScopes = []string{scopeOpenId, scopeProfile, scopeEmail}
+
+type oauthService struct {
+	repo     repository.OAuth
+	users    repository.Users
+	idGen    *idgen.IdGen
+	settings OAuthSettings
+	now      func() time.Time
+}
+
+func newOAuthService(repo repository.OAuth, users repository.Users, idGen *idgen.IdGen, settings OAuthSettings) OAuth {
+	return &oauthService{
+		repo:     repo,
+		users:    users,
+		idGen:    idGen,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+func (s *oauthService) Discovery() *OpenIDConfiguration {
+	return &OpenIDConfiguration{
+		Issuer:                            s.settings.Issuer,
+		AuthorizationEndpoint:             s.settings.Issuer + "/authorize",
+		TokenEndpoint:                     s.settings.Issuer + "/token",
+		UserInfoEndpoint:                  s.settings.Issuer + "/userinfo",
+		JWKSURI:                           s.settings.Issuer + "/jwks",
+		ScopesSupported:                   supportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IdTokenSigningAlgValuesSupported:  []string{s.settings.IdTokenSigner.Algorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "email", "name", "given_name", "family_name", "nickname"},
+	}
+}
+
+func (s *oauthService) KeySet() *security.JSONWebKeySet {
+	return s.settings.IdTokenSigner.KeySet()
+}
+
+func (i *RegisterOAuthClientInput) Validate() error {
+	return validation.ValidateStruct(i,
+		validation.Field(&i.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&i.RedirectURIs, validation.Required, validation.Each(validation.Required, is.RequestURL)),
+	)
+}
+
+func toOAuthClientOutput(c *core.OAuthClient) *OAuthClientOutput {
+	return &OAuthClientOutput{
+		Id:           c.Id,
+		Name:         c.Name,
+		RedirectURIs: c.RedirectURIs,
+		Public:       c.IsPublic(),
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+func (s *oauthService) RegisterClient(ctx context.Context, input *RegisterOAuthClientInput) (*RegisterOAuthClientOutput, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := &core.OAuthClient{
+		Id:           s.idGen.Generate(),
+		Name:         input.Name,
+		HashedSecret: []byte{},
+		RedirectURIs: input.RedirectURIs,
+		CreatedAt:    s.now(),
+	}
+	var secret string
+	if !input.Public {
+		secret = randomString()
+		client.HashedSecret = hashClientSecret(secret)
+	}
+	if err := s.repo.InsertClient(ctx, client); err != nil {
+		return nil, err
+	}
+	return &RegisterOAuthClientOutput{
+		OAuthClientOutput: *toOAuthClientOutput(client),
+		ClientSecret:      secret,
+	}, nil
+}
+
+func (s *oauthService) ListClients(ctx context.Context) ([]*OAuthClientOutput, error) {
+	clients, err := s.repo.ListClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*OAuthClientOutput, 0, len(clients))
+	for _, c := range clients {
+		result = append(result, toOAuthClientOutput(c))
+	}
+	return result, nil
+}
+
+func (s *oauthService) DeleteClient(ctx context.Context, id string) error {
+	return s.repo.DeleteClient(ctx, id)
+}
+
+func (s *oauthService) Authorize(ctx context.Context, input *OAuthAuthorizeInput, userId string) (*OAuthAuthorizeOutput, error) {
+	client, err := s.repo.GetClient(ctx, input.ClientId)
+	if err == repository.ErrNotFound {
+		return nil, newOAuthError("invalid_request", "unknown client_id")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsRedirectURI(input.RedirectURI) {
+		return nil, newOAuthError("invalid_request", "redirect_uri is not registered for the client")
+	}
+
+	// from here on, errors are reported to the client via redirect
+	if oauthErr := validateAuthorizeInput(input); oauthErr != nil {
+		return &OAuthAuthorizeOutput{RedirectURI: redirectWithParams(input.RedirectURI, url.Values{
+			"error":             {oauthErr.Code},
+			"error_description": {oauthErr.Description},
+			"state":             {input.State},
+		})}, nil
+	}
+
+	if userId == "" {
+		if s.settings.LoginURL == "" {
+			return nil, ErrLoginRequired
+		}
+		returnTo := s.settings.Issuer + "/authorize?" + encodeAuthorizeInput(input)
+		return &OAuthAuthorizeOutput{
+			RedirectURI: redirectWithParams(s.settings.LoginURL, url.Values{"return_to": {returnTo}}),
+		}, nil
+	}
+
+	code := randomString()
+	err = s.repo.InsertAuthorizationCode(ctx, &core.OAuthAuthorizationCode{
+		CodeHash:      hashAuthorizationCode(code),
+		ClientId:      client.Id,
+		UserId:        userId,
+		RedirectURI:   input.RedirectURI,
+		Scope:         input.Scope,
+		Nonce:         input.Nonce,
+		CodeChallenge: input.CodeChallenge,
+		ExpiresAt:     s.now().Add(s.settings.CodeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthAuthorizeOutput{RedirectURI: redirectWithParams(input.RedirectURI, url.Values{
+		"code":  {code},
+		"state": {input.State},
+	})}, nil
+}
+
+func validateAuthorizeInput(input *OAuthAuthorizeInput) *OAuthError {
+	if input.ResponseType != "code" {
+		return newOAuthError("unsupported_response_type", "only the authorization code flow is supported")
+	}
+	if input.CodeChallenge == "" || input.CodeChallengeMethod != "S256" {
+		return newOAuthError("invalid_request", "PKCE with the S256 method is required")
+	}
+	for _, scope := range strings.Fields(input.Scope) {
+		if !isSupportedScope(scope) {
+			return newOAuthError("invalid_scope", "unsupported scope "+scope)
+		}
+	}
+	return nil
+}
+
+func isSupportedScope(scope string) bool {
+	for _, s := range supportedScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func hasScope(scopes string, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeAuthorizeInput(input *OAuthAuthorizeInput) string {
+	params := url.Values{}
+	add := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	add("response_type", input.ResponseType)
+	add("client_id", input.ClientId)
+	add("redirect_uri", input.RedirectURI)
+	add("scope", input.Scope)
+	add("state", input.State)
+	add("nonce", input.Nonce)
+	add("code_challenge", input.CodeChallenge)
+	add("code_challenge_method", input.CodeChallengeMethod)
+	return params.Encode()
+}
+
+// redirectWithParams adds non-empty params to the query of the URI, keeping the existing ones
+func redirectWithParams(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		// registered and configured URIs are validated beforehand
+		return uri
+	}
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query[key] = values
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func (s *oauthService) ExchangeCode(ctx context.Context, input *OAuthTokenInput) (*OAuthGrant, error) {
+	if input.GrantType != "authorization_code" {
+		return nil, newOAuthError("unsupported_grant_type", "")
+	}
+
+	client, err := s.authenticateClient(ctx, input.ClientId, input.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := s.repo.TakeAuthorizationCode(ctx, hashAuthorizationCode(input.Code))
+	if err == repository.ErrNotFound {
+		return nil, newOAuthError("invalid_grant", "unknown authorization code")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if code.ClientId != client.Id || code.RedirectURI != input.RedirectURI || !s.now().Before(code.ExpiresAt) {
+		return nil, newOAuthError("invalid_grant", "authorization code is invalid or expired")
+	}
+	challenge := sha256.Sum256([]byte(input.CodeVerifier))
+	if base64.RawURLEncoding.EncodeToString(challenge[:]) != code.CodeChallenge {
+		return nil, newOAuthError("invalid_grant", "code_verifier does not match the code_challenge")
+	}
+
+	user, err := s.users.GetById(ctx, code.UserId)
+	if err == repository.ErrNotFound {
+		return nil, newOAuthError("invalid_grant", "user no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, newOAuthError("invalid_grant", "user account is disabled")
+	}
+
+	grant := &OAuthGrant{User: user, Client: client, Scope: code.Scope}
+	if hasScope(code.Scope, scopeOpenId) {
+		grant.IdToken, err = s.settings.IdTokenSigner.Sign(user.Id, client.Id, idTokenClaims(user, code))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return grant, nil
+}
+
+func idTokenClaims(user *core.User, code *core.OAuthAuthorizationCode) map[string]interface{} {
+	claims := map[string]interface{}{}
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+	info := toUserInfoClaims(user, code.Scope)
+	if hasScope(code.Scope, scopeEmail) {
+		claims["email"] = info.Email
+	}
+	if hasScope(code.Scope, scopeProfile) {
+		claims["name"] = info.Name
+		claims["given_name"] = info.GivenName
+		claims["family_name"] = info.FamilyName
+		claims["nickname"] = info.Nickname
+	}
+	return claims
+}
+
+// authenticateClient checks the client credentials. Public clients authenticate with their id alone, the possession
+// of the authorization code is proven by PKCE
+func (s *oauthService) authenticateClient(ctx context.Context, clientId, secret string) (*core.OAuthClient, error) {
+	invalidClient := newOAuthError("invalid_client", "client authentication failed")
+	if clientId == "" {
+		return nil, invalidClient
+	}
+	client, err := s.repo.GetClient(ctx, clientId)
+	if err == repository.ErrNotFound {
+		return nil, invalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() {
+		if secret != "" {
+			return nil, invalidClient
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare(hashClientSecret(secret), client.HashedSecret) != 1 {
+		return nil, invalidClient
+	}
+	return client, nil
+}
+
+func (s *oauthService) AuthenticateConfidentialClient(ctx context.Context, clientId string, secret string) (*OAuthClientOutput, error) {
+	if secret == "" {
+		return nil, newOAuthError("invalid_client", "client authentication failed")
+	}
+	client, err := s.authenticateClient(ctx, clientId, secret)
+	if err != nil {
+		return nil, err
+	}
+	return toOAuthClientOutput(client), nil
+}
+
+func (s *oauthService) UserInfo(ctx context.Context, userId string, scope string) (*UserInfoClaims, error) {
+	user, err := s.users.GetById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return toUserInfoClaims(user, scope), nil
+}
+
+// toUserInfoClaims returns the claims the scope grants access to: the email scope covers the email address, and the
+// profile scope the names. The subject is always included
+func toUserInfoClaims(user *core.User, scope string) *UserInfoClaims {
+	claims := &UserInfoClaims{Subject: user.Id}
+	if hasScope(scope, scopeEmail) {
+		claims.Email = user.Email
+	}
+	if hasScope(scope, scopeProfile) {
+		claims.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims.GivenName = user.FirstName
+		claims.FamilyName = user.LastName
+		claims.Nickname = user.DisplayName
+	}
+	return claims
+}
+
+// Client secrets and authorization codes are random 256 bit values, so a plain hash is enough to protect them at rest
+func hashClientSecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
+
+func hashAuthorizationCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
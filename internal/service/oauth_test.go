@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+const (
+	testIssuer      = "https://localhost:8080/auth"
+	testRedirectURI = "https://app.example.com/callback"
+	testVerifier    = "a-code-verifier-which-is-long-enough-for-pkce-1234567890"
+)
+
+func testChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func getOAuthService(t *testing.T, loginURL string) *oauthService {
+	t.Helper()
+	repos := fake_repo.New()
+	gen, err := idgen.New(1)
+	require.NoError(t, err)
+	key, err := security.GenerateIdTokenKey()
+	require.NoError(t, err)
+	signer, err := security.NewIdTokenSigner(testIssuer, time.Hour, key)
+	require.NoError(t, err)
+	return newOAuthService(repos.OAuth, repos.Users, gen, OAuthSettings{
+		Issuer:        testIssuer,
+		LoginURL:      loginURL,
+		CodeTTL:       time.Minute,
+		IdTokenSigner: signer,
+	}).(*oauthService)
+}
+
+func validAuthorizeInput(clientId string) *OAuthAuthorizeInput {
+	return &OAuthAuthorizeInput{
+		ResponseType:        "code",
+		ClientId:            clientId,
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid email profile",
+		State:               "client-state",
+		Nonce:               "client-nonce",
+		CodeChallenge:       testChallenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+// authorize runs the authorization request for the sample user and returns the issued code
+func authorize(t *testing.T, s *oauthService, input *OAuthAuthorizeInput) string {
+	t.Helper()
+	output, err := s.Authorize(context.Background(), input, fake_repo.SampleUser.Id)
+	require.NoError(t, err)
+	redirect, err := url.Parse(output.RedirectURI)
+	require.NoError(t, err)
+	require.Equal(t, testRedirectURI, redirect.Scheme+"://"+redirect.Host+redirect.Path)
+	require.Equal(t, input.State, redirect.Query().Get("state"))
+	require.NotEmpty(t, redirect.Query().Get("code"), redirect.Query().Get("error"))
+	return redirect.Query().Get("code")
+}
+
+func idTokenVerifier(t *testing.T, s *oauthService, clientId string) *oidc.IDTokenVerifier {
+	t.Helper()
+	jwk := s.KeySet().Keys[0]
+	n, err := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+	require.NoError(t, err)
+	e, err := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+	require.NoError(t, err)
+	keySet := &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{
+		&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())},
+	}}
+	return oidc.NewVerifier(s.Discovery().Issuer, keySet, &oidc.Config{ClientID: clientId})
+}
+
+func TestOAuthService_Clients(t *testing.T) {
+	s := getOAuthService(t, "")
+	ctx := context.Background()
+
+	_, err := s.RegisterClient(ctx, &RegisterOAuthClientInput{Name: "no redirect"})
+	var validationErrors validation.Errors
+	assert.ErrorAs(t, err, &validationErrors)
+
+	confidential, err := s.RegisterClient(ctx, &RegisterOAuthClientInput{
+		Name:         "reports",
+		RedirectURIs: []string{testRedirectURI},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, confidential.ClientSecret)
+	assert.False(t, confidential.Public)
+
+	public, err := s.RegisterClient(ctx, &RegisterOAuthClientInput{
+		Name:         "mobile",
+		RedirectURIs: []string{"com.example.app:/callback"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, public.ClientSecret)
+	assert.True(t, public.Public)
+
+	clients, err := s.ListClients(ctx)
+	require.NoError(t, err)
+	assert.Len(t, clients, 2)
+
+	require.NoError(t, s.DeleteClient(ctx, public.Id))
+	assert.Equal(t, repository.ErrNotFound, s.DeleteClient(ctx, public.Id))
+}
+
+func TestOAuthService_Authorize(t *testing.T) {
+	cases := map[string]struct {
+		modify    func(input *OAuthAuthorizeInput)
+		userId    string
+		loginURL  string
+		wantErr   error
+		wantError string
+		wantLogin bool
+	}{
+		"unknown client": {
+			modify:  func(input *OAuthAuthorizeInput) { input.ClientId = "unknown" },
+			userId:  fake_repo.SampleUser.Id,
+			wantErr: &OAuthError{Code: "invalid_request", Description: "unknown client_id"},
+		},
+		"unregistered redirect uri": {
+			modify:  func(input *OAuthAuthorizeInput) { input.RedirectURI = "https://evil.example.com/callback" },
+			userId:  fake_repo.SampleUser.Id,
+			wantErr: &OAuthError{Code: "invalid_request", Description: "redirect_uri is not registered for the client"},
+		},
+		"implicit flow": {
+			modify:    func(input *OAuthAuthorizeInput) { input.ResponseType = "token" },
+			userId:    fake_repo.SampleUser.Id,
+			wantError: "unsupported_response_type",
+		},
+		"no pkce": {
+			modify:    func(input *OAuthAuthorizeInput) { input.CodeChallenge = "" },
+			userId:    fake_repo.SampleUser.Id,
+			wantError: "invalid_request",
+		},
+		"plain pkce": {
+			modify:    func(input *OAuthAuthorizeInput) { input.CodeChallengeMethod = "plain" },
+			userId:    fake_repo.SampleUser.Id,
+			wantError: "invalid_request",
+		},
+		"unsupported scope": {
+			modify:    func(input *OAuthAuthorizeInput) { input.Scope = "openid admin" },
+			userId:    fake_repo.SampleUser.Id,
+			wantError: "invalid_scope",
+		},
+		"not logged in": {
+			wantErr: ErrLoginRequired,
+		},
+		"not logged in with login page": {
+			loginURL:  "https://localhost:8080/login",
+			wantLogin: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := getOAuthService(t, tc.loginURL)
+			client, err := s.RegisterClient(context.Background(), &RegisterOAuthClientInput{
+				Name:         "reports",
+				RedirectURIs: []string{testRedirectURI},
+			})
+			require.NoError(t, err)
+			input := validAuthorizeInput(client.Id)
+			if tc.modify != nil {
+				tc.modify(input)
+			}
+
+			output, err := s.Authorize(context.Background(), input, tc.userId)
+			if tc.wantErr != nil {
+				assert.Equal(t, tc.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			redirect, err := url.Parse(output.RedirectURI)
+			require.NoError(t, err)
+
+			if tc.wantLogin {
+				assert.Equal(t, "https://localhost:8080/login", redirect.Scheme+"://"+redirect.Host+redirect.Path)
+				returnTo, err := url.Parse(redirect.Query().Get("return_to"))
+				require.NoError(t, err)
+				assert.Equal(t, testIssuer+"/authorize", returnTo.Scheme+"://"+returnTo.Host+returnTo.Path)
+				assert.Equal(t, client.Id, returnTo.Query().Get("client_id"))
+				assert.Equal(t, input.CodeChallenge, returnTo.Query().Get("code_challenge"))
+				return
+			}
+			assert.Equal(t, tc.wantError, redirect.Query().Get("error"))
+			assert.Equal(t, input.State, redirect.Query().Get("state"))
+			assert.Empty(t, redirect.Query().Get("code"))
+		})
+	}
+}
+
+func TestOAuthService_ExchangeCode(t *testing.T) {
+	s := getOAuthService(t, "")
+	ctx := context.Background()
+	client, err := s.RegisterClient(ctx, &RegisterOAuthClientInput{
+		Name:         "reports",
+		RedirectURIs: []string{testRedirectURI},
+	})
+	require.NoError(t, err)
+
+	tokenInput := func(code string) *OAuthTokenInput {
+		return &OAuthTokenInput{
+			GrantType:    "authorization_code",
+			Code:         code,
+			RedirectURI:  testRedirectURI,
+			ClientId:     client.Id,
+			ClientSecret: client.ClientSecret,
+			CodeVerifier: testVerifier,
+		}
+	}
+
+	code := authorize(t, s, validAuthorizeInput(client.Id))
+	grant, err := s.ExchangeCode(ctx, tokenInput(code))
+	require.NoError(t, err)
+	assert.Equal(t, fake_repo.SampleUser.Id, grant.User.Id)
+	assert.Equal(t, client.Id, grant.Client.Id)
+	assert.Equal(t, "openid email profile", grant.Scope)
+
+	idToken, err := idTokenVerifier(t, s, client.Id).Verify(ctx, grant.IdToken)
+	require.NoError(t, err)
+	assert.Equal(t, fake_repo.SampleUser.Id, idToken.Subject)
+	assert.Equal(t, "client-nonce", idToken.Nonce)
+	var claims UserInfoClaims
+	require.NoError(t, idToken.Claims(&claims))
+	assert.Equal(t, fake_repo.SampleUser.Email, claims.Email)
+	assert.Equal(t, "John Doe", claims.Name)
+
+	// codes are single use
+	_, err = s.ExchangeCode(ctx, tokenInput(code))
+	assert.Equal(t, "invalid_grant", err.(*OAuthError).Code)
+
+	cases := map[string]struct {
+		modify   func(input *OAuthTokenInput)
+		wantCode string
+	}{
+		"wrong grant type": {
+			modify:   func(input *OAuthTokenInput) { input.GrantType = "password" },
+			wantCode: "unsupported_grant_type",
+		},
+		"wrong secret": {
+			modify:   func(input *OAuthTokenInput) { input.ClientSecret = "wrong" },
+			wantCode: "invalid_client",
+		},
+		"no secret": {
+			modify:   func(input *OAuthTokenInput) { input.ClientSecret = "" },
+			wantCode: "invalid_client",
+		},
+		"wrong verifier": {
+			modify:   func(input *OAuthTokenInput) { input.CodeVerifier = "wrong" },
+			wantCode: "invalid_grant",
+		},
+		"wrong redirect uri": {
+			modify:   func(input *OAuthTokenInput) { input.RedirectURI = "https://app.example.com/other" },
+			wantCode: "invalid_grant",
+		},
+		"unknown code": {
+			modify:   func(input *OAuthTokenInput) { input.Code = "unknown" },
+			wantCode: "invalid_grant",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			input := tokenInput(authorize(t, s, validAuthorizeInput(client.Id)))
+			tc.modify(input)
+			_, err := s.ExchangeCode(ctx, input)
+			require.IsType(t, &OAuthError{}, err)
+			assert.Equal(t, tc.wantCode, err.(*OAuthError).Code)
+		})
+	}
+
+	t.Run("expired code", func(t *testing.T) {
+		input := tokenInput(authorize(t, s, validAuthorizeInput(client.Id)))
+		s.now = func() time.Time { return time.Now().Add(time.Hour) }
+		defer func() { s.now = time.Now }()
+		_, err := s.ExchangeCode(ctx, input)
+		require.IsType(t, &OAuthError{}, err)
+		assert.Equal(t, "invalid_grant", err.(*OAuthError).Code)
+	})
+}
+
+func TestOAuthService_ExchangeCode_PublicClient(t *testing.T) {
+	s := getOAuthService(t, "")
+	ctx := context.Background()
+	client, err := s.RegisterClient(ctx, &RegisterOAuthClientInput{
+		Name:         "spa",
+		RedirectURIs: []string{testRedirectURI},
+		Public:       true,
+	})
+	require.NoError(t, err)
+
+	// without the openid scope, this is plain OAuth and no ID token is issued
+	input := validAuthorizeInput(client.Id)
+	input.Scope = ""
+	code := authorize(t, s, input)
+	grant, err := s.ExchangeCode(ctx, &OAuthTokenInput{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		ClientId:     client.Id,
+		CodeVerifier: testVerifier,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, fake_repo.SampleUser.Id, grant.User.Id)
+	assert.Empty(t, grant.IdToken)
+}
+
+func TestOAuthService_AuthenticateConfidentialClient(t *testing.T) {
+	s := getOAuthService(t, "")
+	ctx := context.Background()
+	confidential, err := s.RegisterClient(ctx, &RegisterOAuthClientInput{
+		Name:         "reports",
+		RedirectURIs: []string{testRedirectURI},
+	})
+	require.NoError(t, err)
+	public, err := s.RegisterClient(ctx, &RegisterOAuthClientInput{
+		Name:         "mobile",
+		RedirectURIs: []string{"com.example.app:/callback"},
+		Public:       true,
+	})
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		clientId string
+		secret   string
+		wantErr  bool
+	}{
+		"success":        {clientId: confidential.Id, secret: confidential.ClientSecret},
+		"wrong_secret":   {clientId: confidential.Id, secret: "wrong", wantErr: true},
+		"no_secret":      {clientId: confidential.Id, secret: "", wantErr: true},
+		"public_client":  {clientId: public.Id, secret: "", wantErr: true},
+		"unknown_client": {clientId: "unknown", secret: "secret", wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			client, err := s.AuthenticateConfidentialClient(ctx, tc.clientId, tc.secret)
+			if tc.wantErr {
+				var oauthErr *OAuthError
+				require.ErrorAs(t, err, &oauthErr)
+				assert.Equal(t, "invalid_client", oauthErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, confidential.Id, client.Id)
+		})
+	}
+}
+
+func TestOAuthService_UserInfo(t *testing.T) {
+	s := getOAuthService(t, "")
+	user := fake_repo.SampleUser
+
+	cases := map[string]struct {
+		scope    string
+		expected *UserInfoClaims
+	}{
+		"openid_only": {
+			scope:    "openid",
+			expected: &UserInfoClaims{Subject: user.Id},
+		},
+		"email": {
+			scope:    "openid email",
+			expected: &UserInfoClaims{Subject: user.Id, Email: user.Email},
+		},
+		"profile": {
+			scope: "openid profile",
+			expected: &UserInfoClaims{
+				Subject:    user.Id,
+				Name:       "John Doe",
+				GivenName:  "John",
+				FamilyName: "Doe",
+				Nickname:   "JonnyD",
+			},
+		},
+		"all": {
+			scope: "openid profile email",
+			expected: &UserInfoClaims{
+				Subject:    user.Id,
+				Email:      user.Email,
+				Name:       "John Doe",
+				GivenName:  "John",
+				FamilyName: "Doe",
+				Nickname:   "JonnyD",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			claims, err := s.UserInfo(context.Background(), user.Id, tc.scope)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, claims)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+	"golang.org/x/oauth2"
+)
+
+// oidcClient is a discovered external provider
+type oidcClient struct {
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// oidcDiscovery holds the client of one provider once its discovery succeeded. The mutex lets concurrent first uses
+// wait for one discovery without blocking the other providers. Failures are not kept, the next use tries again
+type oidcDiscovery struct {
+	mu     sync.Mutex
+	client *oidcClient
+}
+
+type oidcService struct {
+	identities repository.Identities
+	users      repository.Users
+	tx         repository.Transactor
+	idGen      *idgen.IdGen
+	settings   map[string]OIDCProviderSettings
+	stateTTL   time.Duration
+	postLogin  string
+	httpClient *http.Client
+	now        func() time.Time
+	// discoveries has an entry per provider, the map itself is not modified
+	discoveries map[string]*oidcDiscovery
+}
+
+func newOIDCService(
+	identities repository.Identities,
+	users repository.Users,
+	tx repository.Transactor,
+	idGen *idgen.IdGen,
+	settings OIDCSettings,
+) OIDC {
+	providers := make(map[string]OIDCProviderSettings, len(settings.Providers))
+	discoveries := make(map[string]*oidcDiscovery, len(settings.Providers))
+	for _, p := range settings.Providers {
+		providers[p.Name] = p
+		discoveries[p.Name] = &oidcDiscovery{}
+	}
+	return &oidcService{
+		identities:  identities,
+		users:       users,
+		tx:          tx,
+		idGen:       idGen,
+		settings:    providers,
+		stateTTL:    settings.StateTTL,
+		postLogin:   settings.PostLoginURL,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+		discoveries: discoveries,
+	}
+}
+
+// getClient runs provider discovery on first use, so that an unavailable provider does not prevent server start
+func (s *oidcService) getClient(ctx context.Context, name string) (*oidcClient, error) {
+	settings, ok := s.settings[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	discovery := s.discoveries[name]
+	discovery.mu.Lock()
+	defer discovery.mu.Unlock()
+	if discovery.client != nil {
+		return discovery.client, nil
+	}
+
+	// the provider keeps the context to fetch its signing keys later on, so the request must not cancel it. The HTTP
+	// client timeout bounds the discovery instead
+	provider, err := oidc.NewProvider(oidc.ClientContext(detached{ctx}, s.httpClient), settings.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discovery of identity provider %q failed: %w", name, err)
+	}
+	scopes := settings.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+	client := &oidcClient{
+		oauth2: &oauth2.Config{
+			ClientID:     settings.ClientID,
+			ClientSecret: settings.ClientSecret,
+			RedirectURL:  settings.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, scopes...),
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: settings.ClientID}),
+	}
+	discovery.client = client
+	return client, nil
+}
+
+func (s *oidcService) Authorize(ctx context.Context, provider string) (*OIDCAuthorizeOutput, error) {
+	client, err := s.getClient(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &core.OIDCLoginState{
+		State:        randomString(),
+		Provider:     provider,
+		Nonce:        randomString(),
+		CodeVerifier: randomString(),
+		ExpiresAt:    s.now().Add(s.stateTTL),
+	}
+	if err = s.identities.InsertLoginState(ctx, state); err != nil {
+		return nil, err
+	}
+
+	challenge := sha256.Sum256([]byte(state.CodeVerifier))
+	url := client.oauth2.AuthCodeURL(state.State,
+		oidc.Nonce(state.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+	return &OIDCAuthorizeOutput{URL: url, State: state.State}, nil
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Name          string `json:"name"`
+}
+
+func (s *oidcService) Callback(ctx context.Context, provider string, state string, code string) (*OIDCCallbackOutput, error) {
+	client, err := s.getClient(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	loginState, err := s.identities.TakeLoginState(ctx, state)
+	if err == repository.ErrNotFound {
+		return nil, fmt.Errorf("%w: unknown state", ErrExternalLogin)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if loginState.Provider != provider || !s.now().Before(loginState.ExpiresAt) {
+		return nil, fmt.Errorf("%w: unknown state", ErrExternalLogin)
+	}
+
+	token, err := client.oauth2.Exchange(oidc.ClientContext(ctx, s.httpClient), code,
+		oauth2.SetAuthURLParam("code_verifier", loginState.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: code exchange: %s", ErrExternalLogin, err)
+	}
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: no id_token in token response", ErrExternalLogin)
+	}
+	idToken, err := client.verifier.Verify(oidc.ClientContext(ctx, s.httpClient), rawIdToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExternalLogin, err)
+	}
+	if idToken.Nonce != loginState.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrExternalLogin)
+	}
+	var claims oidcClaims
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExternalLogin, err)
+	}
+
+	user, err := s.linkOrCreateUser(ctx, provider, idToken.Subject, &claims)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCCallbackOutput{User: user, PostLoginURL: s.postLogin}, nil
+}
+
+// linkOrCreateUser finds the user linked to the external identity. On the first login, the identity is linked to
+// the existing user with the same email, or to a new user. Both require the email to be verified by the provider
+func (s *oidcService) linkOrCreateUser(ctx context.Context, provider, subject string, claims *oidcClaims) (*core.User, error) {
+	identity, err := s.identities.GetByProviderSubject(ctx, provider, subject)
+	if err == nil {
+		return s.users.GetById(ctx, identity.UserId)
+	}
+	if err != repository.ErrNotFound {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	// a new user without the identity could never log in with the provider again
+	var user *core.User
+	err = s.tx.WithTx(ctx, func(repos *repository.Repositories) error {
+		var err error
+		user, err = repos.Users.GetByEmail(ctx, claims.Email)
+		if err == repository.ErrNotFound {
+			user = &core.User{
+				Id:               s.idGen.Generate(),
+				Email:            claims.Email,
+				FirstName:        claims.GivenName,
+				LastName:         claims.FamilyName,
+				DisplayName:      claims.Name,
+				RegistrationDate: s.now(),
+				// no password: the user can only log in via external providers until a password is set
+				HashedPassword: []byte{},
+				Roles:          []security.Role{security.Student},
+			}
+			err = repos.Users.Insert(ctx, user)
+		}
+		if err != nil {
+			return err
+		}
+
+		return repos.Identities.Insert(ctx, &core.UserIdentity{
+			Provider:  provider,
+			Subject:   subject,
+			UserId:    user.Id,
+			Email:     claims.Email,
+			CreatedAt: s.now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func randomString() string {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		// crypto/rand failures are not recoverable
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
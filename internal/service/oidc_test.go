@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+)
+
+const (
+	testOIDCProvider     = "test-idp"
+	testOIDCClientID     = "course-watch"
+	testOIDCKeyId        = "test-key"
+	testOIDCPostLoginURL = "https://example.com/login/oidc"
+)
+
+// fakeIdentityProvider is a minimal OpenID Connect provider supporting the authorization code flow with PKCE
+type fakeIdentityProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]fakeAuthorization
+}
+
+type fakeAuthorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newFakeIdentityProvider(t *testing.T) *fakeIdentityProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &fakeIdentityProvider{key: key, codes: map[string]fakeAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *fakeIdentityProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *fakeIdentityProvider) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": testOIDCKeyId,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *fakeIdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	p.mu.Lock()
+	authorization, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != authorization.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, authorization.claims)
+	token.Header["kid"] = testOIDCKeyId
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize plays the part of the user approving the request at the authorization endpoint and returns the code
+// which would be passed to the callback
+func (p *fakeIdentityProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := u.Query()
+	require.Equal(t, p.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	now := time.Now()
+	all := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   query.Get("client_id"),
+		"nonce": query.Get("nonce"),
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = fakeAuthorization{challenge: query.Get("code_challenge"), claims: all}
+	p.mu.Unlock()
+	return code
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func getOIDCService(t *testing.T) (*oidcService, *fakeIdentityProvider) {
+	t.Helper()
+	provider := newFakeIdentityProvider(t)
+	repos := fake_repo.New()
+	gen, err := idgen.New(1)
+	require.NoError(t, err)
+	s := newOIDCService(repos.Identities, repos.Users, repos.Transactor, gen, OIDCSettings{
+		Providers: []OIDCProviderSettings{{
+			Name:         testOIDCProvider,
+			Issuer:       provider.server.URL,
+			ClientID:     testOIDCClientID,
+			ClientSecret: "secret",
+			RedirectURL:  "https://example.com/api/v1/auth/oidc/test-idp/callback",
+		}},
+		StateTTL:     10 * time.Minute,
+		PostLoginURL: testOIDCPostLoginURL,
+	}).(*oidcService)
+	return s, provider
+}
+
+func TestOIDCService_Login(t *testing.T) {
+	cases := map[string]struct {
+		claims    jwt.MapClaims
+		wantErr   error
+		wantEmail string
+		wantNew   bool
+	}{
+		"links existing user by verified email": {
+			claims:    jwt.MapClaims{"sub": "subject-1", "email": fake_repo.SampleUser.Email, "email_verified": true},
+			wantEmail: fake_repo.SampleUser.Email,
+		},
+		"creates new user": {
+			claims: jwt.MapClaims{
+				"sub":            "subject-2",
+				"email":          "new.user@example.com",
+				"email_verified": true,
+				"given_name":     "New",
+				"family_name":    "User",
+				"name":           "newbie",
+			},
+			wantEmail: "new.user@example.com",
+			wantNew:   true,
+		},
+		"rejects unverified email": {
+			claims:  jwt.MapClaims{"sub": "subject-3", "email": fake_repo.SampleUser.Email, "email_verified": false},
+			wantErr: ErrEmailNotVerified,
+		},
+		"rejects missing email": {
+			claims:  jwt.MapClaims{"sub": "subject-4"},
+			wantErr: ErrEmailNotVerified,
+		},
+		"rejects wrong nonce": {
+			claims:  jwt.MapClaims{"sub": "subject-5", "email": "x@example.com", "email_verified": true, "nonce": "forged"},
+			wantErr: ErrExternalLogin,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, provider := getOIDCService(t)
+			ctx := context.Background()
+
+			authorization, err := s.Authorize(ctx, testOIDCProvider)
+			require.NoError(t, err)
+			code := provider.authorize(t, authorization.URL, tc.claims)
+
+			output, err := s.Callback(ctx, testOIDCProvider, authorization.State, code)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testOIDCPostLoginURL, output.PostLoginURL)
+			user := output.User
+			assert.Equal(t, tc.wantEmail, user.Email)
+			if tc.wantNew {
+				assert.NotEqual(t, fake_repo.SampleUser.Id, user.Id)
+				assert.Equal(t, "New", user.FirstName)
+			} else {
+				assert.Equal(t, fake_repo.SampleUser.Id, user.Id)
+			}
+
+			// the identity stays linked, even if the email changes at the provider
+			authorization, err = s.Authorize(ctx, testOIDCProvider)
+			require.NoError(t, err)
+			claims := jwt.MapClaims{"sub": tc.claims["sub"], "email": "changed@example.com"}
+			code = provider.authorize(t, authorization.URL, claims)
+			again, err := s.Callback(ctx, testOIDCProvider, authorization.State, code)
+			require.NoError(t, err)
+			assert.Equal(t, user.Id, again.User.Id)
+		})
+	}
+}
+
+func TestOIDCService_State(t *testing.T) {
+	s, provider := getOIDCService(t)
+	ctx := context.Background()
+	claims := jwt.MapClaims{"sub": "subject", "email": fake_repo.SampleUser.Email, "email_verified": true}
+
+	_, err := s.Authorize(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+
+	authorization, err := s.Authorize(ctx, testOIDCProvider)
+	require.NoError(t, err)
+	code := provider.authorize(t, authorization.URL, claims)
+
+	_, err = s.Callback(ctx, testOIDCProvider, "unknown-state", code)
+	assert.ErrorIs(t, err, ErrExternalLogin)
+
+	_, err = s.Callback(ctx, testOIDCProvider, authorization.State, code)
+	require.NoError(t, err)
+
+	// state is single use
+	code = provider.authorize(t, authorization.URL, claims)
+	_, err = s.Callback(ctx, testOIDCProvider, authorization.State, code)
+	assert.ErrorIs(t, err, ErrExternalLogin)
+
+	// expired state
+	authorization, err = s.Authorize(ctx, testOIDCProvider)
+	require.NoError(t, err)
+	code = provider.authorize(t, authorization.URL, claims)
+	s.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, err = s.Callback(ctx, testOIDCProvider, authorization.State, code)
+	assert.ErrorIs(t, err, ErrExternalLogin)
+}
+
+func TestOIDCService_Discovery(t *testing.T) {
+	working := newFakeIdentityProvider(t)
+	discoveryStarted := make(chan struct{})
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(discoveryStarted)
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(slow.Close)
+	var flaky *httptest.Server
+	failures := 1
+	flaky = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                 flaky.URL,
+			"authorization_endpoint": flaky.URL + "/authorize",
+			"token_endpoint":         flaky.URL + "/token",
+			"jwks_uri":               flaky.URL + "/jwks",
+		})
+	}))
+	t.Cleanup(flaky.Close)
+
+	repos := fake_repo.New()
+	gen, err := idgen.New(1)
+	require.NoError(t, err)
+	var providers []OIDCProviderSettings
+	for name, issuer := range map[string]string{"working": working.server.URL, "slow": slow.URL, "flaky": flaky.URL} {
+		providers = append(providers, OIDCProviderSettings{
+			Name:        name,
+			Issuer:      issuer,
+			ClientID:    testOIDCClientID,
+			RedirectURL: "https://example.com/callback",
+		})
+	}
+	s := newOIDCService(repos.Identities, repos.Users, repos.Transactor, gen, OIDCSettings{Providers: providers}).(*oidcService)
+	ctx := context.Background()
+
+	slowErr := make(chan error, 1)
+	go func() {
+		_, err := s.getClient(ctx, "slow")
+		slowErr <- err
+	}()
+	<-discoveryStarted
+
+	// a provider being discovered does not hold up the others
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.getClient(ctx, "working")
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("discovery of the working provider waited for the slow one")
+	}
+	close(release)
+	assert.Error(t, <-slowErr)
+
+	// failures are not cached, the next use tries again
+	_, err = s.getClient(ctx, "flaky")
+	assert.Error(t, err)
+	first, err := s.getClient(ctx, "flaky")
+	require.NoError(t, err)
+	second, err := s.getClient(ctx, "flaky")
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/password"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+type scimService struct {
+	users    repository.Users
+	sessions repository.Sessions
+	idGen    *idgen.IdGen
+	hasher   password.Hasher
+	settings SCIMSettings
+	now      func() time.Time
+}
+
+func newSCIMService(users repository.Users, sessions repository.Sessions, idGen *idgen.IdGen, hasher password.Hasher, settings SCIMSettings) SCIM {
+	return &scimService{
+		users:    users,
+		sessions: sessions,
+		idGen:    idGen,
+		hasher:   hasher,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+func (s *scimService) Authenticate(token string) bool {
+	if s.settings.Token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.settings.Token)) == 1
+}
+
+func toSCIMUser(user *core.User) *SCIMUser {
+	active := !user.Disabled
+	return &SCIMUser{
+		Schemas:    []string{SCIMUserSchema},
+		Id:         user.Id,
+		ExternalId: user.ExternalId,
+		UserName:   user.Email,
+		Name: &SCIMName{
+			Formatted:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+			GivenName:  user.FirstName,
+			FamilyName: user.LastName,
+		},
+		DisplayName: user.DisplayName,
+		Emails:      []SCIMEmail{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      user.RegistrationDate,
+		},
+	}
+}
+
+func validateSCIMEmail(email string) error {
+	err := validation.Validate(email, validation.Required, is.EmailFormat)
+	if err != nil {
+		return newSCIMError("invalidValue", "userName must be an email address: "+err.Error())
+	}
+	return nil
+}
+
+func (s *scimService) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := s.users.GetByEmail(ctx, email)
+	if err == nil {
+		return newSCIMError("uniqueness", "a user with this userName already exists")
+	}
+	if err != repository.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+func (s *scimService) CreateUser(ctx context.Context, input *SCIMUser) (*SCIMUser, error) {
+	if err := validateSCIMEmail(input.UserName); err != nil {
+		return nil, err
+	}
+	if err := s.checkEmailAvailable(ctx, input.UserName); err != nil {
+		return nil, err
+	}
+
+	user := &core.User{
+		Id:               s.idGen.Generate(),
+		Email:            input.UserName,
+		DisplayName:      input.DisplayName,
+		RegistrationDate: s.now(),
+		HashedPassword:   []byte{},
+		Roles:            []security.Role{security.Student},
+		Disabled:         input.Active != nil && !*input.Active,
+		ExternalId:       input.ExternalId,
+	}
+	if input.Name != nil {
+		user.FirstName = input.Name.GivenName
+		user.LastName = input.Name.FamilyName
+	}
+	if input.Password != "" {
+		hash, err := s.hasher.Hash(input.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.HashedPassword = hash
+	}
+
+	if err := s.users.Insert(ctx, user); err != nil {
+		return nil, err
+	}
+	return toSCIMUser(user), nil
+}
+
+func (s *scimService) GetUser(ctx context.Context, id string) (*SCIMUser, error) {
+	user, err := s.users.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSCIMUser(user), nil
+}
+
+func (s *scimService) ListUsers(ctx context.Context, input *SCIMListInput) (*SCIMListResponse, error) {
+	filter, err := parseSCIMFilter(input.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex := input.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count := s.settings.MaxPageSize
+	if input.Count != nil && *input.Count >= 0 && *input.Count < count {
+		count = *input.Count
+	}
+
+	users, total, err := s.users.List(ctx, filter, startIndex-1, count)
+	if err != nil {
+		return nil, err
+	}
+	result := &SCIMListResponse{
+		Schemas:      []string{SCIMListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(users),
+		Resources:    make([]*SCIMUser, 0, len(users)),
+	}
+	for _, user := range users {
+		result.Resources = append(result.Resources, toSCIMUser(user))
+	}
+	return result, nil
+}
+
+// parseSCIMFilter supports the subset of the filter syntax used by provisioning systems for lookups: "eq"
+// comparisons joined with "and", e.g. userName eq "john@example.com" and active eq true
+func parseSCIMFilter(filter string) (*repository.UserFilter, error) {
+	result := &repository.UserFilter{}
+	tokens, err := tokenizeSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return result, nil
+	}
+	if len(tokens)%4 != 3 {
+		return nil, newSCIMError("invalidFilter", "expected comparisons joined with 'and'")
+	}
+
+	for i := 0; i < len(tokens); i += 4 {
+		if i > 0 && !strings.EqualFold(tokens[i-1], "and") {
+			return nil, newSCIMError("invalidFilter", "only the 'and' logical operator is supported")
+		}
+		attr, op, value := tokens[i], tokens[i+1], tokens[i+2]
+		if !strings.EqualFold(op, "eq") {
+			return nil, newSCIMError("invalidFilter", fmt.Sprintf("unsupported operator %q", op))
+		}
+
+		if strings.EqualFold(attr, "active") {
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, newSCIMError("invalidFilter", "active must be compared with true or false")
+			}
+			disabled := !active
+			result.Disabled = &disabled
+			continue
+		}
+
+		str, err := strconv.Unquote(value)
+		if err != nil || !strings.HasPrefix(value, `"`) {
+			return nil, newSCIMError("invalidFilter", fmt.Sprintf("%s must be compared with a string", attr))
+		}
+		switch strings.ToLower(attr) {
+		case "username", "emails", "emails.value":
+			result.Email = &str
+		case "externalid":
+			result.ExternalId = &str
+		case "displayname":
+			result.DisplayName = &str
+		case "name.givenname":
+			result.FirstName = &str
+		case "name.familyname":
+			result.LastName = &str
+		default:
+			return nil, newSCIMError("invalidFilter", fmt.Sprintf("filtering by %q is not supported", attr))
+		}
+	}
+	return result, nil
+}
+
+// tokenizeSCIMFilter splits the filter into whitespace separated tokens, keeping quoted strings (with quotes and
+// escapes) intact
+func tokenizeSCIMFilter(filter string) ([]string, error) {
+	var tokens []string
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+		case runes[i] == '"':
+			start := i
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(runes) {
+				return nil, newSCIMError("invalidFilter", "unterminated string")
+			}
+			i++
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+	return tokens, nil
+}
+
+func (s *scimService) PatchUser(ctx context.Context, id string, input *SCIMPatchInput) (*SCIMUser, error) {
+	stored, err := s.users.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// changes are applied to a copy, the stored user must stay intact if an operation fails
+	updated := *stored
+	user := &updated
+
+	for _, op := range input.Operations {
+		if err = applySCIMPatchOperation(user, &op); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Email != stored.Email {
+		if err = validateSCIMEmail(user.Email); err != nil {
+			return nil, err
+		}
+		if err = s.checkEmailAvailable(ctx, user.Email); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.users.UpdateAccount(ctx, user); err != nil {
+		return nil, err
+	}
+	if user.Disabled && !stored.Disabled {
+		if err = s.sessions.RevokeAllByUser(ctx, user.Id, "", s.now()); err != nil {
+			return nil, err
+		}
+	}
+	return toSCIMUser(user), nil
+}
+
+func applySCIMPatchOperation(user *core.User, op *SCIMPatchOperation) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		if op.Path == "" {
+			// the value is a partial resource, e.g. {"active": false, "name": {"givenName": "John"}}
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return newSCIMError("invalidValue", "value must be an object when path is omitted")
+			}
+			for attr, value := range values {
+				if err := setSCIMAttribute(user, attr, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return setSCIMAttribute(user, op.Path, op.Value)
+	case "remove":
+		switch strings.ToLower(op.Path) {
+		case "externalid":
+			user.ExternalId = ""
+		case "displayname":
+			user.DisplayName = ""
+		case "name.givenname":
+			user.FirstName = ""
+		case "name.familyname":
+			user.LastName = ""
+		default:
+			return newSCIMError("mutability", fmt.Sprintf("%q cannot be removed", op.Path))
+		}
+		return nil
+	default:
+		return newSCIMError("invalidSyntax", fmt.Sprintf("unsupported operation %q", op.Op))
+	}
+}
+
+func setSCIMAttribute(user *core.User, attr string, value json.RawMessage) error {
+	lower := strings.ToLower(attr)
+	switch {
+	case lower == "active":
+		active, err := parseSCIMBool(value)
+		if err != nil {
+			return err
+		}
+		user.Disabled = !active
+	case lower == "name":
+		var name SCIMName
+		if err := json.Unmarshal(value, &name); err != nil {
+			return newSCIMError("invalidValue", "name must be an object")
+		}
+		user.FirstName = name.GivenName
+		user.LastName = name.FamilyName
+	case lower == "emails":
+		var emails []SCIMEmail
+		if err := json.Unmarshal(value, &emails); err != nil || len(emails) == 0 {
+			return newSCIMError("invalidValue", "emails must be a non-empty array")
+		}
+		user.Email = emails[0].Value
+		for _, email := range emails {
+			if email.Primary {
+				user.Email = email.Value
+			}
+		}
+	// emails[type eq "work"].value etc. The user has a single email, so the value filter is irrelevant
+	case lower == "username" || lower == "emails.value" || strings.HasPrefix(lower, "emails["):
+		return setSCIMString(&user.Email, attr, value)
+	case lower == "externalid":
+		return setSCIMString(&user.ExternalId, attr, value)
+	case lower == "displayname":
+		return setSCIMString(&user.DisplayName, attr, value)
+	case lower == "name.givenname":
+		return setSCIMString(&user.FirstName, attr, value)
+	case lower == "name.familyname":
+		return setSCIMString(&user.LastName, attr, value)
+	default:
+		return newSCIMError("invalidPath", fmt.Sprintf("attribute %q is not supported", attr))
+	}
+	return nil
+}
+
+func setSCIMString(target *string, attr string, value json.RawMessage) error {
+	if err := json.Unmarshal(value, target); err != nil {
+		return newSCIMError("invalidValue", fmt.Sprintf("%s must be a string", attr))
+	}
+	return nil
+}
+
+// parseSCIMBool accepts JSON booleans as well as strings, as sent by some provisioning systems ("False")
+func parseSCIMBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if b, err = strconv.ParseBool(strings.ToLower(str)); err == nil {
+			return b, nil
+		}
+	}
+	return false, newSCIMError("invalidValue", "active must be a boolean")
+}
+
+func (s *scimService) DeactivateUser(ctx context.Context, id string) error {
+	user, err := s.users.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.Disabled {
+		updated := *user
+		updated.Disabled = true
+		if err = s.users.UpdateAccount(ctx, &updated); err != nil {
+			return err
+		}
+	}
+	return s.sessions.RevokeAllByUser(ctx, id, "", s.now())
+}
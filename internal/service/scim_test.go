@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+)
+
+func getSCIMService(t *testing.T) (*scimService, *repository.Repositories) {
+	t.Helper()
+	repos := fake_repo.New()
+	gen, err := idgen.New(1)
+	require.NoError(t, err)
+	s := newSCIMService(repos.Users, repos.Sessions, gen, testHasher, SCIMSettings{
+		Token:       "scim-token",
+		MaxPageSize: 2,
+	}).(*scimService)
+	return s, repos
+}
+
+func createSCIMUser(t *testing.T, s *scimService, email string, externalId string) *SCIMUser {
+	t.Helper()
+	user, err := s.CreateUser(context.Background(), &SCIMUser{
+		Schemas:    []string{SCIMUserSchema},
+		ExternalId: externalId,
+		UserName:   email,
+		Name:       &SCIMName{GivenName: "Jane", FamilyName: "Roe"},
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func TestSCIMService_Authenticate(t *testing.T) {
+	s, _ := getSCIMService(t)
+	assert.True(t, s.Authenticate("scim-token"))
+	assert.False(t, s.Authenticate("other"))
+
+	s.settings.Token = ""
+	assert.False(t, s.Authenticate(""))
+}
+
+func TestSCIMService_CreateUser(t *testing.T) {
+	s, repos := getSCIMService(t)
+	ctx := context.Background()
+
+	created, err := s.CreateUser(ctx, &SCIMUser{
+		Schemas:     []string{SCIMUserSchema},
+		ExternalId:  "hr-1",
+		UserName:    "jane.roe@example.com",
+		Name:        &SCIMName{GivenName: "Jane", FamilyName: "Roe"},
+		DisplayName: "JR",
+		Password:    "initial-password",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Id)
+	assert.Equal(t, "Jane Roe", created.Name.Formatted)
+	assert.True(t, *created.Active)
+	assert.Empty(t, created.Password)
+	assert.Equal(t, []SCIMEmail{{Value: "jane.roe@example.com", Type: "work", Primary: true}}, created.Emails)
+
+	stored, err := repos.Users.GetById(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "hr-1", stored.ExternalId)
+	ok, _, err := testHasher.Verify("initial-password", stored.HashedPassword)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = s.CreateUser(ctx, &SCIMUser{UserName: "jane.roe@example.com"})
+	assert.Equal(t, "uniqueness", err.(*SCIMError).ScimType)
+
+	_, err = s.CreateUser(ctx, &SCIMUser{UserName: "not an email"})
+	assert.Equal(t, "invalidValue", err.(*SCIMError).ScimType)
+}
+
+func TestSCIMService_ListUsers(t *testing.T) {
+	s, _ := getSCIMService(t)
+	ctx := context.Background()
+	first := createSCIMUser(t, s, "first@example.com", "hr-1")
+	second := createSCIMUser(t, s, "second@example.com", "hr-2")
+	count := func(n int) *int { return &n }
+
+	cases := map[string]struct {
+		input     *SCIMListInput
+		wantIds   []string
+		wantTotal int
+		wantErr   string
+	}{
+		"first page": {
+			input:     &SCIMListInput{},
+			wantIds:   []string{fake_repo.SampleUser.Id, first.Id},
+			wantTotal: 3,
+		},
+		"second page": {
+			input:     &SCIMListInput{StartIndex: 3},
+			wantIds:   []string{second.Id},
+			wantTotal: 3,
+		},
+		"count above maximum": {
+			input:     &SCIMListInput{Count: count(10)},
+			wantIds:   []string{fake_repo.SampleUser.Id, first.Id},
+			wantTotal: 3,
+		},
+		"count only": {
+			input:     &SCIMListInput{Count: count(0)},
+			wantIds:   []string{},
+			wantTotal: 3,
+		},
+		"by userName": {
+			input:     &SCIMListInput{Filter: `userName eq "second@example.com"`},
+			wantIds:   []string{second.Id},
+			wantTotal: 1,
+		},
+		"by externalId and active": {
+			input:     &SCIMListInput{Filter: `externalId eq "hr-1" and active eq true`},
+			wantIds:   []string{first.Id},
+			wantTotal: 1,
+		},
+		"escaped string": {
+			input:     &SCIMListInput{Filter: `displayName eq "say \"hi\""`},
+			wantIds:   []string{},
+			wantTotal: 0,
+		},
+		"unsupported operator": {
+			input:   &SCIMListInput{Filter: `userName co "example"`},
+			wantErr: "invalidFilter",
+		},
+		"unsupported attribute": {
+			input:   &SCIMListInput{Filter: `title eq "CEO"`},
+			wantErr: "invalidFilter",
+		},
+		"or": {
+			input:   &SCIMListInput{Filter: `externalId eq "hr-1" or externalId eq "hr-2"`},
+			wantErr: "invalidFilter",
+		},
+		"unterminated string": {
+			input:   &SCIMListInput{Filter: `userName eq "first@example.com`},
+			wantErr: "invalidFilter",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := s.ListUsers(ctx, tc.input)
+			if tc.wantErr != "" {
+				require.IsType(t, &SCIMError{}, err)
+				assert.Equal(t, tc.wantErr, err.(*SCIMError).ScimType)
+				return
+			}
+			require.NoError(t, err)
+			ids := make([]string, 0)
+			for _, user := range result.Resources {
+				ids = append(ids, user.Id)
+			}
+			assert.Equal(t, tc.wantIds, ids)
+			assert.Equal(t, tc.wantTotal, result.TotalResults)
+			assert.Equal(t, len(tc.wantIds), result.ItemsPerPage)
+		})
+	}
+}
+
+func TestSCIMService_PatchUser(t *testing.T) {
+	cases := map[string]struct {
+		operations string
+		check      func(t *testing.T, user *SCIMUser)
+		wantErr    string
+	}{
+		"replace by path": {
+			operations: `[{"op":"replace","path":"name.givenName","value":"Janet"},
+				{"op":"Replace","path":"emails[type eq \"work\"].value","value":"janet@example.com"}]`,
+			check: func(t *testing.T, user *SCIMUser) {
+				assert.Equal(t, "Janet", user.Name.GivenName)
+				assert.Equal(t, "Roe", user.Name.FamilyName)
+				assert.Equal(t, "janet@example.com", user.UserName)
+			},
+		},
+		"replace without path": {
+			operations: `[{"op":"replace","value":{"displayName":"JR","name":{"givenName":"J","familyName":"R"}}}]`,
+			check: func(t *testing.T, user *SCIMUser) {
+				assert.Equal(t, "JR", user.DisplayName)
+				assert.Equal(t, "J", user.Name.GivenName)
+				assert.Equal(t, "R", user.Name.FamilyName)
+			},
+		},
+		"remove external id": {
+			operations: `[{"op":"remove","path":"externalId"}]`,
+			check: func(t *testing.T, user *SCIMUser) {
+				assert.Empty(t, user.ExternalId)
+			},
+		},
+		"email taken": {
+			operations: `[{"op":"replace","path":"userName","value":"doe.j@example.com"}]`,
+			wantErr:    "uniqueness",
+		},
+		"invalid email": {
+			operations: `[{"op":"replace","path":"userName","value":"jane"}]`,
+			wantErr:    "invalidValue",
+		},
+		"unknown attribute": {
+			operations: `[{"op":"replace","path":"title","value":"CEO"}]`,
+			wantErr:    "invalidPath",
+		},
+		"remove userName": {
+			operations: `[{"op":"remove","path":"userName"}]`,
+			wantErr:    "mutability",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, repos := getSCIMService(t)
+			ctx := context.Background()
+			created := createSCIMUser(t, s, "jane.roe@example.com", "hr-1")
+
+			var input SCIMPatchInput
+			require.NoError(t, json.Unmarshal([]byte(`{"Operations":`+tc.operations+`}`), &input))
+			result, err := s.PatchUser(ctx, created.Id, &input)
+			if tc.wantErr != "" {
+				require.IsType(t, &SCIMError{}, err)
+				assert.Equal(t, tc.wantErr, err.(*SCIMError).ScimType)
+				stored, err := repos.Users.GetById(ctx, created.Id)
+				require.NoError(t, err)
+				assert.Equal(t, "jane.roe@example.com", stored.Email)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, result)
+
+			stored, err := s.GetUser(ctx, created.Id)
+			require.NoError(t, err)
+			assert.Equal(t, result, stored)
+		})
+	}
+}
+
+func TestSCIMService_DeactivationBlocksLogin(t *testing.T) {
+	for name, deactivate := range map[string]func(s *scimService, id string) error{
+		"patch": func(s *scimService, id string) error {
+			input := &SCIMPatchInput{Operations: []SCIMPatchOperation{
+				{Op: "replace", Path: "active", Value: json.RawMessage(`"False"`)},
+			}}
+			_, err := s.PatchUser(context.Background(), id, input)
+			return err
+		},
+		"delete": func(s *scimService, id string) error {
+			return s.DeactivateUser(context.Background(), id)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, repos := getSCIMService(t)
+			ctx := context.Background()
+			gen, err := idgen.New(2)
+			require.NoError(t, err)
+			sessions := newSessionsService(repos.Sessions, repos.Users, gen, time.Hour)
+			created := createSCIMUser(t, s, "jane.roe@example.com", "hr-1")
+
+			session, err := sessions.Create(ctx, &CreateSessionInput{UserId: created.Id})
+			require.NoError(t, err)
+
+			require.NoError(t, deactivate(s, created.Id))
+
+			// existing sessions are revoked and no new ones can be started
+			active, err := repos.Sessions.ListActiveByUser(ctx, created.Id, time.Now())
+			require.NoError(t, err)
+			assert.Empty(t, active)
+			stored, err := repos.Sessions.GetById(ctx, session.Id)
+			require.NoError(t, err)
+			assert.NotNil(t, stored.RevokedAt)
+			_, err = sessions.Create(ctx, &CreateSessionInput{UserId: created.Id})
+			assert.ErrorIs(t, err, ErrUserDisabled)
+
+			user, err := s.GetUser(ctx, created.Id)
+			require.NoError(t, err)
+			assert.False(t, *user.Active)
+		})
+	}
+}
@@ -4,11 +4,17 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/zhuravlev-pe/course-watch/internal/core"
 	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/background"
 	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/mailer"
+	"github.com/zhuravlev-pe/course-watch/pkg/password"
+	"github.com/zhuravlev-pe/course-watch/pkg/patch"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
 )
 
@@ -17,9 +23,29 @@ type CreateCourseInput struct {
 	Description string
 }
 
+type UpdateCourseInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Version is the version the update is conditional on, taken from the If-Match header. Zero updates any version
+	Version int64 `json:"-" swaggerignore:"true"`
+}
+
 type Courses interface {
 	GetById(ctx context.Context, id string) (*core.Course, error)
 	Create(ctx context.Context, input CreateCourseInput) (*core.Course, error)
+	// Update returns repository.ErrVersionConflict if input.Version is set and the course has changed since
+	Update(ctx context.Context, id string, input *UpdateCourseInput) error
+	// Patch changes only the members present in the patch. Errors like Update
+	Patch(ctx context.Context, id string, input *PatchCourseInput) error
+}
+
+// PatchCourseInput is a JSON merge patch of the course. Missing members are left unchanged, a null description
+// clears it
+type PatchCourseInput struct {
+	Title       patch.Field[string] `json:"title" swaggertype:"string"`
+	Description patch.Field[string] `json:"description" swaggertype:"string"`
+	// Version is the version the update is conditional on, taken from the If-Match header. Zero updates any version
+	Version int64 `json:"-" swaggerignore:"true"`
 }
 
 type GetUserInfoOutput struct {
@@ -30,18 +56,33 @@ type GetUserInfoOutput struct {
 	DisplayName      string          `json:"display_name"`
 	RegistrationDate time.Time       `json:"registration_date"`
 	Roles            []security.Role `json:"roles"`
+	// Version is returned in the ETag header
+	Version int64 `json:"-"`
 }
 
 type UpdateUserInfoInput struct {
 	FirstName   string `json:"first_name"`
 	LastName    string `json:"last_name"`
 	DisplayName string `json:"display_name"`
+	// Version is the version the update is conditional on, taken from the If-Match header. Zero updates any version
+	Version int64 `json:"-" swaggerignore:"true"`
+}
+
+// PatchUserInfoInput is a JSON merge patch of the user info. Missing members are left unchanged, a null display name
+// removes it. The first and last names cannot be removed
+type PatchUserInfoInput struct {
+	FirstName   patch.Field[string] `json:"first_name" swaggertype:"string"`
+	LastName    patch.Field[string] `json:"last_name" swaggertype:"string"`
+	DisplayName patch.Field[string] `json:"display_name" swaggertype:"string"`
+	// Version is the version the update is conditional on, taken from the If-Match header. Zero updates any version
+	Version int64 `json:"-" swaggerignore:"true"`
 }
 
 type LoginInput struct {
-	Email      string `json:"email"`
-	Password   string `json:"password"`
-	Persistent bool   `json:"persistent"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	Persistent  bool   `json:"persistent"`
+	DeviceLabel string `json:"device_label"`
 }
 
 type SignupUserInput struct {
@@ -53,34 +94,510 @@ type SignupUserInput struct {
 }
 
 type PostUserLoginOutput struct {
-	UserId      string `json:"user_id"`
-	AccessToken string `json:"access_token"`
+	UserId string `json:"user_id"`
+	// AccessToken is omitted in the cookie session mode, the token is set as an HttpOnly cookie instead
+	AccessToken string `json:"access_token,omitempty"`
 	ExpiresIn   int    `json:"expires_in"`
+	// CSRFToken is only set in the cookie session mode. It must be passed in the X-CSRF-Token header of state-changing
+	// requests
+	CSRFToken string `json:"csrf_token,omitempty"`
 }
 
 type Users interface {
 	GetUserInfo(ctx context.Context, id string) (*GetUserInfoOutput, error)
 	UpdateUserInfo(ctx context.Context, id string, input *UpdateUserInfoInput) error
+	PatchUserInfo(ctx context.Context, id string, input *PatchUserInfoInput) error
 	Login(ctx context.Context, input *LoginInput) (*core.User, error)
 	Signup(ctx context.Context, input *SignupUserInput) error
 }
 
+type CreateSessionInput struct {
+	UserId      string
+	DeviceLabel string
+	IP          string
+	UserAgent   string
+}
+
+type SessionOutput struct {
+	Id          string    `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	Current     bool      `json:"current"`
+}
+
+type Sessions interface {
+	Create(ctx context.Context, input *CreateSessionInput) (*core.Session, error)
+	// ValidateSession fails with ErrSessionInactive unless the principal's session is active. Refreshes the session's
+	// last-seen time
+	ValidateSession(ctx context.Context, principal *security.UserPrincipal) error
+	List(ctx context.Context, userId string, currentSessionId string) ([]*SessionOutput, error)
+	Revoke(ctx context.Context, userId string, sessionId string) error
+	RevokeOthers(ctx context.Context, userId string, currentSessionId string) error
+}
+
+type MagicLinkRequestInput struct {
+	Email string `json:"email"`
+}
+
+type MagicLinkExchangeInput struct {
+	Token       string `json:"token"`
+	DeviceLabel string `json:"device_label"`
+}
+
+type MagicLinks interface {
+	// Request emails a single-use login link to the user, if such a user exists. The link can only be exchanged
+	// together with the same nonce, which binds it to the requesting browser. The link is sent in the background,
+	// and the requests for an address are limited whether it is registered or not, so that neither the response
+	// nor its timing reveals that. Fails with ErrTooManyRequests above the limit
+	Request(ctx context.Context, input *MagicLinkRequestInput, nonce string) error
+	Exchange(ctx context.Context, token string, nonce string) (*core.User, error)
+	// PurgeAttempts forgets the request counts of the rate limit windows which have ended and returns their number
+	PurgeAttempts(ctx context.Context) (int, error)
+}
+
+type MagicLinkSettings struct {
+	// URL of the page the link points to. The token is appended as the "token" query parameter
+	URL string
+	TTL time.Duration
+	// RateLimit is the number of links which may be requested for an address per RateInterval
+	RateLimit    int
+	RateInterval time.Duration
+	SigningKey   []byte
+}
+
+type WebAuthnBeginOutput struct {
+	CeremonyId string `json:"ceremony_id"`
+	// Options must be passed to navigator.credentials.create() or navigator.credentials.get() as is
+	Options interface{} `json:"options" swaggertype:"object"`
+}
+
+type WebAuthnLoginInput struct {
+	Email string `json:"email"`
+}
+
+type WebAuthnFinishInput struct {
+	CeremonyId  string `json:"ceremony_id"`
+	DeviceLabel string `json:"device_label"`
+	// Credential is the PublicKeyCredential returned by the browser, serialized to JSON with binary fields encoded
+	// as base64url
+	Credential json.RawMessage `json:"credential" swaggertype:"object"`
+}
+
+type WebAuthn interface {
+	BeginRegistration(ctx context.Context, userId string) (*WebAuthnBeginOutput, error)
+	FinishRegistration(ctx context.Context, userId string, input *WebAuthnFinishInput) error
+	BeginLogin(ctx context.Context, input *WebAuthnLoginInput) (*WebAuthnBeginOutput, error)
+	FinishLogin(ctx context.Context, input *WebAuthnFinishInput) (*core.User, error)
+}
+
+type WebAuthnSettings struct {
+	RelyingParty    *webauthn.WebAuthn
+	CeremonyTimeout time.Duration
+}
+
+type OIDCAuthorizeOutput struct {
+	// URL of the provider's authorization endpoint the browser must be redirected to
+	URL string
+	// State must be bound to the browser (e.g. via a cookie) and compared with the state returned to the callback
+	State string
+}
+
+type OIDCCallbackOutput struct {
+	// User is the linked or newly created user
+	User *core.User
+	// PostLoginURL of the frontend page the browser must be sent to once the session is started
+	PostLoginURL string
+}
+
+type OIDC interface {
+	Authorize(ctx context.Context, provider string) (*OIDCAuthorizeOutput, error)
+	// Callback completes the authorization code flow and returns the linked or newly created user
+	Callback(ctx context.Context, provider string, state string, code string) (*OIDCCallbackOutput, error)
+}
+
+type OIDCProviderSettings struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type OIDCSettings struct {
+	Providers []OIDCProviderSettings
+	StateTTL  time.Duration
+	// PostLoginURL of the frontend page the browser is redirected to after a successful login
+	PostLoginURL string
+}
+
+type RegisterOAuthClientInput struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	// Public clients (SPAs, mobile apps) cannot keep a secret. They get no secret and must use PKCE
+	Public bool `json:"public"`
+}
+
+type OAuthClientOutput struct {
+	Id           string    `json:"id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type RegisterOAuthClientOutput struct {
+	OAuthClientOutput
+	// ClientSecret is only returned once, on registration. Empty for public clients
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// OAuthAuthorizeInput holds the parameters of an authorization request,
+// see https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.1
+type OAuthAuthorizeInput struct {
+	ResponseType        string `form:"response_type"`
+	ClientId            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+type OAuthAuthorizeOutput struct {
+	// RedirectURI is where the user agent must be sent next: back to the client with a code or an error, or to the
+	// login page if the user is not authenticated
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// OAuthTokenInput holds the parameters of a token request, see https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.3
+type OAuthTokenInput struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientId     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// OAuthTokenRequestInput is the body of the token introspection (RFC 7662) and revocation (RFC 7009) requests
+type OAuthTokenRequestInput struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientId      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// OAuthGrant is the result of a successful code exchange. The access token is issued by the caller, as it is bound
+// to a login session
+type OAuthGrant struct {
+	User    *core.User
+	Client  *core.OAuthClient
+	Scope   string
+	IdToken string
+}
+
+type OAuthTokenOutput struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+	IdToken     string `json:"id_token,omitempty"`
+}
+
+// UserInfoClaims are the standard OpenID Connect claims about a user
+type UserInfoClaims struct {
+	Subject    string `json:"sub"`
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+	Nickname   string `json:"nickname,omitempty"`
+}
+
+// OpenIDConfiguration is the OpenID Provider metadata, see https://openid.net/specs/openid-connect-discovery-1_0.html
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// OAuth implements a minimal OAuth 2.0 / OpenID Connect authorization server: the authorization code flow with
+// PKCE for clients registered by admins
+type OAuth interface {
+	Discovery() *OpenIDConfiguration
+	KeySet() *security.JSONWebKeySet
+
+	RegisterClient(ctx context.Context, input *RegisterOAuthClientInput) (*RegisterOAuthClientOutput, error)
+	ListClients(ctx context.Context) ([]*OAuthClientOutput, error)
+	DeleteClient(ctx context.Context, id string) error
+
+	// Authorize handles an authorization request on behalf of the user (empty if not authenticated). Fails with
+	// *OAuthError if the client or the redirect URI are invalid, in which case the user agent must not be
+	// redirected, and with ErrLoginRequired if the user is not authenticated and no login page is configured
+	Authorize(ctx context.Context, input *OAuthAuthorizeInput, userId string) (*OAuthAuthorizeOutput, error)
+	// ExchangeCode authenticates the client and redeems the authorization code. Fails with *OAuthError
+	ExchangeCode(ctx context.Context, input *OAuthTokenInput) (*OAuthGrant, error)
+	// AuthenticateConfidentialClient checks the credentials of a client with a secret, e.g. a resource server calling
+	// the token introspection endpoint. Fails with *OAuthError
+	AuthenticateConfidentialClient(ctx context.Context, clientId string, secret string) (*OAuthClientOutput, error)
+	// UserInfo returns the claims about the user which the space separated scope grants access to
+	UserInfo(ctx context.Context, userId string, scope string) (*UserInfoClaims, error)
+}
+
+type OAuthSettings struct {
+	// Issuer is the base URL of the authorization server endpoints, e.g. https://localhost:8080/auth
+	Issuer string
+	// LoginURL of the frontend page unauthenticated users are sent to. The authorization request URL to come back
+	// to is passed in the "return_to" query parameter
+	LoginURL      string
+	CodeTTL       time.Duration
+	IdTokenSigner *security.IdTokenSigner
+}
+
+const (
+	SCIMUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMPatchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+}
+
+// SCIMUser is the SCIM representation of a user, see https://datatracker.ietf.org/doc/html/rfc7643#section-4.1.
+// UserName is the email address of the user
+type SCIMUser struct {
+	Schemas     []string    `json:"schemas"`
+	Id          string      `json:"id,omitempty"`
+	ExternalId  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *SCIMName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []SCIMEmail `json:"emails,omitempty"`
+	// Active defaults to true on creation
+	Active *bool `json:"active,omitempty"`
+	// Password is optional and never returned. Users without a password log in with passwordless methods
+	Password string    `json:"password,omitempty"`
+	Meta     *SCIMMeta `json:"meta,omitempty"`
+}
+
+type SCIMListInput struct {
+	Filter string `form:"filter"`
+	// StartIndex is 1-based
+	StartIndex int  `form:"startIndex"`
+	Count      *int `form:"count"`
+}
+
+type SCIMListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    []*SCIMUser `json:"Resources"`
+}
+
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+type SCIMPatchInput struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIM provisions users on behalf of an external identity management system (e.g. HR),
+// see https://datatracker.ietf.org/doc/html/rfc7644. Errors caused by the request are reported as *SCIMError
+type SCIM interface {
+	// Authenticate checks the bearer token of the provisioning system
+	Authenticate(token string) bool
+	CreateUser(ctx context.Context, input *SCIMUser) (*SCIMUser, error)
+	GetUser(ctx context.Context, id string) (*SCIMUser, error)
+	ListUsers(ctx context.Context, input *SCIMListInput) (*SCIMListResponse, error)
+	PatchUser(ctx context.Context, id string, input *SCIMPatchInput) (*SCIMUser, error)
+	// DeactivateUser disables the user and revokes all their sessions. Users are never deleted, as they own
+	// course data
+	DeactivateUser(ctx context.Context, id string) error
+}
+
+type SCIMSettings struct {
+	// Token the provisioning system authenticates with. SCIM is disabled when empty
+	Token       string
+	MaxPageSize int
+}
+
+type AdminCreateUserInput struct {
+	Email       string
+	Password    string
+	FirstName   string
+	LastName    string
+	DisplayName string
+	Roles       []security.Role
+}
+
+type AdminUserOutput struct {
+	Id               string          `json:"id"`
+	Email            string          `json:"email"`
+	FirstName        string          `json:"first_name"`
+	LastName         string          `json:"last_name"`
+	DisplayName      string          `json:"display_name"`
+	RegistrationDate time.Time       `json:"registration_date"`
+	Roles            []security.Role `json:"roles"`
+	Disabled         bool            `json:"disabled"`
+}
+
+type AdminUserList struct {
+	Total int                `json:"total"`
+	Users []*AdminUserOutput `json:"users"`
+}
+
+// Admin is used by operators, e.g. to bootstrap the first admin. Users are referred to by id or email address
+type Admin interface {
+	CreateUser(ctx context.Context, input *AdminCreateUserInput) (*AdminUserOutput, error)
+	// GrantRole and RevokeRole change the roles and revoke all sessions of the user, whose access tokens carry the
+	// old roles. Nothing happens if the user already has, respectively lacks, the role
+	GrantRole(ctx context.Context, user string, role security.Role) (*AdminUserOutput, error)
+	RevokeRole(ctx context.Context, user string, role security.Role) (*AdminUserOutput, error)
+	// ResetPassword replaces the password and revokes all sessions of the user
+	ResetPassword(ctx context.Context, user string, password string) error
+	// DisableUser disables the user and revokes all their sessions
+	DisableUser(ctx context.Context, user string) (*AdminUserOutput, error)
+	ListUsers(ctx context.Context, offset int, limit int) (*AdminUserList, error)
+}
+
+// IdempotentRequest is a request with an Idempotency-Key header. Keys are scoped to the user and the route
+type IdempotentRequest struct {
+	// UserId is empty for anonymous requests
+	UserId string
+	// Caller identifies an anonymous caller, e.g. by the client address, so that anonymous callers do not share keys.
+	// Ignored for authenticated requests
+	Caller string
+	Route  string
+	Key    string
+	// Body tells retries from different requests reusing the key
+	Body []byte
+	// Token is set by Begin when it reserves the key for the request. Complete and Release only apply to the
+	// reservation with this token, which is lost when a retry takes the key over after the lock timeout
+	Token string
+}
+
+type IdempotentResponse struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       []byte
+}
+
+type Idempotency interface {
+	// Begin reserves the key for the request. It returns the stored response if the request has been processed
+	// before, or nil if it has to be processed now, in which case Complete or Release must follow. Fails with
+	// ErrIdempotencyKeyReused if the key was used for a different request, and with ErrIdempotencyKeyInUse while the
+	// first request with the key is still being processed
+	Begin(ctx context.Context, request *IdempotentRequest) (*IdempotentResponse, error)
+	// Complete stores the response, which is replayed to retries until the key expires. Fails with
+	// repository.ErrNotFound if the reservation has been taken over. Like Release, it is not canceled with ctx, so
+	// that a client disconnecting does not leave the key reserved
+	Complete(ctx context.Context, request *IdempotentRequest, response *IdempotentResponse) error
+	// Release forgets the key, e.g. after a failure, so that a retry is processed again
+	Release(ctx context.Context, request *IdempotentRequest) error
+	// PurgeExpired deletes the expired keys and returns their number
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+type IdempotencySettings struct {
+	// TTL is how long responses are replayed
+	TTL time.Duration
+	// LockTimeout is how long a key stays reserved for a request which neither completed nor released it, e.g.
+	// because the server crashed. Retries are processed again after that
+	LockTimeout time.Duration
+	// FingerprintKey keys the hashes which tell retries from different requests reusing a key
+	FingerprintKey []byte
+}
+
 type Services struct {
-	Courses Courses
-	Users   Users
+	Courses     Courses
+	Users       Users
+	Sessions    Sessions
+	MagicLinks  MagicLinks
+	WebAuthn    WebAuthn
+	OIDC        OIDC
+	OAuth       OAuth
+	SCIM        SCIM
+	Admin       Admin
+	Idempotency Idempotency
 }
 
 type Deps struct {
 	Repos *repository.Repositories
 	IdGen *idgen.IdGen
+	// PasswordHasher hashes new passwords and verifies stored ones
+	PasswordHasher password.Hasher
+	// SessionTTL is the lifetime of a login session, normally equal to the access token TTL
+	SessionTTL  time.Duration
+	Mailer      mailer.Sender
+	MagicLink   MagicLinkSettings
+	WebAuthn    WebAuthnSettings
+	OIDC        OIDCSettings
+	OAuth       OAuthSettings
+	SCIM        SCIMSettings
+	Idempotency IdempotencySettings
+	// Background runs the work which outlives requests, e.g. sending magic links. The caller waits for it on
+	// shutdown. When nil, the tasks are never waited for
+	Background *background.Tasks
 }
 
 func NewServices(deps Deps) *Services {
+	if deps.Background == nil {
+		deps.Background = background.NewTasks(1)
+	}
 	coursesService := NewCoursesService(deps.Repos.Courses, deps.IdGen)
-	usersSrv := newUsersService(deps.Repos.Users, deps.IdGen)
+	usersSrv := newUsersService(deps.Repos.Users, deps.IdGen, deps.PasswordHasher)
+	sessionsSrv := newSessionsService(deps.Repos.Sessions, deps.Repos.Users, deps.IdGen, deps.SessionTTL)
+	magicLinksSrv := newMagicLinksService(deps.Repos.MagicLinks, deps.Repos.Users, deps.IdGen, deps.Mailer, deps.MagicLink, deps.Background)
+	webAuthnSrv := newWebAuthnService(deps.Repos.WebAuthn, deps.Repos.Users, deps.IdGen, deps.WebAuthn)
+	oidcSrv := newOIDCService(deps.Repos.Identities, deps.Repos.Users, deps.Repos.Transactor, deps.IdGen, deps.OIDC)
+	oauthSrv := newOAuthService(deps.Repos.OAuth, deps.Repos.Users, deps.IdGen, deps.OAuth)
+	scimSrv := newSCIMService(deps.Repos.Users, deps.Repos.Sessions, deps.IdGen, deps.PasswordHasher, deps.SCIM)
 
 	return &Services{
-		Courses: coursesService,
-		Users:   usersSrv,
+		Courses:     tracedCourses{next: coursesService},
+		Users:       tracedUsers{next: usersSrv},
+		Sessions:    tracedSessions{next: sessionsSrv},
+		MagicLinks:  magicLinksSrv,
+		WebAuthn:    webAuthnSrv,
+		OIDC:        oidcSrv,
+		OAuth:       oauthSrv,
+		SCIM:        scimSrv,
+		Admin:       newAdminService(deps.Repos.Users, deps.Repos.Sessions, deps.Repos.Transactor, deps.IdGen, deps.PasswordHasher),
+		Idempotency: newIdempotencyService(deps.Repos.IdempotencyKeys, deps.Idempotency),
 	}
 }
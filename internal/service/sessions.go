@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+// lastSeenResolution limits how often the last-seen time is written back for a busy session
+const lastSeenResolution = time.Minute
+
+type sessionsService struct {
+	repo  repository.Sessions
+	users repository.Users
+	idGen *idgen.IdGen
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func newSessionsService(repo repository.Sessions, users repository.Users, idGen *idgen.IdGen, ttl time.Duration) Sessions {
+	return &sessionsService{
+		repo:  repo,
+		users: users,
+		idGen: idGen,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+func (s *sessionsService) Create(ctx context.Context, input *CreateSessionInput) (*core.Session, error) {
+	// every login method ends up here, so this is the single place to keep disabled users out
+	user, err := s.users.GetById(ctx, input.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	now := s.now()
+	session := &core.Session{
+		Id:          s.idGen.Generate(),
+		UserId:      input.UserId,
+		DeviceLabel: input.DeviceLabel,
+		IP:          input.IP,
+		UserAgent:   input.UserAgent,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	if err = s.repo.Insert(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *sessionsService) ValidateSession(ctx context.Context, principal *security.UserPrincipal) error {
+	if principal.SessionId == "" {
+		return ErrSessionInactive
+	}
+	session, err := s.repo.GetById(ctx, principal.SessionId)
+	if err == repository.ErrNotFound {
+		return ErrSessionInactive
+	}
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if session.UserId != principal.UserId || !session.IsActive(now) {
+		return ErrSessionInactive
+	}
+	if now.Sub(session.LastSeenAt) >= lastSeenResolution {
+		return s.repo.UpdateLastSeen(ctx, session.Id, now)
+	}
+	return nil
+}
+
+func (s *sessionsService) List(ctx context.Context, userId string, currentSessionId string) ([]*SessionOutput, error) {
+	sessions, err := s.repo.ListActiveByUser(ctx, userId, s.now())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SessionOutput, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, &SessionOutput{
+			Id:          session.Id,
+			DeviceLabel: session.DeviceLabel,
+			IP:          session.IP,
+			UserAgent:   session.UserAgent,
+			CreatedAt:   session.CreatedAt,
+			LastSeenAt:  session.LastSeenAt,
+			Current:     session.Id == currentSessionId,
+		})
+	}
+	return result, nil
+}
+
+func (s *sessionsService) Revoke(ctx context.Context, userId string, sessionId string) error {
+	session, err := s.repo.GetById(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	// Sessions of other users are reported as missing in order not to reveal their existence
+	if session.UserId != userId || !session.IsActive(s.now()) {
+		return repository.ErrNotFound
+	}
+	return s.repo.Revoke(ctx, sessionId, s.now())
+}
+
+func (s *sessionsService) RevokeOthers(ctx context.Context, userId string, currentSessionId string) error {
+	return s.repo.RevokeAllByUser(ctx, userId, currentSessionId, s.now())
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	repoMocks "github.com/zhuravlev-pe/course-watch/internal/repository/mocks"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+)
+
+var sessionNow = time.Date(2022, time.December, 1, 12, 0, 0, 0, time.UTC)
+
+func getSessionsService(t *testing.T) (*sessionsService, *repoMocks.MockSessions, *repoMocks.MockUsers) {
+	t.Helper()
+	mockCtrl := gomock.NewController(t)
+	mockSessions := repoMocks.NewMockSessions(mockCtrl)
+	mockUsers := repoMocks.NewMockUsers(mockCtrl)
+	gen, err := idgen.New(1)
+	assert.NoError(t, err)
+	s := newSessionsService(mockSessions, mockUsers, gen, time.Hour).(*sessionsService)
+	s.now = func() time.Time { return sessionNow }
+	return s, mockSessions, mockUsers
+}
+
+func TestSessionsService_Create(t *testing.T) {
+	cases := map[string]struct {
+		user       *core.User
+		setupMocks func(context.Context, *repoMocks.MockSessions)
+		checkError func(*testing.T, error)
+	}{
+		"active_user": {
+			user: &core.User{Id: "1111111"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, session *core.Session) error {
+						assert.Equal(t, "1111111", session.UserId)
+						assert.Equal(t, "laptop", session.DeviceLabel)
+						assert.Equal(t, sessionNow.Add(time.Hour), session.ExpiresAt)
+						return nil
+					}).Times(1)
+			},
+			checkError: noError,
+		},
+		"disabled_user": {
+			user:       &core.User{Id: "1111111", Disabled: true},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrUserDisabled)
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, mockSessions, mockUsers := getSessionsService(t)
+			ctx := context.Background()
+			mockUsers.EXPECT().GetById(ctx, tc.user.Id).Return(tc.user, nil).Times(1)
+			tc.setupMocks(ctx, mockSessions)
+
+			_, err := s.Create(ctx, &CreateSessionInput{UserId: tc.user.Id, DeviceLabel: "laptop"})
+
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestSessionsService_ValidateSession(t *testing.T) {
+	revoked := sessionNow.Add(-time.Minute)
+
+	cases := map[string]struct {
+		principal  *security.UserPrincipal
+		setupMocks func(context.Context, *repoMocks.MockSessions)
+		checkError func(*testing.T, error)
+	}{
+		"active_recently_seen": {
+			principal: &security.UserPrincipal{UserId: "1111111", SessionId: "2222222"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(&core.Session{
+					Id:         "2222222",
+					UserId:     "1111111",
+					LastSeenAt: sessionNow.Add(-time.Second),
+					ExpiresAt:  sessionNow.Add(time.Hour),
+				}, nil).Times(1)
+			},
+			checkError: noError,
+		},
+		"active_last_seen_refreshed": {
+			principal: &security.UserPrincipal{UserId: "1111111", SessionId: "2222222"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(&core.Session{
+					Id:         "2222222",
+					UserId:     "1111111",
+					LastSeenAt: sessionNow.Add(-time.Hour),
+					ExpiresAt:  sessionNow.Add(time.Hour),
+				}, nil).Times(1)
+				mockSessions.EXPECT().UpdateLastSeen(ctx, "2222222", sessionNow).Return(nil).Times(1)
+			},
+			checkError: noError,
+		},
+		"no_session_in_token": {
+			principal:  &security.UserPrincipal{UserId: "1111111"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrSessionInactive)
+			},
+		},
+		"unknown_session": {
+			principal: &security.UserPrincipal{UserId: "1111111", SessionId: "2222222"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(nil, repository.ErrNotFound).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrSessionInactive)
+			},
+		},
+		"revoked": {
+			principal: &security.UserPrincipal{UserId: "1111111", SessionId: "2222222"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(&core.Session{
+					Id:        "2222222",
+					UserId:    "1111111",
+					ExpiresAt: sessionNow.Add(time.Hour),
+					RevokedAt: &revoked,
+				}, nil).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrSessionInactive)
+			},
+		},
+		"other_user": {
+			principal: &security.UserPrincipal{UserId: "3333333", SessionId: "2222222"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(&core.Session{
+					Id:        "2222222",
+					UserId:    "1111111",
+					ExpiresAt: sessionNow.Add(time.Hour),
+				}, nil).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrSessionInactive)
+			},
+		},
+		"db_error": {
+			principal: &security.UserPrincipal{UserId: "1111111", SessionId: "2222222"},
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(nil, someDatabaseError).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, someDatabaseError)
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, mockSessions, _ := getSessionsService(t)
+			ctx := context.Background()
+			tc.setupMocks(ctx, mockSessions)
+
+			err := s.ValidateSession(ctx, tc.principal)
+
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestSessionsService_Revoke(t *testing.T) {
+	cases := map[string]struct {
+		userId     string
+		setupMocks func(context.Context, *repoMocks.MockSessions)
+		checkError func(*testing.T, error)
+	}{
+		"success": {
+			userId: "1111111",
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(&core.Session{
+					Id:        "2222222",
+					UserId:    "1111111",
+					ExpiresAt: sessionNow.Add(time.Hour),
+				}, nil).Times(1)
+				mockSessions.EXPECT().Revoke(ctx, "2222222", sessionNow).Return(nil).Times(1)
+			},
+			checkError: noError,
+		},
+		"session_of_other_user": {
+			userId: "3333333",
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(&core.Session{
+					Id:        "2222222",
+					UserId:    "1111111",
+					ExpiresAt: sessionNow.Add(time.Hour),
+				}, nil).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, repository.ErrNotFound)
+			},
+		},
+		"not_found": {
+			userId: "1111111",
+			setupMocks: func(ctx context.Context, mockSessions *repoMocks.MockSessions) {
+				mockSessions.EXPECT().GetById(ctx, "2222222").Return(nil, repository.ErrNotFound).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, repository.ErrNotFound)
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, mockSessions, _ := getSessionsService(t)
+			ctx := context.Background()
+			tc.setupMocks(ctx, mockSessions)
+
+			err := s.Revoke(ctx, tc.userId, "2222222")
+
+			tc.checkError(t, err)
+		})
+	}
+}
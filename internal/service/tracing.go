@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/pkg/security"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/zhuravlev-pe/course-watch/internal/service"
+
+// The traced* types wrap services with a span per method call, so that repository queries show up under the service
+// operation that issued them. Only identifiers are recorded; credentials, tokens and other inputs stay off the spans
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func userIdAttr(id string) attribute.KeyValue {
+	return semconv.EnduserIDKey.String(id)
+}
+
+type tracedUsers struct {
+	next Users
+}
+
+func (t tracedUsers) GetUserInfo(ctx context.Context, id string) (*GetUserInfoOutput, error) {
+	ctx, span := startSpan(ctx, "Users.GetUserInfo", userIdAttr(id))
+	output, err := t.next.GetUserInfo(ctx, id)
+	endSpan(span, err)
+	return output, err
+}
+
+func (t tracedUsers) UpdateUserInfo(ctx context.Context, id string, input *UpdateUserInfoInput) error {
+	ctx, span := startSpan(ctx, "Users.UpdateUserInfo", userIdAttr(id))
+	err := t.next.UpdateUserInfo(ctx, id, input)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedUsers) PatchUserInfo(ctx context.Context, id string, input *PatchUserInfoInput) error {
+	ctx, span := startSpan(ctx, "Users.PatchUserInfo", userIdAttr(id))
+	err := t.next.PatchUserInfo(ctx, id, input)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedUsers) Login(ctx context.Context, input *LoginInput) (*core.User, error) {
+	ctx, span := startSpan(ctx, "Users.Login")
+	user, err := t.next.Login(ctx, input)
+	if err == nil {
+		span.SetAttributes(userIdAttr(user.Id))
+	}
+	endSpan(span, err)
+	return user, err
+}
+
+func (t tracedUsers) Signup(ctx context.Context, input *SignupUserInput) error {
+	ctx, span := startSpan(ctx, "Users.Signup")
+	err := t.next.Signup(ctx, input)
+	endSpan(span, err)
+	return err
+}
+
+type tracedSessions struct {
+	next Sessions
+}
+
+func (t tracedSessions) Create(ctx context.Context, input *CreateSessionInput) (*core.Session, error) {
+	ctx, span := startSpan(ctx, "Sessions.Create", userIdAttr(input.UserId))
+	session, err := t.next.Create(ctx, input)
+	endSpan(span, err)
+	return session, err
+}
+
+func (t tracedSessions) ValidateSession(ctx context.Context, principal *security.UserPrincipal) error {
+	ctx, span := startSpan(ctx, "Sessions.ValidateSession", userIdAttr(principal.UserId))
+	err := t.next.ValidateSession(ctx, principal)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedSessions) List(ctx context.Context, userId string, currentSessionId string) ([]*SessionOutput, error) {
+	ctx, span := startSpan(ctx, "Sessions.List", userIdAttr(userId))
+	sessions, err := t.next.List(ctx, userId, currentSessionId)
+	endSpan(span, err)
+	return sessions, err
+}
+
+func (t tracedSessions) Revoke(ctx context.Context, userId string, sessionId string) error {
+	ctx, span := startSpan(ctx, "Sessions.Revoke", userIdAttr(userId))
+	err := t.next.Revoke(ctx, userId, sessionId)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedSessions) RevokeOthers(ctx context.Context, userId string, currentSessionId string) error {
+	ctx, span := startSpan(ctx, "Sessions.RevokeOthers", userIdAttr(userId))
+	err := t.next.RevokeOthers(ctx, userId, currentSessionId)
+	endSpan(span, err)
+	return err
+}
+
+type tracedCourses struct {
+	next Courses
+}
+
+func (t tracedCourses) GetById(ctx context.Context, id string) (*core.Course, error) {
+	ctx, span := startSpan(ctx, "Courses.GetById", attribute.String("course.id", id))
+	course, err := t.next.GetById(ctx, id)
+	endSpan(span, err)
+	return course, err
+}
+
+func (t tracedCourses) Create(ctx context.Context, input CreateCourseInput) (*core.Course, error) {
+	ctx, span := startSpan(ctx, "Courses.Create")
+	course, err := t.next.Create(ctx, input)
+	endSpan(span, err)
+	return course, err
+}
+
+func (t tracedCourses) Update(ctx context.Context, id string, input *UpdateCourseInput) error {
+	ctx, span := startSpan(ctx, "Courses.Update", attribute.String("course.id", id))
+	err := t.next.Update(ctx, id, input)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedCourses) Patch(ctx context.Context, id string, input *PatchCourseInput) error {
+	ctx, span := startSpan(ctx, "Courses.Patch", attribute.String("course.id", id))
+	err := t.next.Patch(ctx, id, input)
+	endSpan(span, err)
+	return err
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	repoMocks "github.com/zhuravlev-pe/course-watch/internal/repository/mocks"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedUsers_Login(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	hash, err := testHasher.Hash("secret")
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		password   string
+		statusCode codes.Code
+		userId     bool
+	}{
+		"success":        {password: "secret", statusCode: codes.Unset, userId: true},
+		"wrong_password": {password: "wrong", statusCode: codes.Error},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockUsers := repoMocks.NewMockUsers(ctrl)
+			mockUsers.EXPECT().GetByEmail(gomock.Any(), "doe.h@example.com").
+				DoAndReturn(func(ctx context.Context, _ string) (*core.User, error) {
+					// repository calls must run within the service span
+					if !trace.SpanContextFromContext(ctx).IsValid() {
+						return nil, errors.New("no span in the context")
+					}
+					return &core.User{Id: "1111111", Email: "doe.h@example.com", HashedPassword: hash}, nil
+				})
+			users := tracedUsers{next: newUsersService(mockUsers, nil, testHasher)}
+			before := len(recorder.Ended())
+
+			_, _ = users.Login(context.Background(), &LoginInput{Email: "doe.h@example.com", Password: tc.password})
+
+			spans := recorder.Ended()
+			require.Len(t, spans, before+1)
+			span := spans[before]
+			assert.Equal(t, "Users.Login", span.Name())
+			assert.Equal(t, tc.statusCode, span.Status().Code)
+			if tc.userId {
+				assert.Contains(t, span.Attributes(), attribute.String("enduser.id", "1111111"))
+			}
+			for _, attr := range span.Attributes() {
+				assert.NotContains(t, attr.Value.Emit(), tc.password)
+			}
+		})
+	}
+}
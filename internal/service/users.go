@@ -2,19 +2,21 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/zhuravlev-pe/course-watch/internal/core"
 	"github.com/zhuravlev-pe/course-watch/internal/repository"
 	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/password"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type usersService struct {
-	repo  repository.Users
-	idGen *idgen.IdGen
+	repo   repository.Users
+	idGen  *idgen.IdGen
+	hasher password.Hasher
 }
 
 func (u *usersService) GetUserInfo(ctx context.Context, id string) (*GetUserInfoOutput, error) {
@@ -30,6 +32,7 @@ func (u *usersService) GetUserInfo(ctx context.Context, id string) (*GetUserInfo
 	result.DisplayName = user.DisplayName
 	result.RegistrationDate = user.RegistrationDate
 	result.Roles = user.Roles
+	result.Version = user.Version
 	return &result, nil
 }
 
@@ -52,9 +55,29 @@ func (u *usersService) UpdateUserInfo(ctx context.Context, id string, input *Upd
 	upd.FirstName = input.FirstName
 	upd.LastName = input.LastName
 	upd.DisplayName = input.DisplayName
+	upd.ExpectedVersion = input.Version
 	return u.repo.Update(ctx, id, &upd)
 }
 
+func (i *PatchUserInfoInput) Validate() error {
+	return validation.ValidateStruct(i,
+		validation.Field(&i.FirstName, validation.When(i.FirstName.Set, validation.Required)),
+		validation.Field(&i.LastName, validation.When(i.LastName.Set, validation.Required)),
+	)
+}
+
+func (u *usersService) PatchUserInfo(ctx context.Context, id string, input *PatchUserInfoInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return u.repo.Patch(ctx, id, &repository.PatchUserInput{
+		FirstName:       input.FirstName,
+		LastName:        input.LastName,
+		DisplayName:     input.DisplayName,
+		ExpectedVersion: input.Version,
+	})
+}
+
 func (u *usersService) Signup(ctx context.Context, input *SignupUserInput) error {
 	user, err := u.repo.GetByEmail(ctx, input.Email)
 	if err != nil && err != repository.ErrNotFound {
@@ -66,7 +89,7 @@ func (u *usersService) Signup(ctx context.Context, input *SignupUserInput) error
 		return ErrUserAlreadyExist
 	}
 
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashPassword, err := u.hasher.Hash(input.Password)
 	if err != nil {
 		return err
 	}
@@ -88,6 +111,19 @@ func (u *usersService) Signup(ctx context.Context, input *SignupUserInput) error
 }
 
 func (u *usersService) Login(ctx context.Context, input *LoginInput) (*core.User, error) {
+	user, err := u.login(ctx, input)
+	switch {
+	case err == nil:
+		loginAttempts.WithLabelValues(loginSuccess).Inc()
+	case errors.Is(err, ErrInvalidCredentials):
+		loginAttempts.WithLabelValues(loginFailure).Inc()
+	default:
+		loginAttempts.WithLabelValues(loginError).Inc()
+	}
+	return user, err
+}
+
+func (u *usersService) login(ctx context.Context, input *LoginInput) (*core.User, error) {
 	user, err := u.repo.GetByEmail(ctx, input.Email)
 	if err != nil && err != repository.ErrNotFound {
 		return nil, err
@@ -97,15 +133,36 @@ func (u *usersService) Login(ctx context.Context, input *LoginInput) (*core.User
 		return nil, ErrInvalidCredentials
 	}
 
-	if err = bcrypt.CompareHashAndPassword(user.HashedPassword, []byte(input.Password)); err != nil {
+	ok, needsRehash, err := u.hasher.Verify(input.Password, user.HashedPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
 		return nil, ErrInvalidCredentials
 	}
+	if needsRehash {
+		u.rehash(ctx, user, input.Password)
+	}
 	return user, nil
 }
 
-func newUsersService(repo repository.Users, idGen *idgen.IdGen) Users {
+// rehash upgrades the stored hash to the current algorithm and parameters. Failures are ignored, since the login itself
+// has already succeeded and the upgrade will be retried on the next one
+func (u *usersService) rehash(ctx context.Context, user *core.User, plainPassword string) {
+	hash, err := u.hasher.Hash(plainPassword)
+	if err != nil {
+		return
+	}
+	if err = u.repo.UpdatePassword(ctx, user.Id, hash); err != nil {
+		return
+	}
+	user.HashedPassword = hash
+}
+
+func newUsersService(repo repository.Users, idGen *idgen.IdGen, hasher password.Hasher) Users {
 	return &usersService{
-		repo:  repo,
-		idGen: idGen,
+		repo:   repo,
+		idGen:  idGen,
+		hasher: hasher,
 	}
 }
@@ -5,13 +5,17 @@ import (
 	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zhuravlev-pe/course-watch/internal/core"
 	"github.com/zhuravlev-pe/course-watch/internal/repository"
 	repoMocks "github.com/zhuravlev-pe/course-watch/internal/repository/mocks"
 	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+	"github.com/zhuravlev-pe/course-watch/pkg/password"
+	"github.com/zhuravlev-pe/course-watch/pkg/patch"
 	"github.com/zhuravlev-pe/course-watch/pkg/security"
+	"golang.org/x/crypto/bcrypt"
 	"testing"
 	"time"
 )
@@ -19,6 +23,15 @@ import (
 var someDatabaseError = errors.New("some database error")
 var someDate = time.Now()
 
+// cheap argon2id parameters to keep the tests fast
+var testHasher = password.NewArgon2idHasher(password.Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+})
+
 func noError(t *testing.T, err error) {
 	assert.NoError(t, err)
 }
@@ -83,7 +96,7 @@ func TestUsersService_GetUserInfo(t *testing.T) {
 			mockUsers := repoMocks.NewMockUsers(mockCtrl)
 			gen, err := idgen.New(1)
 			assert.NoError(t, err)
-			s := newUsersService(mockUsers, gen)
+			s := newUsersService(mockUsers, gen, testHasher)
 			ctx := context.Background()
 			tc.setupMocks(ctx, mockUsers)
 
@@ -166,6 +179,27 @@ func TestUsersService_UpdateUserInfo(t *testing.T) {
 				assert.ErrorIs(t, err, someDatabaseError)
 			},
 		},
+		"version_conflict": {
+			id: "1111111",
+			input: &UpdateUserInfoInput{
+				FirstName:   "John",
+				LastName:    "Doe",
+				DisplayName: "JohnnyD",
+				Version:     2,
+			},
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetById(ctx, "1111111").Return(nil, nil).Times(1)
+				var upd repository.UpdateUserInput
+				upd.FirstName = "John"
+				upd.LastName = "Doe"
+				upd.DisplayName = "JohnnyD"
+				upd.ExpectedVersion = 2
+				mockUsers.EXPECT().Update(ctx, "1111111", &upd).Return(repository.ErrVersionConflict).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, repository.ErrVersionConflict)
+			},
+		},
 		"validation_firstName_required": {
 			id: "1111111",
 			input: &UpdateUserInfoInput{
@@ -204,7 +238,7 @@ func TestUsersService_UpdateUserInfo(t *testing.T) {
 			mockUsers := repoMocks.NewMockUsers(mockCtrl)
 			gen, err := idgen.New(1)
 			assert.NoError(t, err)
-			s := newUsersService(mockUsers, gen)
+			s := newUsersService(mockUsers, gen, testHasher)
 			ctx := context.Background()
 			tc.setupMocks(ctx, mockUsers)
 
@@ -214,3 +248,206 @@ func TestUsersService_UpdateUserInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestUsersService_PatchUserInfo(t *testing.T) {
+	cases := map[string]struct {
+		input      *PatchUserInfoInput
+		setupMocks func(context.Context, *repoMocks.MockUsers)
+		checkError func(*testing.T, error)
+	}{
+		"success": {
+			input: &PatchUserInfoInput{
+				LastName:    patch.Value("Doe"),
+				DisplayName: patch.Null[string](),
+				Version:     2,
+			},
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				upd := &repository.PatchUserInput{
+					LastName:        patch.Value("Doe"),
+					DisplayName:     patch.Null[string](),
+					ExpectedVersion: 2,
+				}
+				mockUsers.EXPECT().Patch(ctx, "1111111", upd).Return(nil).Times(1)
+			},
+			checkError: noError,
+		},
+		"not_found": {
+			input: &PatchUserInfoInput{FirstName: patch.Value("John")},
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				upd := &repository.PatchUserInput{FirstName: patch.Value("John")}
+				mockUsers.EXPECT().Patch(ctx, "1111111", upd).Return(repository.ErrNotFound).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, repository.ErrNotFound)
+			},
+		},
+		"validation_null_names": {
+			input: &PatchUserInfoInput{
+				FirstName: patch.Null[string](),
+				LastName:  patch.Value(""),
+			},
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {},
+			checkError: func(t *testing.T, err error) {
+				var errs validation.Errors
+				require.True(t, errors.As(err, &errs))
+				assert.Equal(t, 2, len(errs))
+				assert.Contains(t, errs, "first_name")
+				assert.Contains(t, errs, "last_name")
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockUsers := repoMocks.NewMockUsers(mockCtrl)
+			gen, err := idgen.New(1)
+			assert.NoError(t, err)
+			s := newUsersService(mockUsers, gen, testHasher)
+			ctx := context.Background()
+			tc.setupMocks(ctx, mockUsers)
+
+			err = s.PatchUserInfo(ctx, "1111111", tc.input)
+
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestUsersService_Login(t *testing.T) {
+	current, err := testHasher.Hash("secret")
+	require.NoError(t, err)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	userWithHash := func(hash []byte) *core.User {
+		return &core.User{Id: "1111111", Email: "doe.h@example.com", HashedPassword: hash}
+	}
+	isRehashed := func(t *testing.T, hash []byte) {
+		ok, needsRehash, err := testHasher.Verify("secret", hash)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.False(t, needsRehash)
+	}
+
+	cases := map[string]struct {
+		password   string
+		setupMocks func(context.Context, *repoMocks.MockUsers)
+		result     string
+		checkUser  func(*testing.T, *core.User)
+		checkError func(*testing.T, error)
+	}{
+		"success": {
+			password: "secret",
+			result:   loginSuccess,
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetByEmail(ctx, "doe.h@example.com").Return(userWithHash(current), nil).Times(1)
+				mockUsers.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkUser: func(t *testing.T, user *core.User) {
+				assert.Equal(t, current, user.HashedPassword)
+			},
+			checkError: noError,
+		},
+		"bcrypt_rehashed": {
+			password: "secret",
+			result:   loginSuccess,
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetByEmail(ctx, "doe.h@example.com").Return(userWithHash(legacy), nil).Times(1)
+				mockUsers.EXPECT().UpdatePassword(ctx, "1111111", gomock.Any()).
+					Do(func(_ context.Context, _ string, hash []byte) {
+						isRehashed(t, hash)
+					}).Return(nil).Times(1)
+			},
+			checkUser: func(t *testing.T, user *core.User) {
+				isRehashed(t, user.HashedPassword)
+			},
+			checkError: noError,
+		},
+		"rehash_failure_ignored": {
+			password: "secret",
+			result:   loginSuccess,
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetByEmail(ctx, "doe.h@example.com").Return(userWithHash(legacy), nil).Times(1)
+				mockUsers.EXPECT().UpdatePassword(ctx, "1111111", gomock.Any()).Return(someDatabaseError).Times(1)
+			},
+			checkUser: func(t *testing.T, user *core.User) {
+				assert.Equal(t, legacy, user.HashedPassword)
+			},
+			checkError: noError,
+		},
+		"wrong_password": {
+			password: "wrong",
+			result:   loginFailure,
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetByEmail(ctx, "doe.h@example.com").Return(userWithHash(legacy), nil).Times(1)
+				mockUsers.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkUser: func(t *testing.T, user *core.User) {
+				assert.Nil(t, user)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+			},
+		},
+		"no_password": {
+			password: "",
+			result:   loginFailure,
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetByEmail(ctx, "doe.h@example.com").Return(userWithHash(nil), nil).Times(1)
+			},
+			checkUser: func(t *testing.T, user *core.User) {
+				assert.Nil(t, user)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+			},
+		},
+		"db_error": {
+			password: "secret",
+			result:   loginError,
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetByEmail(ctx, "doe.h@example.com").Return(nil, someDatabaseError).Times(1)
+			},
+			checkUser: func(t *testing.T, user *core.User) {
+				assert.Nil(t, user)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, someDatabaseError)
+			},
+		},
+		"not_found": {
+			password: "secret",
+			result:   loginFailure,
+			setupMocks: func(ctx context.Context, mockUsers *repoMocks.MockUsers) {
+				mockUsers.EXPECT().GetByEmail(ctx, "doe.h@example.com").Return(nil, repository.ErrNotFound).Times(1)
+			},
+			checkUser: func(t *testing.T, user *core.User) {
+				assert.Nil(t, user)
+			},
+			checkError: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrInvalidCredentials)
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockUsers := repoMocks.NewMockUsers(mockCtrl)
+			gen, err := idgen.New(1)
+			assert.NoError(t, err)
+			s := newUsersService(mockUsers, gen, testHasher)
+			ctx := context.Background()
+			tc.setupMocks(ctx, mockUsers)
+
+			counter := loginAttempts.WithLabelValues(tc.result)
+			before := testutil.ToFloat64(counter)
+
+			user, err := s.Login(ctx, &LoginInput{Email: "doe.h@example.com", Password: tc.password})
+
+			tc.checkUser(t, user)
+			tc.checkError(t, err)
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/zhuravlev-pe/course-watch/internal/core"
+	"github.com/zhuravlev-pe/course-watch/internal/repository"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+)
+
+type webAuthnService struct {
+	repo     repository.WebAuthn
+	users    repository.Users
+	idGen    *idgen.IdGen
+	settings WebAuthnSettings
+	now      func() time.Time
+}
+
+func newWebAuthnService(
+	repo repository.WebAuthn,
+	users repository.Users,
+	idGen *idgen.IdGen,
+	settings WebAuthnSettings,
+) WebAuthn {
+	return &webAuthnService{
+		repo:     repo,
+		users:    users,
+		idGen:    idGen,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// webAuthnUser adapts core.User and its credentials to the webauthn.User interface
+type webAuthnUser struct {
+	user        *core.User
+	credentials []*core.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(u.user.Id)
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if u.user.DisplayName != "" {
+		return u.user.DisplayName
+	}
+	return strings.TrimSpace(u.user.FirstName + " " + u.user.LastName)
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	result := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		id, err := base64.RawURLEncoding.DecodeString(c.Id)
+		if err != nil {
+			continue
+		}
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, t := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+		result = append(result, webauthn.Credential{
+			ID:              id,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+	return result
+}
+
+func (s *webAuthnService) loadUser(ctx context.Context, user *core.User) (*webAuthnUser, error) {
+	credentials, err := s.repo.ListCredentialsByUser(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+func (s *webAuthnService) BeginRegistration(ctx context.Context, userId string) (*WebAuthnBeginOutput, error) {
+	user, err := s.users.GetById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	waUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(waUser.credentials))
+	for _, c := range waUser.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+	options, sessionData, err := s.settings.RelyingParty.BeginRegistration(waUser, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, err
+	}
+	return s.startCeremony(ctx, user.Id, core.WebAuthnRegistration, sessionData, options)
+}
+
+func (s *webAuthnService) FinishRegistration(ctx context.Context, userId string, input *WebAuthnFinishInput) error {
+	sessionData, err := s.finishCeremony(ctx, input.CeremonyId, core.WebAuthnRegistration, userId)
+	if err != nil {
+		return err
+	}
+	user, err := s.users.GetById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	waUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(input.Credential))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrWebAuthnFailed, describeProtocolError(err))
+	}
+	credential, err := s.settings.RelyingParty.CreateCredential(waUser, *sessionData, parsed)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrWebAuthnFailed, describeProtocolError(err))
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+	return s.repo.InsertCredential(ctx, &core.WebAuthnCredential{
+		Id:              base64.RawURLEncoding.EncodeToString(credential.ID),
+		UserId:          userId,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      transports,
+		CreatedAt:       s.now(),
+	})
+}
+
+func (s *webAuthnService) BeginLogin(ctx context.Context, input *WebAuthnLoginInput) (*WebAuthnBeginOutput, error) {
+	user, err := s.users.GetByEmail(ctx, input.Email)
+	if err == repository.ErrNotFound {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	waUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(waUser.credentials) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+
+	options, sessionData, err := s.settings.RelyingParty.BeginLogin(waUser)
+	if err != nil {
+		return nil, err
+	}
+	return s.startCeremony(ctx, user.Id, core.WebAuthnLogin, sessionData, options)
+}
+
+func (s *webAuthnService) FinishLogin(ctx context.Context, input *WebAuthnFinishInput) (*core.User, error) {
+	sessionData, err := s.finishCeremony(ctx, input.CeremonyId, core.WebAuthnLogin, "")
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetById(ctx, string(sessionData.UserID))
+	if err != nil {
+		return nil, err
+	}
+	waUser, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(input.Credential))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebAuthnFailed, describeProtocolError(err))
+	}
+	credential, err := s.settings.RelyingParty.ValidateLogin(waUser, *sessionData, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebAuthnFailed, describeProtocolError(err))
+	}
+	// The sign count must grow with every assertion. Otherwise, the credential's private key has likely been cloned
+	if credential.Authenticator.CloneWarning {
+		return nil, fmt.Errorf("%w: signature counter did not increase", ErrWebAuthnFailed)
+	}
+
+	id := base64.RawURLEncoding.EncodeToString(credential.ID)
+	if err = s.repo.UpdateCredentialUsage(ctx, id, credential.Authenticator.SignCount, s.now()); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *webAuthnService) startCeremony(
+	ctx context.Context,
+	userId string,
+	kind core.WebAuthnCeremonyKind,
+	sessionData *webauthn.SessionData,
+	options interface{},
+) (*WebAuthnBeginOutput, error) {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	ceremony := &core.WebAuthnCeremony{
+		Id:        s.idGen.Generate(),
+		UserId:    userId,
+		Kind:      kind,
+		Data:      data,
+		ExpiresAt: s.now().Add(s.settings.CeremonyTimeout),
+	}
+	if err = s.repo.InsertCeremony(ctx, ceremony); err != nil {
+		return nil, err
+	}
+	return &WebAuthnBeginOutput{CeremonyId: ceremony.Id, Options: options}, nil
+}
+
+// finishCeremony consumes the ceremony and returns its session data. If userId is not empty, the ceremony must have
+// been started by that user
+func (s *webAuthnService) finishCeremony(
+	ctx context.Context,
+	id string,
+	kind core.WebAuthnCeremonyKind,
+	userId string,
+) (*webauthn.SessionData, error) {
+	ceremony, err := s.repo.TakeCeremony(ctx, id)
+	if err == repository.ErrNotFound {
+		return nil, fmt.Errorf("%w: unknown ceremony", ErrWebAuthnFailed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.Kind != kind || (userId != "" && ceremony.UserId != userId) || !s.now().Before(ceremony.ExpiresAt) {
+		return nil, fmt.Errorf("%w: unknown ceremony", ErrWebAuthnFailed)
+	}
+	var sessionData webauthn.SessionData
+	if err = json.Unmarshal(ceremony.Data, &sessionData); err != nil {
+		return nil, err
+	}
+	return &sessionData, nil
+}
+
+func describeProtocolError(err error) string {
+	if pErr, ok := err.(*protocol.Error); ok && pErr.Details != "" {
+		return pErr.Details
+	}
+	return err.Error()
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/internal/repository/fake_repo"
+	"github.com/zhuravlev-pe/course-watch/pkg/idgen"
+)
+
+const (
+	testRPID     = "localhost"
+	testRPOrigin = "https://localhost:8080"
+)
+
+// softAuthenticator is a minimal software implementation of a FIDO2 authenticator with "none" attestation and an
+// ES256 key, sufficient to drive the registration and authentication ceremonies in tests
+type softAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialId []byte
+	signCount    uint32
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialId := make([]byte, 16)
+	_, err = rand.Read(credentialId)
+	require.NoError(t, err)
+	return &softAuthenticator{t: t, key: key, credentialId: credentialId, origin: testRPOrigin}
+}
+
+func (a *softAuthenticator) clientData(ceremonyType string, challenge []byte) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremonyType,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	require.NoError(a.t, err)
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte{}, rpIdHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+// create emulates navigator.credentials.create()
+func (a *softAuthenticator) create(options interface{}) json.RawMessage {
+	creation, ok := options.(*protocol.CredentialCreation)
+	require.True(a.t, ok)
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(a.t, err)
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialId)))
+	attested = append(attested, a.credentialId...)
+	attested = append(attested, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x45, attested), // UP | UV | AT
+	})
+	require.NoError(a.t, err)
+
+	return a.marshalCredential(map[string]string{
+		"clientDataJSON":    encodeB64(a.clientData("webauthn.create", creation.Response.Challenge)),
+		"attestationObject": encodeB64(attestationObject),
+	})
+}
+
+// get emulates navigator.credentials.get()
+func (a *softAuthenticator) get(options interface{}) json.RawMessage {
+	assertion, ok := options.(*protocol.CredentialAssertion)
+	require.True(a.t, ok)
+
+	a.signCount++
+	authData := a.authData(0x05, nil) // UP | UV
+	clientData := a.clientData("webauthn.get", assertion.Response.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	return a.marshalCredential(map[string]string{
+		"clientDataJSON":    encodeB64(clientData),
+		"authenticatorData": encodeB64(authData),
+		"signature":         encodeB64(signature),
+	})
+}
+
+func (a *softAuthenticator) marshalCredential(response map[string]string) json.RawMessage {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       encodeB64(a.credentialId),
+		"rawId":    encodeB64(a.credentialId),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(a.t, err)
+	return data
+}
+
+func encodeB64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func getWebAuthnService(t *testing.T) *webAuthnService {
+	t.Helper()
+	repos := fake_repo.New()
+	gen, err := idgen.New(1)
+	require.NoError(t, err)
+	rp, err := webauthn.New(&webauthn.Config{
+		RPID:          testRPID,
+		RPOrigin:      testRPOrigin,
+		RPDisplayName: "Course Watch",
+	})
+	require.NoError(t, err)
+	return newWebAuthnService(repos.WebAuthn, repos.Users, gen, WebAuthnSettings{
+		RelyingParty:    rp,
+		CeremonyTimeout: time.Minute,
+	}).(*webAuthnService)
+}
+
+func registerSoftAuthenticator(t *testing.T, s *webAuthnService, a *softAuthenticator) {
+	t.Helper()
+	ctx := context.Background()
+	begin, err := s.BeginRegistration(ctx, fake_repo.SampleUser.Id)
+	require.NoError(t, err)
+	err = s.FinishRegistration(ctx, fake_repo.SampleUser.Id, &WebAuthnFinishInput{
+		CeremonyId: begin.CeremonyId,
+		Credential: a.create(begin.Options),
+	})
+	require.NoError(t, err)
+}
+
+func TestWebAuthnService_RegisterAndLogin(t *testing.T) {
+	s := getWebAuthnService(t)
+	a := newSoftAuthenticator(t)
+	ctx := context.Background()
+	registerSoftAuthenticator(t, s, a)
+
+	for i := 0; i < 2; i++ {
+		begin, err := s.BeginLogin(ctx, &WebAuthnLoginInput{Email: fake_repo.SampleUser.Email})
+		require.NoError(t, err)
+
+		user, err := s.FinishLogin(ctx, &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: a.get(begin.Options)})
+		require.NoError(t, err)
+		assert.Equal(t, fake_repo.SampleUser.Id, user.Id)
+	}
+
+	credentials, err := s.repo.ListCredentialsByUser(ctx, fake_repo.SampleUser.Id)
+	require.NoError(t, err)
+	require.Len(t, credentials, 1)
+	assert.Equal(t, uint32(2), credentials[0].SignCount)
+}
+
+func TestWebAuthnService_Login_Failures(t *testing.T) {
+	cases := map[string]struct {
+		tamper func(s *webAuthnService, a *softAuthenticator, begin *WebAuthnBeginOutput) *WebAuthnFinishInput
+	}{
+		"sign_count_not_increased": {
+			tamper: func(s *webAuthnService, a *softAuthenticator, begin *WebAuthnBeginOutput) *WebAuthnFinishInput {
+				// a cloned authenticator replays an old counter value
+				a.signCount = 0
+				return &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: a.get(begin.Options)}
+			},
+		},
+		"foreign_key": {
+			tamper: func(s *webAuthnService, a *softAuthenticator, begin *WebAuthnBeginOutput) *WebAuthnFinishInput {
+				impostor := newSoftAuthenticator(a.t)
+				impostor.credentialId = a.credentialId
+				impostor.signCount = 10
+				return &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: impostor.get(begin.Options)}
+			},
+		},
+		"wrong_origin": {
+			tamper: func(s *webAuthnService, a *softAuthenticator, begin *WebAuthnBeginOutput) *WebAuthnFinishInput {
+				a.origin = "https://phishing.example.com"
+				return &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: a.get(begin.Options)}
+			},
+		},
+		"unknown_ceremony": {
+			tamper: func(s *webAuthnService, a *softAuthenticator, begin *WebAuthnBeginOutput) *WebAuthnFinishInput {
+				return &WebAuthnFinishInput{CeremonyId: "42", Credential: a.get(begin.Options)}
+			},
+		},
+		"expired_ceremony": {
+			tamper: func(s *webAuthnService, a *softAuthenticator, begin *WebAuthnBeginOutput) *WebAuthnFinishInput {
+				s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+				return &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: a.get(begin.Options)}
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := getWebAuthnService(t)
+			a := newSoftAuthenticator(t)
+			ctx := context.Background()
+			registerSoftAuthenticator(t, s, a)
+			// one successful login, so that the stored sign count is not zero
+			begin, err := s.BeginLogin(ctx, &WebAuthnLoginInput{Email: fake_repo.SampleUser.Email})
+			require.NoError(t, err)
+			_, err = s.FinishLogin(ctx, &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: a.get(begin.Options)})
+			require.NoError(t, err)
+
+			begin, err = s.BeginLogin(ctx, &WebAuthnLoginInput{Email: fake_repo.SampleUser.Email})
+			require.NoError(t, err)
+			_, err = s.FinishLogin(ctx, tc.tamper(s, a, begin))
+
+			assert.ErrorIs(t, err, ErrWebAuthnFailed)
+		})
+	}
+}
+
+func TestWebAuthnService_BeginLogin_NoCredentials(t *testing.T) {
+	s := getWebAuthnService(t)
+
+	_, err := s.BeginLogin(context.Background(), &WebAuthnLoginInput{Email: fake_repo.SampleUser.Email})
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestWebAuthnService_Ceremony_SingleUse(t *testing.T) {
+	s := getWebAuthnService(t)
+	a := newSoftAuthenticator(t)
+	ctx := context.Background()
+	registerSoftAuthenticator(t, s, a)
+
+	begin, err := s.BeginLogin(ctx, &WebAuthnLoginInput{Email: fake_repo.SampleUser.Email})
+	require.NoError(t, err)
+	credential := a.get(begin.Options)
+	_, err = s.FinishLogin(ctx, &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: credential})
+	require.NoError(t, err)
+
+	_, err = s.FinishLogin(ctx, &WebAuthnFinishInput{CeremonyId: begin.CeremonyId, Credential: credential})
+
+	assert.ErrorIs(t, err, ErrWebAuthnFailed)
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.sessions;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE public.sessions
+(
+    id              TEXT NOT NULL PRIMARY KEY,
+    user_id         TEXT NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    device_label    TEXT NOT NULL DEFAULT '',
+    ip              TEXT NOT NULL DEFAULT '',
+    user_agent      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL,
+    last_seen_at    TIMESTAMPTZ NOT NULL,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    revoked_at      TIMESTAMPTZ
+);
+
+CREATE INDEX sessions_user_id_idx ON public.sessions (user_id);
@@ -0,0 +1,11 @@
+CREATE TABLE public.magic_links
+(
+    id              TEXT NOT NULL PRIMARY KEY,
+    user_id         TEXT NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    email           TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    used_at         TIMESTAMPTZ
+);
+
+CREATE INDEX magic_links_email_created_at_idx ON public.magic_links (email, created_at);
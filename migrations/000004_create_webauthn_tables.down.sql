@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.webauthn_ceremonies;
+DROP TABLE IF EXISTS public.webauthn_credentials;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE public.webauthn_credentials
+(
+    id                  TEXT NOT NULL PRIMARY KEY,
+    user_id             TEXT NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    public_key          BYTEA NOT NULL,
+    attestation_type    TEXT NOT NULL,
+    aaguid              BYTEA NOT NULL,
+    sign_count          BIGINT NOT NULL,
+    transports          TEXT[] NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL,
+    last_used_at        TIMESTAMPTZ
+);
+
+CREATE INDEX webauthn_credentials_user_id_idx ON public.webauthn_credentials (user_id);
+
+CREATE TABLE public.webauthn_ceremonies
+(
+    id                  TEXT NOT NULL PRIMARY KEY,
+    user_id             TEXT NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    kind                TEXT NOT NULL,
+    data                BYTEA NOT NULL,
+    expires_at          TIMESTAMPTZ NOT NULL
+);
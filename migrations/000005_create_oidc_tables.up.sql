@@ -0,0 +1,20 @@
+CREATE TABLE public.user_identities
+(
+    provider            TEXT NOT NULL,
+    subject             TEXT NOT NULL,
+    user_id             TEXT NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    email               TEXT NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX user_identities_user_id_idx ON public.user_identities (user_id);
+
+CREATE TABLE public.oidc_login_states
+(
+    state               TEXT NOT NULL PRIMARY KEY,
+    provider            TEXT NOT NULL,
+    nonce               TEXT NOT NULL,
+    code_verifier       TEXT NOT NULL,
+    expires_at          TIMESTAMPTZ NOT NULL
+);
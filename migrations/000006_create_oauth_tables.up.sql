@@ -0,0 +1,20 @@
+CREATE TABLE public.oauth_clients
+(
+    id                  TEXT NOT NULL PRIMARY KEY,
+    name                TEXT NOT NULL,
+    hashed_secret       BYTEA NOT NULL DEFAULT '',
+    redirect_uris       TEXT[] NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE public.oauth_authorization_codes
+(
+    code_hash           TEXT NOT NULL PRIMARY KEY,
+    client_id           TEXT NOT NULL REFERENCES public.oauth_clients (id) ON DELETE CASCADE,
+    user_id             TEXT NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    redirect_uri        TEXT NOT NULL,
+    scope               TEXT NOT NULL,
+    nonce               TEXT NOT NULL DEFAULT '',
+    code_challenge      TEXT NOT NULL,
+    expires_at          TIMESTAMPTZ NOT NULL
+);
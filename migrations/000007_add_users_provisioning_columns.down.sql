@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.users_external_id_idx;
+
+ALTER TABLE public.users
+    DROP COLUMN IF EXISTS external_id,
+    DROP COLUMN IF EXISTS disabled;
\ No newline at end of file
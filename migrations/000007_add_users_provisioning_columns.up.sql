@@ -0,0 +1,5 @@
+ALTER TABLE public.users
+    ADD COLUMN disabled     BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN external_id  TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX users_external_id_idx ON public.users (external_id);
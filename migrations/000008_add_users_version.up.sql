@@ -0,0 +1,2 @@
+ALTER TABLE public.users
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
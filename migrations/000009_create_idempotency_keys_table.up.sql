@@ -0,0 +1,15 @@
+CREATE TABLE public.idempotency_keys
+(
+    user_id         TEXT NOT NULL,
+    route           TEXT NOT NULL,
+    key             TEXT NOT NULL,
+    fingerprint     TEXT NOT NULL,
+    status_code     INT,
+    headers         JSONB,
+    body            BYTEA,
+    created_at      TIMESTAMPTZ NOT NULL,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, route, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON public.idempotency_keys (expires_at);
@@ -0,0 +1 @@
+-- the cleared fingerprints cannot be restored
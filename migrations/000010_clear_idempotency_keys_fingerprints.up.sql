@@ -0,0 +1,4 @@
+-- the fingerprints used to be plain hashes of the request bodies, which may contain passwords. Retries of these
+-- requests fail as reusing the key with a different request until the keys expire
+UPDATE public.idempotency_keys
+    SET fingerprint = '';
@@ -0,0 +1,2 @@
+ALTER TABLE public.idempotency_keys
+    DROP COLUMN IF EXISTS token;
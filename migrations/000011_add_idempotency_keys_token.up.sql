@@ -0,0 +1,2 @@
+ALTER TABLE public.idempotency_keys
+    ADD COLUMN token TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+CREATE TABLE public.magic_link_attempts
+(
+    email           TEXT NOT NULL PRIMARY KEY,
+    window_start    TIMESTAMPTZ NOT NULL,
+    count           INT NOT NULL
+);
+
+CREATE INDEX magic_link_attempts_window_start_idx ON public.magic_link_attempts (window_start);
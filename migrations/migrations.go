@@ -0,0 +1,37 @@
+// Package migrations embeds the SQL migrations, so that the binary knows the schema version it was built for
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the migrations in the golang-migrate layout: <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion returns the version of the newest migration, i.e. the schema version the code expects
+func LatestVersion() (uint, error) {
+	entries, err := fs.ReadDir(FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	var latest uint
+	for _, entry := range entries {
+		prefix, _, found := strings.Cut(entry.Name(), "_")
+		if !found {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	entries, err := fs.ReadDir(FS, ".")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	// ReadDir sorts by name and the versions are zero-padded, so the newest migration comes last
+	newest := entries[len(entries)-1].Name()
+
+	version, err := LatestVersion()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(newest, fmt.Sprintf("%06d_", version)), newest)
+}
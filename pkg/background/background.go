@@ -0,0 +1,65 @@
+// Package background runs work which outlives the request that started it, so that the work can be waited for before
+// the process exits
+package background
+
+import (
+	"context"
+	"sync"
+)
+
+// Tasks runs functions in the background, at most a fixed number at a time. Tasks started while all workers are busy
+// wait for a free one
+type Tasks struct {
+	workers chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	closed  bool
+}
+
+// NewTasks creates a runner with the given number of workers, at least one
+func NewTasks(workers int) *Tasks {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Tasks{workers: make(chan struct{}, workers)}
+}
+
+// Go runs task in the background. After Wait has been called, task runs right away in the calling goroutine, so
+// that it is not lost
+func (t *Tasks) Go(task func()) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		task()
+		return
+	}
+	t.wg.Add(1)
+	t.mu.Unlock()
+
+	go func() {
+		defer t.wg.Done()
+		t.workers <- struct{}{}
+		defer func() { <-t.workers }()
+		task()
+	}()
+}
+
+// Wait waits for the running and waiting tasks to finish. Returns ctx.Err() if ctx is done first, in which case the
+// remaining tasks go on until the process exits
+func (t *Tasks) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
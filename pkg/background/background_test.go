@@ -0,0 +1,57 @@
+package background
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTasks_WaitDrainsTasks(t *testing.T) {
+	tasks := NewTasks(2)
+	var running, maxRunning, finished atomic.Int32
+	for i := 0; i < 6; i++ {
+		tasks.Go(func() {
+			n := running.Add(1)
+			for {
+				max := maxRunning.Load()
+				if n <= max || maxRunning.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			finished.Add(1)
+		})
+	}
+
+	assert.NoError(t, tasks.Wait(context.Background()))
+	assert.Equal(t, int32(6), finished.Load())
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestTasks_WaitTimesOut(t *testing.T) {
+	tasks := NewTasks(1)
+	release := make(chan struct{})
+	defer close(release)
+	tasks.Go(func() {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tasks.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestTasks_GoAfterWaitRunsRightAway(t *testing.T) {
+	tasks := NewTasks(1)
+	assert.NoError(t, tasks.Wait(context.Background()))
+
+	ran := false
+	tasks.Go(func() {
+		ran = true
+	})
+	assert.True(t, ran)
+}
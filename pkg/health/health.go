@@ -0,0 +1,120 @@
+// Package health runs the readiness checks of the application's dependencies
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusFailed      = "failed"
+)
+
+// Check returns an error when the dependency it checks is not usable. It must give up when ctx is done
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status string `json:"status"`
+	// Error tells why the check failed. It is meant for the logs and never served, as it may reveal internals such
+	// as database addresses
+	Error    string `json:"-"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Ready tells whether all checks passed and no shutdown is in progress
+func (r *Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the registered checks. Checks run concurrently, each limited by the checker's timeout
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// ShutDown marks the application as shutting down, after which it is never reported ready
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Run runs all checks. While shutting down, the checks are skipped and the report is unavailable
+func (c *Checker) Run(ctx context.Context) *Report {
+	if c.shuttingDown.Load() {
+		return &Report{Status: StatusUnavailable}
+	}
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, nc.check)
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, nc := range checks {
+		report.Checks[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// a check that ignores its context must not hold up the report
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	result := CheckResult{Status: StatusOK, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
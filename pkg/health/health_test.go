@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_Run(t *testing.T) {
+	passing := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("connection refused") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	ignoringContext := func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	cases := map[string]struct {
+		checks       map[string]Check
+		shuttingDown bool
+		status       string
+		results      map[string]string
+	}{
+		"all_passing": {
+			checks:  map[string]Check{"postgres": passing, "keys": passing},
+			status:  StatusOK,
+			results: map[string]string{"postgres": StatusOK, "keys": StatusOK},
+		},
+		"one_failing": {
+			checks:  map[string]Check{"postgres": failing, "keys": passing},
+			status:  StatusUnavailable,
+			results: map[string]string{"postgres": StatusFailed, "keys": StatusOK},
+		},
+		"timeout": {
+			checks:  map[string]Check{"postgres": hanging, "slow": ignoringContext},
+			status:  StatusUnavailable,
+			results: map[string]string{"postgres": StatusFailed, "slow": StatusFailed},
+		},
+		"no_checks": {
+			checks:  map[string]Check{},
+			status:  StatusOK,
+			results: map[string]string{},
+		},
+		"shutting_down": {
+			checks:       map[string]Check{"postgres": passing},
+			shuttingDown: true,
+			status:       StatusUnavailable,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			checker := NewChecker(50 * time.Millisecond)
+			for checkName, check := range tc.checks {
+				checker.Register(checkName, check)
+			}
+			if tc.shuttingDown {
+				checker.ShutDown()
+			}
+
+			start := time.Now()
+			report := checker.Run(context.Background())
+
+			assert.Less(t, time.Since(start), 500*time.Millisecond)
+			assert.Equal(t, tc.status, report.Status)
+			assert.Equal(t, tc.status == StatusOK, report.Ready())
+			assert.Len(t, report.Checks, len(tc.results))
+			for checkName, status := range tc.results {
+				assert.Equal(t, status, report.Checks[checkName].Status, checkName)
+				if status == StatusFailed {
+					assert.NotEmpty(t, report.Checks[checkName].Error)
+				}
+			}
+		})
+	}
+}
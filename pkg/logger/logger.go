@@ -0,0 +1,36 @@
+// Package logger creates the structured application logger. Request handlers get a logger enriched with request
+// fields from the request context, see FromContext
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// New creates a logger which writes JSON lines to w. level is one of trace, debug, info, warn, error, fatal, panic
+// or disabled, empty means info
+func New(w io.Writer, level string) (zerolog.Logger, error) {
+	lvl := zerolog.InfoLevel
+	if level != "" {
+		var err error
+		lvl, err = zerolog.ParseLevel(strings.ToLower(level))
+		if err != nil {
+			return zerolog.Nop(), fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+	}
+	return zerolog.New(w).Level(lvl).With().Timestamp().Logger(), nil
+}
+
+// WithContext returns a copy of ctx carrying the logger
+func WithContext(ctx context.Context, logger zerolog.Logger) context.Context {
+	return logger.WithContext(ctx)
+}
+
+// FromContext returns the logger stored in ctx, or a disabled logger if there is none
+func FromContext(ctx context.Context) *zerolog.Logger {
+	return zerolog.Ctx(ctx)
+}
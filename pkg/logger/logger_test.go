@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		level     string
+		wantDebug bool
+		wantInfo  bool
+		wantErr   bool
+	}{
+		"default": {level: "", wantInfo: true},
+		"debug":   {level: "debug", wantDebug: true, wantInfo: true},
+		"upper":   {level: "INFO", wantInfo: true},
+		"warn":    {level: "warn"},
+		"invalid": {level: "verbose", wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log, err := New(&buf, tc.level)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			log.Debug().Msg("debug")
+			assert.Equal(t, tc.wantDebug, bytes.Contains(buf.Bytes(), []byte(`"message":"debug"`)))
+			log.Info().Msg("info")
+			assert.Equal(t, tc.wantInfo, bytes.Contains(buf.Bytes(), []byte(`"message":"info"`)))
+		})
+	}
+}
+
+func TestNew_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(&buf, "info")
+	require.NoError(t, err)
+
+	log.Info().Str("user_id", "1111111").Msg("logged in")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "logged in", entry["message"])
+	assert.Equal(t, "1111111", entry["user_id"])
+	assert.NotEmpty(t, entry["time"])
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := New(&buf, "info")
+	require.NoError(t, err)
+
+	ctx := WithContext(context.Background(), log.With().Str("path", "/api/v1/user").Logger())
+	FromContext(ctx).Info().Msg("request")
+	assert.Contains(t, buf.String(), `"path":"/api/v1/user"`)
+
+	// without a logger in the context, nothing is written and nothing fails
+	FromContext(context.Background()).Error().Msg("dropped")
+	assert.NotContains(t, buf.String(), "dropped")
+}
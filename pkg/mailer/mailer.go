@@ -0,0 +1,72 @@
+// Package mailer provides pluggable outgoing email delivery
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Message is a plain text email message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email messages. Implementations must be safe for concurrent use
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPSender delivers messages via an SMTP relay using PLAIN authentication when credentials are set
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPSender(host, port, username, password, from string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPSender{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	if err := smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// LogSender writes messages to the log instead of sending them. Intended for local development only, as message
+// bodies may contain secrets such as login links
+type LogSender struct {
+	Logger zerolog.Logger
+}
+
+func (s LogSender) Send(_ context.Context, msg *Message) error {
+	s.Logger.Info().Str("to", msg.To).Str("subject", msg.Subject).Str("body", msg.Body).Msg("email")
+	return nil
+}
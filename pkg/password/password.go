@@ -0,0 +1,129 @@
+// Package password hashes and verifies user passwords. New hashes are argon2id, encoded in the PHC string format
+// (https://github.com/P-H-C/phc-string-format/blob/master/phc-sf-spec.md). bcrypt hashes are still verified, so that
+// they can be upgraded on login
+package password
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher hashes new passwords and verifies passwords against stored hashes
+type Hasher interface {
+	Hash(password string) ([]byte, error)
+	// Verify reports whether the password matches the hash, and if so, whether the hash uses an outdated algorithm
+	// or parameters and should be replaced with a new Hash of the password
+	Verify(password string, hash []byte) (ok bool, needsRehash bool, err error)
+}
+
+var ErrUnknownFormat = errors.New("unknown password hash format")
+
+// Argon2idParams are the cost parameters of argon2id, see RFC 9106 section 4 for recommended values
+type Argon2idParams struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2idHasher creates argon2id hashes with the configured parameters and verifies argon2id and bcrypt hashes
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// PHC uses standard base64 without padding
+var phcEncoding = base64.RawStdEncoding
+
+func (h *Argon2idHasher) Hash(password string) ([]byte, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism,
+		h.params.KeyLength)
+	encoded := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key))
+	return []byte(encoded), nil
+}
+
+func (h *Argon2idHasher) Verify(password string, hash []byte) (bool, bool, error) {
+	switch {
+	case bytes.HasPrefix(hash, []byte(argon2idPrefix)):
+		return h.verifyArgon2id(password, string(hash))
+	case bytes.HasPrefix(hash, []byte("$2")):
+		err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	case len(hash) == 0:
+		// users without a password (e.g. provisioned, or signed up via an external provider)
+		return false, false, nil
+	default:
+		return false, false, ErrUnknownFormat
+	}
+}
+
+func (h *Argon2idHasher) verifyArgon2id(password string, hash string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnknownFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, false, ErrUnknownFormat
+	}
+	var params Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return false, false, ErrUnknownFormat
+	}
+	salt, err := phcEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnknownFormat
+	}
+	key, err := phcEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, ErrUnknownFormat
+	}
+	if version != argon2.Version {
+		return false, false, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+		params.KeyLength)
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return false, false, nil
+	}
+	return true, params != h.params, nil
+}
@@ -0,0 +1,76 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cheap parameters to keep the tests fast
+var testParams = Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHasher_Hash(t *testing.T) {
+	h := NewArgon2idHasher(testParams)
+
+	first, err := h.Hash("secret")
+	require.NoError(t, err)
+	second, err := h.Hash("secret")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(string(first), "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.NotEqual(t, first, second, "salt must be random")
+	assert.Len(t, strings.Split(string(first), "$"), 6)
+}
+
+func TestArgon2idHasher_Verify(t *testing.T) {
+	h := NewArgon2idHasher(testParams)
+	current, err := h.Hash("secret")
+	require.NoError(t, err)
+	weaker, err := NewArgon2idHasher(Argon2idParams{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 8, KeyLength: 16}).Hash("secret")
+	require.NoError(t, err)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		password        string
+		hash            []byte
+		wantOk          bool
+		wantNeedsRehash bool
+		wantErr         bool
+	}{
+		"current":               {password: "secret", hash: current, wantOk: true},
+		"current wrong":         {password: "wrong", hash: current},
+		"outdated params":       {password: "secret", hash: weaker, wantOk: true, wantNeedsRehash: true},
+		"outdated params wrong": {password: "wrong", hash: weaker},
+		"bcrypt":                {password: "secret", hash: legacy, wantOk: true, wantNeedsRehash: true},
+		"bcrypt wrong":          {password: "wrong", hash: legacy},
+		"no password":           {password: "", hash: []byte{}},
+		"unknown format":        {password: "secret", hash: []byte("$md5$abc"), wantErr: true},
+		"malformed argon2id":    {password: "secret", hash: []byte("$argon2id$v=19$m=1024"), wantErr: true},
+		"bad base64":            {password: "secret", hash: []byte("$argon2id$v=19$m=1024,t=1,p=1$!!!$!!!"), wantErr: true},
+		"unsupported version":   {password: "secret", hash: []byte(strings.Replace(string(current), "v=19", "v=16", 1)), wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ok, needsRehash, err := h.Verify(tc.password, tc.hash)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantOk, ok)
+			assert.Equal(t, tc.wantNeedsRehash, needsRehash)
+		})
+	}
+}
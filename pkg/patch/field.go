@@ -0,0 +1,61 @@
+// Package patch supports JSON Merge Patch (RFC 7386) documents, in which a missing member leaves a field unchanged
+// and a null member removes its value
+package patch
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Field is a member of a merge patch. The zero value is a missing member
+type Field[T any] struct {
+	Val T
+	// Set tells whether the member is present, Null whether it is null
+	Set  bool
+	Null bool
+}
+
+// Value returns a field set to v
+func Value[T any](v T) Field[T] {
+	return Field[T]{Val: v, Set: true}
+}
+
+// Null returns a field set to null
+func Null[T any]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+// UnmarshalJSON is only called for present members, null included
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	*f = Field[T]{Set: true}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Val)
+}
+
+// Ptr returns nil for missing and null members, and a pointer to the value otherwise
+func (f Field[T]) Ptr() *T {
+	if !f.Set || f.Null {
+		return nil
+	}
+	return &f.Val
+}
+
+// Apply changes *target to the value of a present member. Null members set it to the zero value
+func (f Field[T]) Apply(target *T) {
+	if f.Set {
+		*target = f.Val
+	}
+}
+
+// Value implements driver.Valuer, which makes validation rules and database drivers see missing and null members as
+// nil, and set members as their value
+func (f Field[T]) Value() (driver.Value, error) {
+	if p := f.Ptr(); p != nil {
+		return *p, nil
+	}
+	return nil, nil
+}
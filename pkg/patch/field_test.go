@@ -0,0 +1,96 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type document struct {
+	Name  Field[string] `json:"name"`
+	Count Field[int]    `json:"count"`
+}
+
+func TestField_UnmarshalJSON(t *testing.T) {
+	cases := map[string]struct {
+		body          string
+		expectedName  Field[string]
+		expectedCount Field[int]
+	}{
+		"missing": {
+			body: `{}`,
+		},
+		"null": {
+			body:         `{"name":null}`,
+			expectedName: Null[string](),
+		},
+		"value": {
+			body:          `{"name":"John","count":2}`,
+			expectedName:  Value("John"),
+			expectedCount: Value(2),
+		},
+		"zero_value": {
+			body:          `{"name":"","count":0}`,
+			expectedName:  Value(""),
+			expectedCount: Value(0),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var doc document
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &doc))
+
+			assert.Equal(t, tc.expectedName, doc.Name)
+			assert.Equal(t, tc.expectedCount, doc.Count)
+		})
+	}
+}
+
+func TestField_UnmarshalJSON_TypeMismatch(t *testing.T) {
+	var doc document
+	assert.Error(t, json.Unmarshal([]byte(`{"count":"two"}`), &doc))
+}
+
+func TestField_Apply(t *testing.T) {
+	target := "unchanged"
+	Field[string]{}.Apply(&target)
+	assert.Equal(t, "unchanged", target)
+
+	Value("changed").Apply(&target)
+	assert.Equal(t, "changed", target)
+
+	Null[string]().Apply(&target)
+	assert.Equal(t, "", target)
+}
+
+func TestField_Ptr(t *testing.T) {
+	assert.Nil(t, Field[string]{}.Ptr())
+	assert.Nil(t, Null[string]().Ptr())
+	if p := Value("John").Ptr(); assert.NotNil(t, p) {
+		assert.Equal(t, "John", *p)
+	}
+}
+
+func TestField_Validation(t *testing.T) {
+	cases := map[string]struct {
+		field    Field[string]
+		expected error
+	}{
+		"missing": {field: Field[string]{}, expected: nil},
+		"value":   {field: Value("John"), expected: nil},
+		"null":    {field: Null[string](), expected: validation.ErrRequired},
+		"blank":   {field: Value(""), expected: validation.ErrRequired},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validation.Validate(tc.field, validation.When(tc.field.Set, validation.Required))
+
+			assert.Equal(t, tc.expected, err)
+		})
+	}
+}
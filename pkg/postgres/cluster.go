@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Querier runs statements. It is implemented by pgxpool.Pool, pgx.Conn and pgx.Tx
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// Router picks the database a repository method runs its statements on
+type Router interface {
+	// Reader returns where read-only statements run. The data may lag behind the primary
+	Reader(ctx context.Context) Querier
+	// Writer returns the primary
+	Writer(ctx context.Context) Querier
+}
+
+type ClusterOptions struct {
+	// StickyKey returns whose statements ctx belongs to, e.g. the id of the authenticated user. After a write, the
+	// reads with the same key go to the primary for Stickiness, so that users always see their own changes. Contexts
+	// with an empty key are never sticky. The writes are only known to this process, see WithClientWrites for
+	// stickiness across processes
+	StickyKey  func(ctx context.Context) string
+	Stickiness time.Duration
+	// CheckInterval is how often the replicas are pinged. Unhealthy replicas get no reads until they recover
+	CheckInterval time.Duration
+}
+
+// Cluster routes reads to healthy replicas in turn, and writes and sticky reads to the primary. Without replicas,
+// everything goes to the primary
+type Cluster struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	opts     ClusterOptions
+	next     atomic.Uint64
+	now      func() time.Time
+
+	mu sync.Mutex
+	// writes holds the time of the last write of each sticky key, for the keys written within the stickiness window
+	writes    map[string]time.Time
+	forgotten time.Time
+}
+
+type replica struct {
+	db      Querier
+	ping    func(ctx context.Context) error
+	close   func()
+	healthy atomic.Bool
+}
+
+// NewCluster creates the router over the primary and replica pools. The replicas are only used once Run has
+// found them healthy. Close closes all pools
+func NewCluster(primary *pgxpool.Pool, replicas []*pgxpool.Pool, opts ClusterOptions) *Cluster {
+	rs := make([]*replica, 0, len(replicas))
+	for _, pool := range replicas {
+		rs = append(rs, &replica{db: pool, ping: pool.Ping, close: pool.Close})
+	}
+	return newCluster(primary, rs, opts)
+}
+
+func newCluster(primary *pgxpool.Pool, replicas []*replica, opts ClusterOptions) *Cluster {
+	return &Cluster{
+		primary:  primary,
+		replicas: replicas,
+		opts:     opts,
+		now:      time.Now,
+		writes:   map[string]time.Time{},
+	}
+}
+
+// Primary returns the primary pool, e.g. for migrations and transactions
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+func (c *Cluster) Reader(ctx context.Context) Querier {
+	if len(c.replicas) == 0 || c.sticky(ctx) {
+		return c.primary
+	}
+	n := uint64(len(c.replicas))
+	start := c.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := c.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.db
+		}
+	}
+	return c.primary
+}
+
+func (c *Cluster) Writer(ctx context.Context) Querier {
+	c.NoteWrite(ctx)
+	return c.primary
+}
+
+// NoteWrite makes the reads with the sticky key of ctx go to the primary for the stickiness window. Writer calls it,
+// writes which bypass Writer, e.g. in transactions, have to call it themselves
+func (c *Cluster) NoteWrite(ctx context.Context) {
+	if len(c.replicas) == 0 || c.opts.Stickiness <= 0 {
+		return
+	}
+	now := c.now()
+	if cw, ok := ctx.Value(clientWritesKey{}).(*clientWrites); ok {
+		cw.mu.Lock()
+		cw.last = now
+		cw.mu.Unlock()
+	}
+	if key := c.stickyKey(ctx); key != "" {
+		c.mu.Lock()
+		c.writes[key] = now
+		// Run forgets the expired writes as well, this keeps the map small without it
+		if now.Sub(c.forgotten) >= c.opts.Stickiness {
+			c.forgetWritesLocked(now)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// clientWrites is the last write of a client, which it passes along with its requests, e.g. in a cookie
+type clientWrites struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+type clientWritesKey struct{}
+
+// WithClientWrites returns a context which carries the time of the last write the client made, possibly through
+// another instance of the application, or the zero time if it reported none. Reads through the context go to the
+// primary within the stickiness window after that write. Writes through the context update it, see LastClientWrite
+func WithClientWrites(ctx context.Context, last time.Time) context.Context {
+	return context.WithValue(ctx, clientWritesKey{}, &clientWrites{last: last})
+}
+
+// LastClientWrite returns the time of the last write carried by a context from WithClientWrites. Returns false if
+// there is none
+func LastClientWrite(ctx context.Context) (time.Time, bool) {
+	cw, ok := ctx.Value(clientWritesKey{}).(*clientWrites)
+	if !ok {
+		return time.Time{}, false
+	}
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.last, !cw.last.IsZero()
+}
+
+func (c *Cluster) stickyKey(ctx context.Context) string {
+	if c.opts.StickyKey == nil {
+		return ""
+	}
+	return c.opts.StickyKey(ctx)
+}
+
+func (c *Cluster) sticky(ctx context.Context) bool {
+	if c.opts.Stickiness <= 0 {
+		return false
+	}
+	if last, ok := LastClientWrite(ctx); ok {
+		// the time comes from the client, a write far in the future must not keep it on the primary for good
+		age := c.now().Sub(last)
+		if age < c.opts.Stickiness && age > -c.opts.Stickiness {
+			return true
+		}
+	}
+	key := c.stickyKey(ctx)
+	if key == "" {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	written, ok := c.writes[key]
+	return ok && c.now().Sub(written) < c.opts.Stickiness
+}
+
+// Run checks the replicas right away and then every CheckInterval, until ctx is done
+func (c *Cluster) Run(ctx context.Context) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	interval := c.opts.CheckInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.CheckReplicas(ctx, interval)
+		c.forgetWrites()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckReplicas pings all replicas concurrently and updates their health
+func (c *Cluster) CheckReplicas(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+			r.healthy.Store(r.ping(ctx) == nil)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// HealthyReplicas returns the number of replicas currently receiving reads
+func (c *Cluster) HealthyReplicas() int {
+	n := 0
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// forgetWrites drops the keys whose stickiness has expired, so that the map does not grow with every user
+func (c *Cluster) forgetWrites() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forgetWritesLocked(c.now())
+}
+
+func (c *Cluster) forgetWritesLocked(now time.Time) {
+	c.forgotten = now
+	for key, written := range c.writes {
+		if now.Sub(written) >= c.opts.Stickiness {
+			delete(c.writes, key)
+		}
+	}
+}
+
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		r.close()
+	}
+	c.primary.Close()
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stickyKeyCtx struct{}
+
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	// the pool connects lazily, so no database is needed as long as no statement runs
+	pool, err := pgxpool.New(context.Background(), "postgres://app:pw@127.0.0.1:1/cw")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func newTestReplica(t *testing.T, healthy bool) *replica {
+	pool := newTestPool(t)
+	return &replica{
+		db: pool,
+		ping: func(context.Context) error {
+			if !healthy {
+				return errors.New("down")
+			}
+			return nil
+		},
+		close: func() {},
+	}
+}
+
+func TestCluster_Routing(t *testing.T) {
+	primary := newTestPool(t)
+	up1, up2, down := newTestReplica(t, true), newTestReplica(t, true), newTestReplica(t, false)
+	cluster := newCluster(primary, []*replica{up1, down, up2}, ClusterOptions{
+		StickyKey: func(ctx context.Context) string {
+			key, _ := ctx.Value(stickyKeyCtx{}).(string)
+			return key
+		},
+		Stickiness: time.Minute,
+	})
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cluster.now = func() time.Time { return now }
+	alice := context.WithValue(context.Background(), stickyKeyCtx{}, "alice")
+	bob := context.WithValue(context.Background(), stickyKeyCtx{}, "bob")
+
+	// unchecked replicas get no reads
+	assert.Same(t, primary, cluster.Reader(alice))
+
+	cluster.CheckReplicas(context.Background(), time.Second)
+	assert.Equal(t, 2, cluster.HealthyReplicas())
+	seen := map[Querier]bool{}
+	for i := 0; i < 4; i++ {
+		seen[cluster.Reader(alice)] = true
+	}
+	assert.Equal(t, map[Querier]bool{up1.db: true, up2.db: true}, seen)
+
+	assert.Same(t, primary, cluster.Writer(alice))
+	assert.Same(t, primary, cluster.Reader(alice))
+	assert.NotSame(t, primary, cluster.Reader(bob))
+	assert.NotSame(t, primary, cluster.Reader(context.Background()))
+
+	now = now.Add(time.Minute)
+	assert.NotSame(t, primary, cluster.Reader(alice))
+	cluster.forgetWrites()
+	assert.Empty(t, cluster.writes)
+
+	// all replicas down
+	up1.ping, up2.ping = down.ping, down.ping
+	cluster.CheckReplicas(context.Background(), time.Second)
+	assert.Zero(t, cluster.HealthyReplicas())
+	assert.Same(t, primary, cluster.Reader(bob))
+}
+
+func TestCluster_WithoutReplicas(t *testing.T) {
+	primary := newTestPool(t)
+	cluster := NewCluster(primary, nil, ClusterOptions{
+		StickyKey:  func(context.Context) string { return "alice" },
+		Stickiness: time.Minute,
+	})
+
+	assert.Same(t, primary, cluster.Reader(context.Background()))
+	assert.Same(t, primary, cluster.Writer(context.Background()))
+	// nothing to be sticky about
+	assert.Empty(t, cluster.writes)
+}
+
+func TestCluster_ClientWrites(t *testing.T) {
+	primary := newTestPool(t)
+	up := newTestReplica(t, true)
+	cluster := newCluster(primary, []*replica{up}, ClusterOptions{Stickiness: time.Minute})
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cluster.now = func() time.Time { return now }
+	cluster.CheckReplicas(context.Background(), time.Second)
+
+	// a write through this instance
+	ctx := WithClientWrites(context.Background(), time.Time{})
+	assert.NotSame(t, primary, cluster.Reader(ctx))
+	_, ok := LastClientWrite(ctx)
+	assert.False(t, ok)
+	cluster.Writer(ctx)
+	last, ok := LastClientWrite(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, now, last)
+
+	// the client passes the write on to the next request, possibly served by another instance
+	next := WithClientWrites(context.Background(), last)
+	assert.Same(t, primary, cluster.Reader(next))
+	now = now.Add(time.Minute)
+	assert.NotSame(t, primary, cluster.Reader(next))
+
+	// a time far in the future does not keep the client on the primary
+	forged := WithClientWrites(context.Background(), now.Add(time.Hour))
+	assert.NotSame(t, primary, cluster.Reader(forged))
+}
+
+func TestCluster_NoteWriteForgetsExpiredWrites(t *testing.T) {
+	cluster := newCluster(newTestPool(t), []*replica{newTestReplica(t, true)}, ClusterOptions{
+		StickyKey: func(ctx context.Context) string {
+			key, _ := ctx.Value(stickyKeyCtx{}).(string)
+			return key
+		},
+		Stickiness: time.Minute,
+	})
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cluster.now = func() time.Time { return now }
+
+	cluster.NoteWrite(context.WithValue(context.Background(), stickyKeyCtx{}, "alice"))
+	now = now.Add(time.Minute)
+	cluster.NoteWrite(context.WithValue(context.Background(), stickyKeyCtx{}, "bob"))
+
+	assert.Equal(t, map[string]time.Time{"bob": now}, cluster.writes)
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PingCheck returns a health check which pings the database through the pool
+func PingCheck(pool *pgxpool.Pool) func(ctx context.Context) error {
+	return pool.Ping
+}
+
+// MigrationCheck returns a health check which fails unless the schema is at least at the expected golang-migrate
+// version and the last migration completed. Newer versions pass, so that during a rolling deploy the instances still
+// running the previous release stay ready once the new release has migrated the schema
+func MigrationCheck(pool *pgxpool.Pool, expected uint) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var version int64
+		var dirty bool
+		err := pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("no migrations applied, expected version %d", expected)
+		}
+		if err != nil {
+			return err
+		}
+		return checkSchemaVersion(uint(version), dirty, expected)
+	}
+}
+
+func checkSchemaVersion(version uint, dirty bool, expected uint) error {
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if version < expected {
+		return fmt.Errorf("schema version is %d, expected at least %d", version, expected)
+	}
+	return nil
+}
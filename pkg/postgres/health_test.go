@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSchemaVersion(t *testing.T) {
+	cases := map[string]struct {
+		version uint
+		dirty   bool
+		wantErr bool
+	}{
+		"expected":    {version: 9},
+		"newer":       {version: 10},
+		"older":       {version: 8, wantErr: true},
+		"dirty":       {version: 9, dirty: true, wantErr: true},
+		"newer_dirty": {version: 10, dirty: true, wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := checkSchemaVersion(tc.version, tc.dirty, 9)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
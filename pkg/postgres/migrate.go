@@ -0,0 +1,274 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationsLockKey is the advisory lock held while migrating, so that concurrently starting replicas apply every
+// migration once
+const migrationsLockKey int64 = 0x636f757273657761 // "coursewa"
+
+// NilVersion is the schema version before the first migration
+const NilVersion = -1
+
+var (
+	ErrDirtySchema      = errors.New("the last migration failed, fix the schema and force its version")
+	ErrUnknownMigration = errors.New("no migration with this version")
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	upFile   string
+	downFile string
+}
+
+type MigrationStatus struct {
+	// Version of the schema, NilVersion if no migration has been applied
+	Version int
+	// Dirty is set when the migration to Version failed
+	Dirty bool
+	// Latest is the version of the newest known migration
+	Latest  int
+	Pending []Migration
+}
+
+// Migrator applies SQL migrations in the golang-migrate layout (<version>_<name>.up.sql and .down.sql) and keeps the
+// schema version in the same schema_migrations table, so that it can take over databases migrated with that tool.
+// Like golang-migrate, it marks the version dirty while a migration runs, rather than running migrations in
+// transactions, so that statements such as CREATE INDEX CONCURRENTLY can be used
+type Migrator struct {
+	pool       *pgxpool.Pool
+	source     fs.FS
+	migrations []Migration
+}
+
+func NewMigrator(pool *pgxpool.Pool, source fs.FS) (*Migrator, error) {
+	migrations, err := readMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, source: source, migrations: migrations}, nil
+}
+
+func readMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", m.Name, match[2], version)
+		}
+		if match[3] == "up" {
+			m.upFile = entry.Name()
+		} else {
+			m.downFile = entry.Name()
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.upFile == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("version %d: %w", version, ErrDirtySchema)
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err = m.apply(ctx, conn, migration.Version, migration.upFile); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last n applied migrations and returns how many were reverted
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	reverted := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("version %d: %w", version, ErrDirtySchema)
+		}
+		for ; reverted < n && version != NilVersion; reverted++ {
+			i := m.index(version)
+			if i < 0 {
+				return fmt.Errorf("version %d: %w", version, ErrUnknownMigration)
+			}
+			if m.migrations[i].downFile == "" {
+				return fmt.Errorf("migration %d_%s has no down file", version, m.migrations[i].Name)
+			}
+			previous := NilVersion
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err = m.apply(ctx, conn, previous, m.migrations[i].downFile); err != nil {
+				return err
+			}
+			version = previous
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status reports the schema version and the migrations not applied yet
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	var status MigrationStatus
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+		status.Version, status.Dirty, err = readVersion(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	status.Latest = NilVersion
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if migration.Version > status.Version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return &status, nil
+}
+
+// Force sets the schema version and clears the dirty flag without running any migration. Used to recover after a
+// failed migration has been fixed by hand
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version != NilVersion && m.index(version) < 0 {
+		return fmt.Errorf("version %d: %w", version, ErrUnknownMigration)
+	}
+	return m.locked(ctx, func(conn *pgxpool.Conn) error {
+		return writeVersion(ctx, conn, version, false)
+	})
+}
+
+func (m *Migrator) index(version int) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// apply runs the migration file, recording the version it leads to as dirty until it succeeds
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, version int, file string) error {
+	sql, err := fs.ReadFile(m.source, file)
+	if err != nil {
+		return err
+	}
+	if err = writeVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+	if _, err = conn.Exec(ctx, string(sql)); err != nil {
+		return fmt.Errorf("migration %s failed: %w", file, err)
+	}
+	return writeVersion(ctx, conn, version, false)
+}
+
+// locked runs f on a single connection holding the migrations lock
+func (m *Migrator) locked(ctx context.Context, f func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// waiting for the lock and the DDL may take longer than the statement timeout of the pool's connections. The
+	// setting is reset before the connection goes back to the pool
+	if _, err = conn.Exec(ctx, "SET statement_timeout = 0"); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), "RESET statement_timeout"); err != nil {
+			// the pool discards closed connections
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey); err != nil {
+		return fmt.Errorf("failed to acquire the migrations lock: %w", err)
+	}
+	defer func() {
+		// the lock belongs to the session, so it must be released even if ctx is done
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockKey)
+	}()
+
+	const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+	if _, err = conn.Exec(ctx, createTable); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+func readVersion(ctx context.Context, conn *pgxpool.Conn) (version int, dirty bool, err error) {
+	err = conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NilVersion, false, nil
+	}
+	return version, dirty, err
+}
+
+func writeVersion(ctx context.Context, conn *pgxpool.Conn, version int, dirty bool) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+			return err
+		}
+		// a failed revert of the first migration still has to leave the dirty flag behind
+		if version == NilVersion && !dirty {
+			return nil
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", version, dirty)
+		return err
+	})
+}
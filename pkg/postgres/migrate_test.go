@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMigrations(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+
+	cases := map[string]struct {
+		files    fstest.MapFS
+		expected []Migration
+		wantErr  bool
+	}{
+		"sorted_by_version": {
+			files: fstest.MapFS{
+				"000010_add_index.up.sql":      file,
+				"000002_create_users.up.sql":   file,
+				"000002_create_users.down.sql": file,
+				"000010_add_index.down.sql":    file,
+				"README.md":                    file,
+			},
+			expected: []Migration{
+				{Version: 2, Name: "create_users", upFile: "000002_create_users.up.sql", downFile: "000002_create_users.down.sql"},
+				{Version: 10, Name: "add_index", upFile: "000010_add_index.up.sql", downFile: "000010_add_index.down.sql"},
+			},
+		},
+		"irreversible": {
+			files: fstest.MapFS{"000001_seed.up.sql": file},
+			expected: []Migration{
+				{Version: 1, Name: "seed", upFile: "000001_seed.up.sql"},
+			},
+		},
+		"empty": {
+			files:    fstest.MapFS{},
+			expected: []Migration{},
+		},
+		"missing_up": {
+			files:   fstest.MapFS{"000001_seed.down.sql": file},
+			wantErr: true,
+		},
+		"duplicate_version": {
+			files: fstest.MapFS{
+				"000001_create_users.up.sql":   file,
+				"000001_create_courses.up.sql": file,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			migrations, err := readMigrations(tc.files)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, migrations)
+		})
+	}
+}
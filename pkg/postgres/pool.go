@@ -3,33 +3,193 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	initialRetryDelay = 250 * time.Millisecond
+	maxRetryDelay     = 5 * time.Second
+)
+
 type PgConfig struct {
+	// DSN is a complete connection string, either a postgres:// URL or key=value pairs. When set, it replaces
+	// Username, Password, Host, Port and Database, while the options below still apply
+	DSN      string
 	Username string
 	Password string
 	Host     string
 	Port     string
 	Database string
+
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or verify-full. Empty leaves the DSN's or
+	// libpq's default
+	SSLMode string
+	// SSLRootCert is the CA certificate file the server certificate is verified with
+	SSLRootCert string
+	// SSLCert and SSLKey are the client certificate and key files for certificate authentication
+	SSLCert string
+	SSLKey  string
+
+	ApplicationName string
+	// StatementTimeout aborts statements which take longer. Zero leaves the server default
+	StatementTimeout time.Duration
+
+	// Pool settings, zero leaves the pgxpool defaults
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+
+	// ConnectTimeout bounds the retries of the first connection, e.g. while the database is still starting.
+	// Zero means a single attempt
+	ConnectTimeout time.Duration
 }
 
 func NewPgConfig(username, password, host, port, database string) *PgConfig {
 	return &PgConfig{Username: username, Password: password, Host: host, Port: port, Database: database}
 }
 
+// ConnString returns the connection string with the TLS options applied. The credentials are escaped, so that
+// passwords may contain any character
+func (cfg *PgConfig) ConnString() string {
+	params := make([][2]string, 0, 4)
+	for _, p := range [][2]string{
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+	} {
+		if p[1] != "" {
+			params = append(params, p)
+		}
+	}
+
+	dsn := cfg.DSN
+	if dsn == "" {
+		dsn = (&url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.Username, cfg.Password),
+			Host:   net.JoinHostPort(cfg.Host, cfg.Port),
+			Path:   "/" + cfg.Database,
+		}).String()
+	}
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			// left to pgxpool.ParseConfig to report
+			return dsn
+		}
+		query := u.Query()
+		for _, p := range params {
+			query.Set(p[0], p[1])
+		}
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	// key=value format, later keys override earlier ones
+	var sb strings.Builder
+	sb.WriteString(dsn)
+	for _, p := range params {
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(p[1])
+		fmt.Fprintf(&sb, " %s='%s'", p[0], value)
+	}
+	return sb.String()
+}
+
+func (cfg *PgConfig) poolConfig() (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.ConnString())
+	if err != nil {
+		// the error of ParseConfig may quote the connection string, password included
+		return nil, fmt.Errorf("invalid postgres connection settings: %s", redactConnError(err, cfg))
+	}
+	poolConfig.ConnConfig.Tracer = NewQueryTracer()
+
+	if cfg.ApplicationName != "" {
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = cfg.ApplicationName
+	}
+	if cfg.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	return poolConfig, nil
+}
+
+func redactConnError(err error, cfg *PgConfig) string {
+	msg := err.Error()
+	for _, secret := range []string{cfg.DSN, cfg.Password, url.QueryEscape(cfg.Password)} {
+		if secret != "" {
+			msg = strings.ReplaceAll(msg, secret, "REDACTED")
+		}
+	}
+	return msg
+}
+
+// NewClient creates the connection pool and waits until the database accepts connections. Failed attempts are
+// retried with exponential backoff for up to cfg.ConnectTimeout
 func NewClient(ctx context.Context, cfg *PgConfig) (*pgxpool.Pool, error) {
-	
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
-	
-	pool, err := pgxpool.New(ctx, connStr)
+	pool, err := NewLazyClient(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
-	
-	err = pool.Ping(ctx)
-	if err != nil {
+
+	if err = ping(ctx, pool, cfg.ConnectTimeout); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 	return pool, nil
 }
+
+// NewLazyClient creates the connection pool without waiting for the database, for databases which may be down,
+// e.g. replicas
+func NewLazyClient(ctx context.Context, cfg *PgConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := cfg.poolConfig()
+	if err != nil {
+		return nil, err
+	}
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+func ping(ctx context.Context, pool *pgxpool.Pool, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := pool.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("gave up after %d attempt(s): %w", attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
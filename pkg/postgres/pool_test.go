@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPgConfig_ConnString(t *testing.T) {
+	cases := map[string]struct {
+		cfg      PgConfig
+		expected string
+	}{
+		"escaped_credentials": {
+			cfg:      PgConfig{Username: "app", Password: "p@ss/w:rd", Host: "db", Port: "5432", Database: "cw"},
+			expected: "postgres://app:p%40ss%2Fw%3Ard@db:5432/cw",
+		},
+		"tls_options": {
+			cfg: PgConfig{Username: "app", Password: "pw", Host: "db", Port: "5432", Database: "cw",
+				SSLMode: "verify-full", SSLRootCert: "/certs/ca.pem"},
+			expected: "postgres://app:pw@db:5432/cw?sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.pem",
+		},
+		"url_dsn_overridden": {
+			cfg:      PgConfig{DSN: "postgres://app:pw@db/cw?sslmode=disable", Username: "ignored", SSLMode: "require"},
+			expected: "postgres://app:pw@db/cw?sslmode=require",
+		},
+		"keyword_dsn": {
+			cfg:      PgConfig{DSN: "host=db user=app dbname=cw", SSLCert: "/certs/it's.pem"},
+			expected: `host=db user=app dbname=cw sslcert='/certs/it\'s.pem'`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.cfg.ConnString())
+		})
+	}
+}
+
+func TestPgConfig_PoolConfig(t *testing.T) {
+	cfg := &PgConfig{
+		DSN:               "postgres://app:p%40ss@db:5432/cw",
+		ApplicationName:   "course-watch",
+		StatementTimeout:  15 * time.Second,
+		MaxConns:          20,
+		MinConns:          2,
+		MaxConnLifetime:   time.Hour,
+		MaxConnIdleTime:   10 * time.Minute,
+		HealthCheckPeriod: 30 * time.Second,
+	}
+
+	poolConfig, err := cfg.poolConfig()
+
+	require.NoError(t, err)
+	assert.Equal(t, "p@ss", poolConfig.ConnConfig.Password)
+	assert.Equal(t, "course-watch", poolConfig.ConnConfig.RuntimeParams["application_name"])
+	assert.Equal(t, "15000", poolConfig.ConnConfig.RuntimeParams["statement_timeout"])
+	assert.Equal(t, int32(20), poolConfig.MaxConns)
+	assert.Equal(t, int32(2), poolConfig.MinConns)
+	assert.Equal(t, time.Hour, poolConfig.MaxConnLifetime)
+	assert.Equal(t, 10*time.Minute, poolConfig.MaxConnIdleTime)
+	assert.Equal(t, 30*time.Second, poolConfig.HealthCheckPeriod)
+	assert.NotNil(t, poolConfig.ConnConfig.Tracer)
+
+	_, err = (&PgConfig{DSN: "postgres://app:secret@db:5432/cw?sslmode=bogus", Password: "secret"}).poolConfig()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestNewClient_Retries(t *testing.T) {
+	// a port nothing listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, ln.Close())
+
+	cfg := NewPgConfig("app", "pw", host, port, "cw")
+	cfg.SSLMode = "disable"
+	cfg.ConnectTimeout = time.Second
+	start := time.Now()
+
+	_, err = NewClient(context.Background(), cfg)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gave up after 3 attempt(s)")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
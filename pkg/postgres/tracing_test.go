@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryTracer(t *testing.T) {
+	cases := map[string]struct {
+		sql        string
+		err        error
+		name       string
+		statusCode codes.Code
+	}{
+		"select": {
+			sql:        "\n\tselect id, email from users where id = $1",
+			name:       "postgres SELECT",
+			statusCode: codes.Unset,
+		},
+		"failed_update": {
+			sql:        "UPDATE users SET hashed_password = $2 WHERE id = $1",
+			err:        errors.New("connection reset"),
+			name:       "postgres UPDATE",
+			statusCode: codes.Error,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			tracer := &QueryTracer{tracer: provider.Tracer(tracerName)}
+
+			ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: tc.sql, Args: []any{"secret"}})
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 1"), Err: tc.err})
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tc.name, span.Name())
+			assert.Equal(t, tc.statusCode, span.Status().Code)
+			assert.Contains(t, span.Attributes(), attribute.String("db.statement", tc.sql))
+			for _, attr := range span.Attributes() {
+				assert.NotEqual(t, "secret", attr.Value.Emit())
+			}
+		})
+	}
+}
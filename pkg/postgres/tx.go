@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+
+	retryDelay = 10 * time.Millisecond
+)
+
+type TxOptions struct {
+	IsoLevel pgx.TxIsoLevel
+	// MaxRetries is how often a transaction aborted by a serialization failure or a deadlock is started over
+	MaxRetries int
+}
+
+// IsRetryable reports whether err aborted a transaction only because of concurrent transactions, so that running
+// it again may succeed
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected
+}
+
+// RunInTx runs fn in a transaction and commits it if fn succeeds, or rolls it back otherwise. A transaction aborted
+// by a serialization failure or a deadlock is started over, fn included, so fn must not have effects outside the
+// transaction
+func RunInTx(ctx context.Context, pool *pgxpool.Pool, opts TxOptions, fn func(tx pgx.Tx) error) error {
+	return retry(ctx, opts.MaxRetries, func() error {
+		tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: opts.IsoLevel})
+		if err != nil {
+			return err
+		}
+		return finish(ctx, tx, fn)
+	})
+}
+
+// RunInSavepoint runs fn in a savepoint of tx. If fn fails, only its changes are rolled back and tx can go on
+func RunInSavepoint(ctx context.Context, tx pgx.Tx, fn func(tx pgx.Tx) error) error {
+	// a transaction started within a transaction is a savepoint
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	return finish(ctx, savepoint, fn)
+}
+
+func finish(ctx context.Context, tx pgx.Tx, fn func(tx pgx.Tx) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		// the error of fn matters more than the one of the rollback
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func retry(ctx context.Context, maxRetries int, run func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= maxRetries || !IsRetryable(err) {
+			return err
+		}
+		// a short pause, growing with the attempts, lets the conflicting transaction finish
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt+1) * retryDelay):
+		}
+	}
+}
+
+// TxRouter runs all statements in tx, e.g. for repositories taking part in a transaction
+func TxRouter(tx pgx.Tx) Router {
+	return txRouter{tx: tx}
+}
+
+type txRouter struct {
+	tx pgx.Tx
+}
+
+func (r txRouter) Reader(context.Context) Querier {
+	return r.tx
+}
+
+func (r txRouter) Writer(context.Context) Querier {
+	return r.tx
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"serialization_failure": {err: &pgconn.PgError{Code: "40001"}, expected: true},
+		"deadlock":              {err: &pgconn.PgError{Code: "40P01"}, expected: true},
+		"wrapped":               {err: fmt.Errorf("insert user: %w", &pgconn.PgError{Code: "40001"}), expected: true},
+		"unique_violation":      {err: &pgconn.PgError{Code: "23505"}, expected: false},
+		"other":                 {err: errors.New("boom"), expected: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsRetryable(tc.err))
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	conflict := &pgconn.PgError{Code: "40001"}
+
+	cases := map[string]struct {
+		errs             []error
+		maxRetries       int
+		expectedErr      error
+		expectedAttempts int
+	}{
+		"success": {
+			errs:             []error{nil},
+			maxRetries:       3,
+			expectedAttempts: 1,
+		},
+		"retried_conflict": {
+			errs:             []error{conflict, conflict, nil},
+			maxRetries:       3,
+			expectedAttempts: 3,
+		},
+		"retries_exhausted": {
+			errs:             []error{conflict, conflict, conflict},
+			maxRetries:       2,
+			expectedErr:      conflict,
+			expectedAttempts: 3,
+		},
+		"other_error_not_retried": {
+			errs:             []error{errors.New("boom"), nil},
+			maxRetries:       3,
+			expectedErr:      errors.New("boom"),
+			expectedAttempts: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			attempts := 0
+			err := retry(context.Background(), tc.maxRetries, func() error {
+				attempts++
+				return tc.errs[attempts-1]
+			})
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedAttempts, attempts)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+
+	ctx := NewContext(context.Background(), "abc-123")
+	assert.Equal(t, "abc-123", FromContext(ctx))
+}
+
+func TestGenerate(t *testing.T) {
+	first := Generate()
+	second := Generate()
+
+	assert.Len(t, first, 32)
+	assert.NotEqual(t, first, second)
+	assert.True(t, Valid(first))
+}
+
+func TestValid(t *testing.T) {
+	cases := map[string]struct {
+		id    string
+		valid bool
+	}{
+		"uuid":       {id: "5f0c7a3e-0b1e-4c8e-9a7a-2d6f3c1b9e44", valid: true},
+		"dotted":     {id: "trace:1.2_3", valid: true},
+		"empty":      {id: "", valid: false},
+		"too_long":   {id: strings.Repeat("a", 129), valid: false},
+		"space":      {id: "abc def", valid: false},
+		"newline":    {id: "abc\ndef", valid: false},
+		"quote":      {id: `abc"`, valid: false},
+		"non_ascii":  {id: "abcé", valid: false},
+		"max_length": {id: strings.Repeat("a", 128), valid: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.valid, Valid(tc.id))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
+)
+
+// IntrospectionResponse is the token introspection response of the authority, see RFC 7662 section 2.2. Only Active
+// is set for inactive tokens
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	TokenType string   `json:"token_type,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Roles     []Role   `json:"roles,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	SessionId string   `json:"sid,omitempty"`
+	ClientId  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+}
+
+// NewIntrospectionResponse describes an active token
+func NewIntrospectionResponse(payload *JwtPayload) *IntrospectionResponse {
+	return &IntrospectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Subject:   payload.UserId,
+		Roles:     payload.Roles,
+		Issuer:    payload.Issuer,
+		Audience:  payload.Audience,
+		ExpiresAt: payload.ExpiresAt.Unix(),
+		IssuedAt:  payload.IssuedAt.Unix(),
+		SessionId: payload.SessionId,
+		ClientId:  payload.ClientId,
+		Scope:     payload.Scope,
+	}
+}
+
+var ErrTokenInactive = errors.New("token is not active")
+
+// AuthorityClient validates and revokes tokens via the introspection (RFC 7662) and revocation (RFC 7009) endpoints
+// of the authority. It is meant for services which accept Course Watch tokens, but do not share the signing key, see
+// JwtHandler.ParseWithoutSignature. The service must be registered as a confidential OAuth client
+type AuthorityClient struct {
+	introspectURL string
+	revokeURL     string
+	clientId      string
+	clientSecret  string
+	httpClient    *http.Client
+}
+
+// NewAuthorityClient creates the client. baseURL is the versioned API root of the authority, e.g.
+// https://localhost:8080/api/v1. If httpClient is nil, a client with a 10 second timeout is used
+func NewAuthorityClient(baseURL string, clientId string, clientSecret string, httpClient *http.Client) *AuthorityClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &AuthorityClient{
+		introspectURL: baseURL + "/auth/introspect",
+		revokeURL:     baseURL + "/auth/revoke",
+		clientId:      clientId,
+		clientSecret:  clientSecret,
+		httpClient:    httpClient,
+	}
+}
+
+// Introspect asks the authority whether the token is active. An inactive token is not an error, check
+// IntrospectionResponse.Active
+func (c *AuthorityClient) Introspect(ctx context.Context, token string) (*IntrospectionResponse, error) {
+	resp, err := c.post(ctx, c.introspectURL, token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token introspection failed with status %d", resp.StatusCode)
+	}
+	var result IntrospectionResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid token introspection response: %w", err)
+	}
+	return &result, nil
+}
+
+// Authenticate returns the principal of an active token, and ErrTokenInactive otherwise. Tokens issued to OAuth
+// clients keep their client id and scope, so that callers can tell them from first-party sessions
+func (c *AuthorityClient) Authenticate(ctx context.Context, token string) (*UserPrincipal, error) {
+	result, err := c.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Active {
+		return nil, ErrTokenInactive
+	}
+	return &UserPrincipal{
+		UserId:    result.Subject,
+		Roles:     result.Roles,
+		SessionId: result.SessionId,
+		ClientId:  result.ClientId,
+		Scope:     result.Scope,
+	}, nil
+}
+
+// Revoke ends the login session the token was issued for. Revoking an invalid or inactive token succeeds
+func (c *AuthorityClient) Revoke(ctx context.Context, token string) error {
+	resp, err := c.post(ctx, c.revokeURL, token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token revocation failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *AuthorityClient) post(ctx context.Context, endpoint string, token string) (*http.Response, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	// client_secret_basic: credentials are form-encoded before being put into the header, see RFC 6749 section 2.3.1
+	req.SetBasicAuth(url.QueryEscape(c.clientId), url.QueryEscape(c.clientSecret))
+	return c.httpClient.Do(req)
+}
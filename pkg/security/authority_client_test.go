@@ -0,0 +1,158 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zhuravlev-pe/course-watch/pkg/requestid"
+)
+
+const (
+	testClientId     = "resource server"
+	testClientSecret = "s3cret&more"
+	testActiveToken  = "active.token"
+	testClientToken  = "client.token"
+)
+
+// fakeAuthority mimics the introspection and revocation endpoints of the API
+func fakeAuthority(t *testing.T, revoked *[]string) *httptest.Server {
+	t.Helper()
+	authenticate := func(w http.ResponseWriter, r *http.Request) bool {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "resource+server" || secret != "s3cret%26more" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/auth/introspect", func(w http.ResponseWriter, r *http.Request) {
+		if !authenticate(w, r) {
+			return
+		}
+		assert.Equal(t, "access_token", r.PostFormValue("token_type_hint"))
+		response := &IntrospectionResponse{Active: false}
+		switch r.PostFormValue("token") {
+		case testActiveToken:
+			response = &IntrospectionResponse{
+				Active:    true,
+				Subject:   "1111111",
+				Roles:     []Role{Student, Admin},
+				ExpiresAt: 1700000000,
+				SessionId: "2222222",
+			}
+		case testClientToken:
+			response = &IntrospectionResponse{
+				Active:    true,
+				Subject:   "1111111",
+				ExpiresAt: 1700000000,
+				SessionId: "3333333",
+				ClientId:  "partner-app",
+				Scope:     "openid profile",
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	})
+	mux.HandleFunc("/api/v1/auth/revoke", func(w http.ResponseWriter, r *http.Request) {
+		if !authenticate(w, r) {
+			return
+		}
+		*revoked = append(*revoked, r.PostFormValue("token"))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuthorityClient_Introspect(t *testing.T) {
+	server := fakeAuthority(t, nil)
+	client := NewAuthorityClient(server.URL+"/api/v1/", testClientId, testClientSecret, server.Client())
+	ctx := context.Background()
+
+	active, err := client.Introspect(ctx, testActiveToken)
+	require.NoError(t, err)
+	assert.True(t, active.Active)
+	assert.Equal(t, "1111111", active.Subject)
+	assert.Equal(t, int64(1700000000), active.ExpiresAt)
+
+	inactive, err := client.Introspect(ctx, "other.token")
+	require.NoError(t, err)
+	assert.False(t, inactive.Active)
+
+	_, err = NewAuthorityClient(server.URL+"/api/v1", testClientId, "wrong", server.Client()).Introspect(ctx, testActiveToken)
+	assert.Error(t, err)
+}
+
+func TestAuthorityClient_PropagatesRequestId(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(requestid.Header)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"active":false}`))
+	}))
+	t.Cleanup(server.Close)
+	client := NewAuthorityClient(server.URL, testClientId, testClientSecret, server.Client())
+
+	_, err := client.Introspect(requestid.NewContext(context.Background(), "request-1"), testActiveToken)
+
+	require.NoError(t, err)
+	assert.Equal(t, "request-1", received)
+}
+
+func TestAuthorityClient_Authenticate(t *testing.T) {
+	server := fakeAuthority(t, nil)
+	client := NewAuthorityClient(server.URL+"/api/v1", testClientId, testClientSecret, server.Client())
+	ctx := context.Background()
+
+	up, err := client.Authenticate(ctx, testActiveToken)
+	require.NoError(t, err)
+	assert.Equal(t, &UserPrincipal{UserId: "1111111", Roles: []Role{Student, Admin}, SessionId: "2222222"}, up)
+
+	_, err = client.Authenticate(ctx, "other.token")
+	assert.ErrorIs(t, err, ErrTokenInactive)
+}
+
+func TestAuthorityClient_AuthenticateClientToken(t *testing.T) {
+	server := fakeAuthority(t, nil)
+	client := NewAuthorityClient(server.URL+"/api/v1", testClientId, testClientSecret, server.Client())
+
+	up, err := client.Authenticate(context.Background(), testClientToken)
+	require.NoError(t, err)
+	assert.Equal(t, &UserPrincipal{UserId: "1111111", SessionId: "3333333", ClientId: "partner-app", Scope: "openid profile"}, up)
+	assert.False(t, up.IsAdmin())
+}
+
+func TestAuthorityClient_Revoke(t *testing.T) {
+	var revoked []string
+	server := fakeAuthority(t, &revoked)
+	client := NewAuthorityClient(server.URL+"/api/v1", testClientId, testClientSecret, server.Client())
+
+	require.NoError(t, client.Revoke(context.Background(), testActiveToken))
+	assert.Equal(t, []string{testActiveToken}, revoked)
+
+	err := NewAuthorityClient(server.URL+"/api/v1", testClientId, "wrong", server.Client()).Revoke(context.Background(), testActiveToken)
+	assert.Error(t, err)
+}
+
+func TestNewIntrospectionResponse(t *testing.T) {
+	handler := getReferenceJwtHandler()
+	token, err := handler.Generate(getReferenceUser())
+	require.NoError(t, err)
+	payload, err := handler.Parse(token)
+	require.NoError(t, err)
+
+	response := NewIntrospectionResponse(payload)
+
+	assert.True(t, response.Active)
+	assert.Equal(t, "Bearer", response.TokenType)
+	assert.Equal(t, payload.UserId, response.Subject)
+	assert.Equal(t, payload.Roles, response.Roles)
+	assert.Equal(t, payload.Audience, response.Audience)
+	assert.Equal(t, payload.ExpiresAt.Unix(), response.ExpiresAt)
+}
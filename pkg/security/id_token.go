@@ -0,0 +1,210 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JSONWebKey is the public part of a signing key, see https://datatracker.ietf.org/doc/html/rfc7517#section-4. RSA
+// keys have a modulus and exponent, EC keys a curve and coordinates
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Modulus   string `json:"n,omitempty"`
+	Exponent  string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	KeyId     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// minRSAKeyBits is the smallest RSA key allowed for RS256, see https://datatracker.ietf.org/doc/html/rfc7518#section-3.3
+const minRSAKeyBits = 2048
+
+// IdTokenKey is a private key ID tokens are signed with, along with its key id, which tells relying parties which of
+// the published keys verifies a token
+type IdTokenKey struct {
+	id     string
+	key    crypto.Signer
+	method jwt.SigningMethod
+}
+
+// ParseIdTokenKey reads a PEM encoded RSA (PKCS #1 or PKCS #8) or EC (SEC 1 or PKCS #8) private key. RSA keys sign
+// with RS256, and EC keys with the ES algorithm of their curve. When id is empty, the JWK thumbprint is used
+func ParseIdTokenKey(pemData []byte, id string) (*IdTokenKey, error) {
+	for rest := pemData; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("no PEM encoded private key found")
+		}
+		var key interface{}
+		var err error
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		default:
+			// e.g. the EC PARAMETERS block openssl ecparam -genkey writes before the key
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return newIdTokenKey(key, id)
+	}
+}
+
+// GenerateIdTokenKey creates a random RSA key, for deployments which do not configure one
+func GenerateIdTokenKey() (*IdTokenKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		return nil, err
+	}
+	return newIdTokenKey(key, "")
+}
+
+func newIdTokenKey(key interface{}, id string) (*IdTokenKey, error) {
+	result := &IdTokenKey{id: id}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must have at least %d bits", minRSAKeyBits)
+		}
+		result.key, result.method = k, jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			result.method = jwt.SigningMethodES256
+		case elliptic.P384():
+			result.method = jwt.SigningMethodES384
+		case elliptic.P521():
+			result.method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %s", k.Curve.Params().Name)
+		}
+		result.key = k
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T, expected RSA or EC", key)
+	}
+	if result.id == "" {
+		result.id = result.publicKey().thumbprint()
+	}
+	return result, nil
+}
+
+// Id is the kid of the tokens the key signs
+func (k *IdTokenKey) Id() string {
+	return k.id
+}
+
+func (k *IdTokenKey) publicKey() JSONWebKey {
+	jwk := JSONWebKey{KeyId: k.id, Algorithm: k.method.Alg(), Use: "sig"}
+	switch public := k.key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// the coordinates are padded to the size of the curve, see https://datatracker.ietf.org/doc/html/rfc7518#section-6.2.1.2
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = public.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+	}
+	return jwk
+}
+
+// thumbprint is the JWK thumbprint, see https://datatracker.ietf.org/doc/html/rfc7638. The members are base64url
+// values and curve names, which need no JSON escaping
+func (k JSONWebKey) thumbprint() string {
+	var canonical string
+	if k.KeyType == "EC" {
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, k.Curve, k.X, k.Y)
+	} else {
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, k.Exponent, k.Modulus)
+	}
+	hash := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// IdTokenSigner issues OpenID Connect ID tokens. Unlike access tokens, ID tokens are verified by third parties,
+// so they are signed with an asymmetric key published via JWKS
+type IdTokenSigner struct {
+	issuer string
+	ttl    time.Duration
+	key    *IdTokenKey
+	keySet *JSONWebKeySet
+}
+
+// NewIdTokenSigner signs with key. The previous keys are only published, so that the tokens they signed before a key
+// rotation can still be verified until they expire
+func NewIdTokenSigner(issuer string, ttl time.Duration, key *IdTokenKey, previous ...*IdTokenKey) (*IdTokenSigner, error) {
+	if key == nil {
+		return nil, errors.New("no ID token key")
+	}
+	keySet := &JSONWebKeySet{}
+	seen := make(map[string]bool)
+	for _, k := range append([]*IdTokenKey{key}, previous...) {
+		if seen[k.id] {
+			return nil, fmt.Errorf("duplicate ID token key id %q", k.id)
+		}
+		seen[k.id] = true
+		keySet.Keys = append(keySet.Keys, k.publicKey())
+	}
+	return &IdTokenSigner{
+		issuer: issuer,
+		ttl:    ttl,
+		key:    key,
+		keySet: keySet,
+	}, nil
+}
+
+func (s *IdTokenSigner) Algorithm() string {
+	return s.key.method.Alg()
+}
+
+// Sign issues an ID token for the subject. Claims are added on top of the registered ones (iss, sub, aud, iat, exp)
+func (s *IdTokenSigner) Sign(subject string, audience string, claims map[string]interface{}) (string, error) {
+	now := time.Now()
+	all := jwt.MapClaims{}
+	for k, v := range claims {
+		all[k] = v
+	}
+	all["iss"] = s.issuer
+	all["sub"] = subject
+	all["aud"] = audience
+	all["iat"] = now.Unix()
+	all["exp"] = now.Add(s.ttl).Unix()
+
+	token := jwt.NewWithClaims(s.key.method, all)
+	token.Header["kid"] = s.key.id
+	return token.SignedString(s.key.key)
+}
+
+// KeySet lists the public keys, the signing key first
+func (s *IdTokenSigner) KeySet() *JSONWebKeySet {
+	return s.keySet
+}
@@ -0,0 +1,235 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePEM(t *testing.T, blockType string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func encodePKCS8(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return encodePEM(t, "PRIVATE KEY", der)
+}
+
+func encodeEC(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return encodePEM(t, "EC PRIVATE KEY", der)
+}
+
+func generateRSA(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	return key
+}
+
+func generateEC(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+// jwkPublicKey is what a relying party reconstructs from the published key
+func jwkPublicKey(t *testing.T, jwk JSONWebKey) crypto.PublicKey {
+	t.Helper()
+	decode := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return new(big.Int).SetBytes(b)
+	}
+	switch jwk.KeyType {
+	case "RSA":
+		return &rsa.PublicKey{N: decode(jwk.Modulus), E: int(decode(jwk.Exponent).Int64())}
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		require.Contains(t, curves, jwk.Curve)
+		return &ecdsa.PublicKey{Curve: curves[jwk.Curve], X: decode(jwk.X), Y: decode(jwk.Y)}
+	}
+	t.Fatalf("unexpected key type %q", jwk.KeyType)
+	return nil
+}
+
+func TestParseIdTokenKey(t *testing.T) {
+	rsaKey := generateRSA(t, 2048)
+	p256 := generateEC(t, elliptic.P256())
+	p384 := generateEC(t, elliptic.P384())
+
+	cases := map[string]struct {
+		pem       []byte
+		algorithm string
+		keyType   string
+		wantErr   bool
+	}{
+		"rsa_pkcs1": {
+			pem:       encodePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			algorithm: "RS256",
+			keyType:   "RSA",
+		},
+		"rsa_pkcs8": {
+			pem:       encodePKCS8(t, rsaKey),
+			algorithm: "RS256",
+			keyType:   "RSA",
+		},
+		"ec_with_parameters": {
+			pem:       append(encodePEM(t, "EC PARAMETERS", []byte{0x06, 0x08}), encodeEC(t, p256)...),
+			algorithm: "ES256",
+			keyType:   "EC",
+		},
+		"ec_pkcs8": {
+			pem:       encodePKCS8(t, p384),
+			algorithm: "ES384",
+			keyType:   "EC",
+		},
+		"not_pem": {
+			pem:     []byte("secret"),
+			wantErr: true,
+		},
+		"public_key_only": {
+			pem:     encodePEM(t, "PUBLIC KEY", []byte{0x30}),
+			wantErr: true,
+		},
+		"short_rsa": {
+			pem:     encodePKCS8(t, generateRSA(t, 1024)),
+			wantErr: true,
+		},
+		"unsupported_curve": {
+			pem:     encodeEC(t, generateEC(t, elliptic.P224())),
+			wantErr: true,
+		},
+		"ed25519": {
+			pem: func() []byte {
+				_, key, err := ed25519.GenerateKey(rand.Reader)
+				require.NoError(t, err)
+				return encodePKCS8(t, key)
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			key, err := ParseIdTokenKey(tc.pem, "key-1")
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "key-1", key.Id())
+			jwk := key.publicKey()
+			assert.Equal(t, tc.keyType, jwk.KeyType)
+			assert.Equal(t, tc.algorithm, jwk.Algorithm)
+			assert.Equal(t, "key-1", jwk.KeyId)
+		})
+	}
+}
+
+func TestParseIdTokenKey_DefaultsIdToThumbprint(t *testing.T) {
+	key, err := ParseIdTokenKey(encodePKCS8(t, generateRSA(t, 2048)), "")
+	require.NoError(t, err)
+	assert.Equal(t, key.publicKey().thumbprint(), key.Id())
+}
+
+func TestJSONWebKey_Thumbprint(t *testing.T) {
+	// the example of https://datatracker.ietf.org/doc/html/rfc7638#section-3.1
+	jwk := JSONWebKey{
+		KeyType:  "RSA",
+		Modulus:  "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		Exponent: "AQAB",
+		KeyId:    "2011-04-29",
+	}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwk.thumbprint())
+}
+
+func TestIdTokenSigner_SignVerifiesWithPublishedKey(t *testing.T) {
+	cases := map[string]struct {
+		pem       []byte
+		algorithm string
+	}{
+		"rsa": {
+			pem:       encodePKCS8(t, generateRSA(t, 2048)),
+			algorithm: "RS256",
+		},
+		"ec": {
+			pem:       encodeEC(t, generateEC(t, elliptic.P256())),
+			algorithm: "ES256",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			key, err := ParseIdTokenKey(tc.pem, "")
+			require.NoError(t, err)
+			signer, err := NewIdTokenSigner(testAuthority, testTokenTtl, key)
+			require.NoError(t, err)
+			assert.Equal(t, tc.algorithm, signer.Algorithm())
+
+			tokenString, err := signer.Sign("user-1", "client-1", map[string]interface{}{"nonce": "abc", "sub": "ignored"})
+			require.NoError(t, err)
+
+			keys := signer.KeySet().Keys
+			require.Len(t, keys, 1)
+			assert.Equal(t, tc.algorithm, keys[0].Algorithm)
+			claims := jwt.MapClaims{}
+			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+				assert.Equal(t, keys[0].KeyId, token.Header["kid"])
+				return jwkPublicKey(t, keys[0]), nil
+			}, jwt.WithValidMethods([]string{tc.algorithm}))
+			require.NoError(t, err)
+			require.True(t, token.Valid)
+
+			assert.Equal(t, testAuthority, claims["iss"])
+			assert.Equal(t, "user-1", claims["sub"])
+			assert.Equal(t, "client-1", claims["aud"])
+			assert.Equal(t, "abc", claims["nonce"])
+			assert.InDelta(t, time.Now().Add(testTokenTtl).Unix(), claims["exp"], 5)
+		})
+	}
+}
+
+func TestIdTokenSigner_PublishesPreviousKeys(t *testing.T) {
+	current, err := ParseIdTokenKey(encodeEC(t, generateEC(t, elliptic.P256())), "2026-10")
+	require.NoError(t, err)
+	previous, err := ParseIdTokenKey(encodePKCS8(t, generateRSA(t, 2048)), "2026-04")
+	require.NoError(t, err)
+
+	signer, err := NewIdTokenSigner(testAuthority, testTokenTtl, current, previous)
+	require.NoError(t, err)
+
+	keys := signer.KeySet().Keys
+	require.Len(t, keys, 2)
+	assert.Equal(t, "2026-10", keys[0].KeyId)
+	assert.Equal(t, "2026-04", keys[1].KeyId)
+	assert.Equal(t, "ES256", signer.Algorithm())
+	tokenString, err := signer.Sign("user-1", "client-1", nil)
+	require.NoError(t, err)
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10", token.Header["kid"])
+
+	_, err = NewIdTokenSigner(testAuthority, testTokenTtl, current, current)
+	assert.Error(t, err)
+}
@@ -9,7 +9,10 @@ import (
 
 type bearerTokenClaims struct {
 	jwt.RegisteredClaims
-	Roles []Role `json:"roles"`
+	Roles     []Role `json:"roles"`
+	SessionId string `json:"sid,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
 }
 
 func (btc *bearerTokenClaims) Valid() error {
@@ -22,6 +25,9 @@ func (btc *bearerTokenClaims) toPayload() *JwtPayload {
 	payload := &JwtPayload{}
 	payload.UserId = btc.Subject
 	payload.Roles = btc.Roles
+	payload.SessionId = btc.SessionId
+	payload.ClientId = btc.ClientId
+	payload.Scope = btc.Scope
 	payload.Issuer = btc.Issuer
 	payload.Audience = btc.Audience
 	if btc.ExpiresAt != nil {
@@ -90,6 +96,9 @@ func (jh *JwtHandler) generateClaims(principal *UserPrincipal) *bearerTokenClaim
 	btc.Audience = jh.AudienceGenerated
 	btc.Subject = principal.UserId
 	btc.Roles = principal.Roles
+	btc.SessionId = principal.SessionId
+	btc.ClientId = principal.ClientId
+	btc.Scope = principal.Scope
 
 	now := time.Now()
 	btc.IssuedAt = jwt.NewNumericDate(now)
@@ -117,6 +126,7 @@ func (jh *JwtHandler) Parse(tokenString string) (*JwtPayload, error) {
 	var btc bearerTokenClaims
 	_, err := jh.parser.ParseWithClaims(tokenString, &btc, jh.keyFunc)
 	if err != nil {
+		countValidationFailure(err)
 		return nil, err
 	}
 	payload := btc.toPayload()
@@ -125,18 +135,27 @@ func (jh *JwtHandler) Parse(tokenString string) (*JwtPayload, error) {
 
 // ParseWithoutSignature parses and validates a JWT token string, while ignoring signature
 // May be used by client services which reuse tokens generated by a remote authority
-// Signature must be validated by other means (e.g. by passing the token to the authority via an endpoint)
+// Signature must be validated by other means (e.g. by passing the token to the authority via an endpoint, see
+// AuthorityClient)
 func (jh *JwtHandler) ParseWithoutSignature(tokenString string) (*JwtPayload, error) {
 	var btc bearerTokenClaims
 	_, _, err := jh.parser.ParseUnverified(tokenString, &btc)
 	if err != nil {
+		countValidationFailure(err)
 		return nil, err
 	}
 	payload := btc.toPayload()
 	return payload, jh.validateClaims(&btc)
 }
 
+// validateClaims checks the claims and counts failures by reason
 func (jh *JwtHandler) validateClaims(btc *bearerTokenClaims) error {
+	err := jh.checkClaims(btc)
+	countValidationFailure(err)
+	return err
+}
+
+func (jh *JwtHandler) checkClaims(btc *bearerTokenClaims) error {
 	if err := jh.validateTimestamps(btc); err != nil {
 		return err
 	}
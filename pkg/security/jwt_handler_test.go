@@ -41,7 +41,7 @@ func getReferenceJwtHandler() *JwtHandler {
 }
 
 func getReferenceUser() *UserPrincipal {
-	return &UserPrincipal{"1111111", []Role{Student}}
+	return &UserPrincipal{UserId: "1111111", Roles: []Role{Student}}
 }
 
 func decodeSegment(t *testing.T, seg string) []byte {
@@ -126,6 +126,21 @@ func TestTokenHandler_GenerateAndParseBack(t *testing.T) {
 	require.Equal(t, jh.AudienceGenerated, payload.Audience)
 }
 
+func TestTokenHandler_GenerateAndParseBack_ClientToken(t *testing.T) {
+	jh := getReferenceJwtHandler()
+	up := &UserPrincipal{UserId: "1111111", SessionId: "2222222", ClientId: "client-1", Scope: "openid email"}
+
+	tokenString, err := jh.Generate(up)
+	require.NoError(t, err)
+	btc := decodeClaims(t, tokenString)
+	require.Equal(t, "client-1", btc.ClientId)
+	require.Equal(t, "openid email", btc.Scope)
+
+	payload, err := jh.Parse(tokenString)
+	require.NoError(t, err)
+	require.Equal(t, up, &payload.UserPrincipal)
+}
+
 func TestTokenHandler_ParseDetectsInvalidSignature(t *testing.T) {
 	jh := getReferenceJwtHandler()
 	up := getReferenceUser()
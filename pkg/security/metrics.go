@@ -0,0 +1,47 @@
+package security
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var tokenValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "course_watch",
+	Name:      "token_validation_failures_total",
+	Help:      "Number of rejected bearer tokens by reason.",
+}, []string{"reason"})
+
+// validationFailureReason names the first failed check of a token validation error
+func validationFailureReason(err error) string {
+	var vErr *jwt.ValidationError
+	if !errors.As(err, &vErr) {
+		return "other"
+	}
+	switch {
+	case vErr.Errors&jwt.ValidationErrorMalformed != 0:
+		return "malformed"
+	case vErr.Errors&(jwt.ValidationErrorSignatureInvalid|jwt.ValidationErrorUnverifiable) != 0:
+		return "signature"
+	case vErr.Errors&jwt.ValidationErrorExpired != 0:
+		return "expired"
+	case vErr.Errors&jwt.ValidationErrorNotValidYet != 0:
+		return "not_valid_yet"
+	case vErr.Errors&jwt.ValidationErrorIssuedAt != 0:
+		return "issued_in_future"
+	case vErr.Errors&jwt.ValidationErrorIssuer != 0:
+		return "issuer"
+	case vErr.Errors&jwt.ValidationErrorAudience != 0:
+		return "audience"
+	default:
+		return "other"
+	}
+}
+
+func countValidationFailure(err error) {
+	if err != nil {
+		tokenValidationFailures.WithLabelValues(validationFailureReason(err)).Inc()
+	}
+}
@@ -0,0 +1,81 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJwtHandler_CountsValidationFailures(t *testing.T) {
+	generate := func(t *testing.T, modify func(jh *JwtHandler)) string {
+		t.Helper()
+		jh := getReferenceJwtHandler()
+		modify(jh)
+		tokenString, err := jh.Generate(getReferenceUser())
+		require.NoError(t, err)
+		return tokenString
+	}
+
+	cases := map[string]struct {
+		token  string
+		reason string
+	}{
+		"expired": {
+			token:  generateModifiedReferenceTokenString(t, offsetTimestamps(-2*testTokenTtl)),
+			reason: "expired",
+		},
+		"not_valid_yet": {
+			token:  generateModifiedReferenceTokenString(t, offsetTimestamps(testTokenTtl/2)),
+			reason: "not_valid_yet",
+		},
+		"issuer": {
+			token:  generate(t, func(jh *JwtHandler) { jh.Issuer = "some-other-authority" }),
+			reason: "issuer",
+		},
+		"audience": {
+			token:  generate(t, func(jh *JwtHandler) { jh.AudienceGenerated = []string{"some-other-api"} }),
+			reason: "audience",
+		},
+		"signature": {
+			token:  generate(t, func(jh *JwtHandler) { jh.SigningKey = []byte("42-42-42") }),
+			reason: "signature",
+		},
+		"malformed": {
+			token:  "not a token",
+			reason: "malformed",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			counter := tokenValidationFailures.WithLabelValues(tc.reason)
+			before := testutil.ToFloat64(counter)
+
+			_, err := getReferenceJwtHandler().Parse(tc.token)
+
+			require.Error(t, err)
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
+
+func TestJwtHandler_ValidTokenIsNotCounted(t *testing.T) {
+	jh := getReferenceJwtHandler()
+	tokenString, err := jh.Generate(getReferenceUser())
+	require.NoError(t, err)
+	total := func() float64 {
+		var sum float64
+		for _, reason := range []string{"malformed", "signature", "expired", "not_valid_yet", "issued_in_future", "issuer", "audience", "other"} {
+			sum += testutil.ToFloat64(tokenValidationFailures.WithLabelValues(reason))
+		}
+		return sum
+	}
+	sumBefore := total()
+
+	_, err = jh.Parse(tokenString)
+
+	require.NoError(t, err)
+	assert.Equal(t, sumBefore, total())
+}
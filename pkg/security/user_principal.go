@@ -1,9 +1,19 @@
 package security
 
+import "context"
+
 // UserPrincipal represents core user identification data (claims) passed via security tokens
 type UserPrincipal struct {
 	UserId string
 	Roles  []Role
+
+	// SessionId identifies the login session the token was issued for. Empty for tokens not bound to a session
+	SessionId string
+
+	// ClientId is the OAuth client the token was issued to, and Scope the space separated scopes the user granted it.
+	// Both are empty for first-party tokens. Client tokens carry no roles
+	ClientId string
+	Scope    string
 }
 
 func (up *UserPrincipal) HasRole(role Role) bool {
@@ -18,3 +28,16 @@ func (up *UserPrincipal) HasRole(role Role) bool {
 func (up *UserPrincipal) IsAdmin() bool {
 	return up.HasRole(Admin)
 }
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying the authenticated user, for the layers below the HTTP handlers
+func ContextWithPrincipal(ctx context.Context, up *UserPrincipal) context.Context {
+	return context.WithValue(ctx, principalKey{}, up)
+}
+
+// PrincipalFromContext returns the authenticated user stored in ctx, or nil for anonymous requests
+func PrincipalFromContext(ctx context.Context) *UserPrincipal {
+	up, _ := ctx.Value(principalKey{}).(*UserPrincipal)
+	return up
+}
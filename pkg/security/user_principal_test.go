@@ -1,6 +1,7 @@
 package security
 
 import (
+	"context"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -93,3 +94,10 @@ func TestUserPrincipal_IsAdmin(t *testing.T) {
 		})
 	}
 }
+
+func TestPrincipalFromContext(t *testing.T) {
+	up := &UserPrincipal{UserId: "42"}
+
+	require.Same(t, up, PrincipalFromContext(ContextWithPrincipal(context.Background(), up)))
+	require.Nil(t, PrincipalFromContext(context.Background()))
+}
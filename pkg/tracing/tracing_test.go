@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup_StdoutToFile(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+	file := filepath.Join(t.TempDir(), "traces.json")
+	ctx := context.Background()
+
+	shutdown, err := Setup(ctx, Options{ServiceName: "course-watch-test", Exporter: ExporterStdout, File: file, SampleRatio: 1})
+	require.NoError(t, err)
+	_, span := otel.Tracer("test").Start(ctx, "test-span")
+	span.End()
+	require.NoError(t, shutdown(ctx))
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"Name":"test-span"`)
+	assert.Contains(t, string(content), "course-watch-test")
+}
+
+func TestSetup_None(t *testing.T) {
+	ctx := context.Background()
+
+	shutdown, err := Setup(ctx, Options{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(ctx))
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+	assert.Error(t, err)
+}